@@ -1,4 +1,323 @@
 package main
 
+import (
+	"context"
+	"diary/internal/config"
+	"diary/internal/database"
+	"diary/internal/handlers"
+	"diary/internal/migrations"
+	"diary/internal/repos"
+	"diary/internal/services"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/supertokens/supertokens-golang/recipe/emailpassword"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+	"github.com/supertokens/supertokens-golang/supertokens"
+	"gorm.io/gorm"
+)
+
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "migrate" {
+		return runMigrate(args[1:])
+	}
+
+	cfg, err := config.Load("diary", args)
+	if err != nil {
+		return err
+	}
+
+	// Хранилище записей
+	repo, closeRepo, err := openRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeRepo(); err != nil {
+			log.Printf("diary: close storage: %v", err)
+		}
+	}()
+
+	// Аутентификация и роли
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return err
+	}
+	admins, err := cfg.AdminIDs()
+	if err != nil {
+		return err
+	}
+
+	// Слои приложения
+	service := services.NewService(repo, services.EntryLimits{
+		MaxTitleLength:   cfg.Limits.MaxTitleLength,
+		MaxContentLength: cfg.Limits.MaxContentLength,
+		MaxTags:          cfg.Limits.MaxTags,
+	})
+	handler := handlers.NewHandler(service, auth, handlers.NewStaticRoles(admins))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	if cfg.RequestTimeout > 0 {
+		r.Use(requestTimeout(cfg.RequestTimeout.Std()))
+	}
+	if cfg.Auth == config.AuthSuperTokens {
+		r.Use(supertokens.Middleware)
+	}
+	handler.RegisterRoutes(r)
+
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout.Std(),
+		WriteTimeout: cfg.WriteTimeout.Std(),
+		IdleTimeout:  cfg.IdleTimeout.Std(),
+	}
+
+	// Фоновые задачи завершаются до закрытия базы данных: stop отменяет ctx
+	// раньше, чем срабатывает ожидание
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Контекст отменяется по SIGINT/SIGTERM и останавливает сервер и фоновые задачи
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.TrashRetention > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			purgeTrash(ctx, service, cfg.TrashRetention.Std(), cfg.TrashPurgeInterval.Std())
+		}()
+	}
+
+	return serve(ctx, stop, srv, cfg.ShutdownTimeout.Std())
+}
+
+// Открывает хранилище, выбранное в конфигурации. Для базы данных
+// проверяет схему. Возвращаемая функция закрывает соединения с базой
+// или сохраняет снимок хранилища в памяти.
+func openRepository(cfg *config.Config) (repos.Repository, func() error, error) {
+	switch cfg.Storage {
+	case config.StorageFiles:
+		repo, err := repos.NewFileRepository(cfg.FilesPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("diary: storing entries as files in %s", cfg.FilesPath)
+		return repo, func() error { return nil }, nil
+	case config.StorageMemory:
+		return openMemoryRepository(cfg.MemorySnapshot)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := prepareDatabase(context.Background(), db, cfg.MigrateOnStart); err != nil {
+		sqlDB.Close()
+		return nil, nil, err
+	}
+	return repos.NewRepository(db), sqlDB.Close, nil
+}
+
+// Демо-режим: записи хранятся в памяти и, если задан файл снимка,
+// сохраняются в него при остановке сервера
+func openMemoryRepository(snapshot string) (repos.Repository, func() error, error) {
+	if snapshot == "" {
+		log.Printf("diary: demo mode, entries are kept in memory and lost on shutdown")
+		return repos.NewMemoryRepository(), func() error { return nil }, nil
+	}
+	repo, err := repos.OpenMemoryRepository(snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("diary: demo mode, entries are kept in memory and saved to %s on shutdown", snapshot)
+	return repo, repo.SaveSnapshot, nil
+}
+
+// Открывает базу драйвером из конфигурации
+func openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	return database.Open(cfg.DatabaseDriver, cfg.DataSource())
+}
+
+// Проверяет версию схемы, при необходимости применяет миграции и создает
+// поисковый индекс. Сервер не запускается поверх схемы, которую обновила
+// более новая версия приложения, и поверх непримененных миграций.
+func prepareDatabase(ctx context.Context, db *gorm.DB, migrate bool) error {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(ctx); err != nil {
+		return fmt.Errorf("refusing to start: %w", err)
+	}
+
+	if migrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			log.Printf("diary: applied migration %04d_%s", m.Version, m.Name)
+		}
+	} else {
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version < migrator.Latest() {
+			return fmt.Errorf("refusing to start: database schema is at version %d, latest is %d; run \"diary migrate up\"", version, migrator.Latest())
+		}
+	}
+
+	// Полнотекстовый индекс; без FTS5 поиск работает через LIKE
+	fts, err := repos.SetupSearchIndex(db)
+	if err != nil {
+		return fmt.Errorf("setup search index: %w", err)
+	}
+	if !fts {
+		log.Printf("diary: SQLite built without FTS5 (build with -tags sqlite_fts5), falling back to simple search")
+	}
+	return nil
+}
+
+// Аутентификатор, выбранный в конфигурации
+func newAuthenticator(cfg *config.Config) (handlers.Authenticator, error) {
+	switch cfg.Auth {
+	case config.AuthJWT:
+		opts := handlers.JWTOptions{Issuer: cfg.JWT.Issuer, Audience: cfg.JWT.Audience}
+		if cfg.JWT.Secret != "" {
+			return handlers.NewHS256Authenticator([]byte(cfg.JWT.Secret), opts), nil
+		}
+		jwks, err := os.ReadFile(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWKS file: %w", err)
+		}
+		return handlers.NewJWKSAuthenticator(jwks, opts)
+	case config.AuthNone:
+		userID, err := uuid.Parse(cfg.SingleUserID)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("diary: authentication disabled, all requests act as user %s", userID)
+		return handlers.NewSingleUserAuthenticator(userID), nil
+	}
+
+	if err := initSuperTokens(cfg); err != nil {
+		return nil, fmt.Errorf("init supertokens: %w", err)
+	}
+	return handlers.NewSuperTokensAuthenticator(), nil
+}
+
+func initSuperTokens(cfg *config.Config) error {
+	st := cfg.SuperTokens
+	return supertokens.Init(supertokens.TypeInput{
+		Supertokens: &supertokens.ConnectionInfo{
+			ConnectionURI: st.ConnectionURI,
+			APIKey:        st.APIKey,
+		},
+		AppInfo: supertokens.AppInfo{
+			AppName:       st.AppName,
+			APIDomain:     st.APIDomain,
+			WebsiteDomain: st.WebsiteDomain,
+			APIBasePath:   &st.APIBasePath,
+		},
+		RecipeList: []supertokens.Recipe{
+			emailpassword.Init(nil),
+			session.Init(nil),
+		},
+	})
+}
+
+// Ограничивает время обработки запроса. Контекст запроса передается
+// до базы данных, поэтому по истечении времени запросы к ней прерываются,
+// а обработчик отвечает 504.
+func requestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Периодически удаляет записи, пролежавшие в корзине дольше retention.
+// Первая очистка выполняется сразу при запуске.
+func purgeTrash(ctx context.Context, service services.TrashService, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := service.PurgeExpiredTrash(ctx, retention)
+		if err != nil {
+			log.Printf("diary: trash purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("diary: purged %d entries from trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Запускает сервер и корректно останавливает его при отмене ctx (SIGINT/SIGTERM),
+// дожидаясь завершения обрабатываемых запросов. stop снимает обработчик сигналов.
+func serve(ctx context.Context, stop context.CancelFunc, srv *http.Server, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("diary: listening on %s", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	// Повторный сигнал завершает процесс немедленно
+	stop()
+	log.Printf("diary: shutting down, waiting up to %s for in-flight requests", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	return nil
 }
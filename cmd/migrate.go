@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"diary/internal/config"
+	"diary/internal/migrations"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: diary migrate up|down|status [flags]"
+
+// Команда diary migrate: управляет схемой базы без запуска сервера.
+// После действия принимаются те же флаги, что и у сервера (-db, -config).
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	action := args[0]
+	switch action {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("unknown migrate action %q; %s", action, migrateUsage)
+	}
+
+	cfg, err := config.Load("diary migrate "+action, args[1:])
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("nothing to revert")
+		} else {
+			fmt.Printf("reverted %04d_%s\n", reverted.Version, reverted.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(os.Stdout, statuses)
+	}
+	return nil
+}
+
+func printStatus(w io.Writer, statuses []migrations.Status) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format(time.RFC3339)
+		}
+		if s.Unknown {
+			applied += " (unknown to this build)"
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,488 @@
+package config
+
+import (
+	"diary/internal/models"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Конфигурация сервера ---
+
+type Config struct {
+	// Адрес, на котором слушает HTTP-сервер
+	Addr string `json:"addr"`
+	// Где хранятся записи: database (SQLite или PostgreSQL), files
+	// (Markdown-файлы в каталоге files_path) или memory (демо-режим)
+	Storage string `json:"storage"`
+	// Корневой каталог файлового хранилища
+	FilesPath string `json:"files_path"`
+	// Файл, из которого хранилище в памяти загружается при запуске и в
+	// который сохраняется при остановке; пусто - данные теряются
+	MemorySnapshot string `json:"memory_snapshot"`
+	// Драйвер базы данных: sqlite или postgres
+	DatabaseDriver string `json:"database_driver"`
+	// Путь к файлу базы данных SQLite
+	DatabasePath string `json:"database_path"`
+	// Строка подключения к PostgreSQL (URL или key=value)
+	DatabaseDSN string `json:"database_dsn"`
+	// Применять непримененные миграции при запуске сервера; без этого
+	// схема обновляется командой diary migrate up
+	MigrateOnStart bool `json:"migrate_on_start"`
+
+	ReadTimeout     Duration `json:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	// Предельное время обработки запроса, включая запросы к базе;
+	// 0 отключает ограничение
+	RequestTimeout Duration `json:"request_timeout"`
+
+	// Сколько удаленные записи хранятся в корзине; 0 отключает автоочистку
+	TrashRetention Duration `json:"trash_retention"`
+	// Как часто запускается очистка корзины
+	TrashPurgeInterval Duration `json:"trash_purge_interval"`
+
+	Limits LimitsConfig `json:"limits"`
+
+	// Способ аутентификации: supertokens, jwt (токены своего сервера
+	// авторизации) или none (один пользователь, для самостоятельного размещения)
+	Auth        string            `json:"auth"`
+	SuperTokens SuperTokensConfig `json:"supertokens"`
+	JWT         JWTConfig         `json:"jwt"`
+	// Пользователь, от имени которого выполняются все запросы при auth=none
+	SingleUserID string `json:"single_user_id"`
+	// Пользователи с ролью admin: им доступны список пользователей и
+	// использование хранилища. Остальные получают роль user.
+	Admins []string `json:"admins"`
+}
+
+// Ограничения на поля записи
+type LimitsConfig struct {
+	// Длина заголовка в символах, не больше размера колонки
+	// (models.MaxTitleColumnLength)
+	MaxTitleLength int `json:"max_title_length"`
+	// Длина текста записи в символах
+	MaxContentLength int `json:"max_content_length"`
+	// Количество тегов у записи
+	MaxTags int `json:"max_tags"`
+}
+
+type SuperTokensConfig struct {
+	ConnectionURI string `json:"connection_uri"`
+	APIKey        string `json:"api_key"`
+	AppName       string `json:"app_name"`
+	APIDomain     string `json:"api_domain"`
+	WebsiteDomain string `json:"website_domain"`
+	APIBasePath   string `json:"api_base_path"`
+}
+
+// Токены подписываются либо общим секретом (HS256), либо ключами RSA,
+// открытые части которых лежат в файле JWKS (RS256)
+type JWTConfig struct {
+	Secret   string `json:"secret"`
+	JWKSFile string `json:"jwks_file"`
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+}
+
+// Минимальная длина секрета HS256 в байтах (RFC 7518, раздел 3.2)
+const minJWTSecretLength = 32
+
+// Варианты аутентификации
+const (
+	AuthSuperTokens = "supertokens"
+	AuthJWT         = "jwt"
+	AuthNone        = "none"
+)
+
+// Варианты хранилища записей
+const (
+	StorageDatabase = "database"
+	StorageFiles    = "files"
+	StorageMemory   = "memory"
+)
+
+// Значения по умолчанию, подходящие для локальной разработки
+func Default() *Config {
+	return &Config{
+		Addr:            ":8080",
+		Storage:         StorageDatabase,
+		FilesPath:       "diary-data",
+		DatabaseDriver:  "sqlite",
+		DatabasePath:    "diary.db",
+		MigrateOnStart:  true,
+		ReadTimeout:     Duration(15 * time.Second),
+		WriteTimeout:    Duration(15 * time.Second),
+		IdleTimeout:     Duration(60 * time.Second),
+		ShutdownTimeout: Duration(20 * time.Second),
+		RequestTimeout:  Duration(10 * time.Second),
+
+		TrashRetention:     Duration(30 * 24 * time.Hour),
+		TrashPurgeInterval: Duration(time.Hour),
+
+		Limits: LimitsConfig{
+			MaxTitleLength:   models.MaxTitleColumnLength,
+			MaxContentLength: models.DefaultMaxContentLength,
+			MaxTags:          models.DefaultMaxTags,
+		},
+
+		Auth:         AuthSuperTokens,
+		SingleUserID: "00000000-0000-0000-0000-000000000001",
+		SuperTokens: SuperTokensConfig{
+			ConnectionURI: "http://localhost:3567",
+			AppName:       "diary",
+			APIDomain:     "http://localhost:8080",
+			WebsiteDomain: "http://localhost:3000",
+			APIBasePath:   "/auth",
+		},
+	}
+}
+
+// --- Загрузка конфигурации ---
+
+// Load собирает конфигурацию из нескольких источников.
+// Приоритет (от низшего к высшему): значения по умолчанию, файл конфигурации,
+// переменные окружения, флаги командной строки.
+func Load(name string, args []string) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("DIARY_CONFIG"), "path to JSON config file (env DIARY_CONFIG)")
+
+	// Флаги применяются после файла и окружения, поэтому откладываем их
+	var overrides []func(*Config) error
+	for _, s := range settings {
+		fs.Func(s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(v string) error {
+			overrides = append(overrides, func(c *Config) error { return s.set(c, v) })
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("env %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, apply := range overrides {
+		if err := apply(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if c.Addr == "" {
+		return errors.New("config: addr must not be empty")
+	}
+	switch c.Storage {
+	case StorageDatabase:
+		if err := c.validateDatabase(); err != nil {
+			return err
+		}
+	case StorageFiles:
+		if c.FilesPath == "" {
+			return errors.New("config: files_path must not be empty")
+		}
+	case StorageMemory:
+	default:
+		return fmt.Errorf("config: unsupported storage %q, expected database, files or memory", c.Storage)
+	}
+	if err := c.validateAuth(); err != nil {
+		return err
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("config: shutdown_timeout must be positive")
+	}
+	if c.RequestTimeout < 0 {
+		return errors.New("config: request_timeout must not be negative")
+	}
+	if c.TrashRetention < 0 {
+		return errors.New("config: trash_retention must not be negative")
+	}
+	if c.TrashRetention > 0 && c.TrashPurgeInterval <= 0 {
+		return errors.New("config: trash_purge_interval must be positive")
+	}
+	if c.Limits.MaxTitleLength <= 0 || c.Limits.MaxTitleLength > models.MaxTitleColumnLength {
+		return fmt.Errorf("config: limits.max_title_length must be between 1 and %d", models.MaxTitleColumnLength)
+	}
+	if c.Limits.MaxContentLength <= 0 {
+		return errors.New("config: limits.max_content_length must be positive")
+	}
+	if c.Limits.MaxTags <= 0 {
+		return errors.New("config: limits.max_tags must be positive")
+	}
+	return nil
+}
+
+func (c *Config) validateDatabase() error {
+	switch c.DatabaseDriver {
+	case "sqlite":
+		if c.DatabasePath == "" {
+			return errors.New("config: database_path must not be empty")
+		}
+	case "postgres":
+		if c.DatabaseDSN == "" {
+			return errors.New("config: database_dsn must not be empty for postgres")
+		}
+	default:
+		return fmt.Errorf("config: unsupported database_driver %q, expected sqlite or postgres", c.DatabaseDriver)
+	}
+	return nil
+}
+
+func (c *Config) validateAuth() error {
+	switch c.Auth {
+	case AuthSuperTokens:
+		if c.SuperTokens.ConnectionURI == "" {
+			return errors.New("config: supertokens.connection_uri must not be empty")
+		}
+	case AuthJWT:
+		if (c.JWT.Secret == "") == (c.JWT.JWKSFile == "") {
+			return errors.New("config: exactly one of jwt.secret and jwt.jwks_file must be set")
+		}
+		if c.JWT.Secret != "" && len(c.JWT.Secret) < minJWTSecretLength {
+			return fmt.Errorf("config: jwt.secret must be at least %d bytes", minJWTSecretLength)
+		}
+	case AuthNone:
+		userID, err := uuid.Parse(c.SingleUserID)
+		if err != nil || userID == uuid.Nil {
+			return errors.New("config: single_user_id must be a non-nil UUID")
+		}
+	default:
+		return fmt.Errorf("config: unsupported auth %q, expected supertokens, jwt or none", c.Auth)
+	}
+	if _, err := c.AdminIDs(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ID администраторов из Admins
+func (c *Config) AdminIDs() ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(c.Admins))
+	for _, raw := range c.Admins {
+		id, err := uuid.Parse(raw)
+		if err != nil || id == uuid.Nil {
+			return nil, fmt.Errorf("config: admins must contain non-nil UUIDs, got %q", raw)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Источник данных для драйвера: путь к файлу SQLite или строка подключения PostgreSQL
+func (c *Config) DataSource() string {
+	if c.DatabaseDriver == "postgres" {
+		return c.DatabaseDSN
+	}
+	return c.DatabasePath
+}
+
+// --- Таблица настроек (флаг + переменная окружения) ---
+
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"addr", "DIARY_ADDR", "HTTP listen address", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"storage", "DIARY_STORAGE", "entry storage: database, files or memory", func(c *Config, v string) error {
+		c.Storage = v
+		return nil
+	}},
+	{"files-path", "DIARY_FILES_PATH", "root directory of the files storage", func(c *Config, v string) error {
+		c.FilesPath = v
+		return nil
+	}},
+	{"memory-snapshot", "DIARY_MEMORY_SNAPSHOT", "snapshot file of the memory storage, empty keeps data in memory only", func(c *Config, v string) error {
+		c.MemorySnapshot = v
+		return nil
+	}},
+	{"db-driver", "DIARY_DB_DRIVER", "database driver: sqlite or postgres", func(c *Config, v string) error {
+		c.DatabaseDriver = v
+		return nil
+	}},
+	{"db", "DIARY_DB_PATH", "SQLite database path", func(c *Config, v string) error {
+		c.DatabasePath = v
+		return nil
+	}},
+	{"db-dsn", "DIARY_DB_DSN", "PostgreSQL connection string", func(c *Config, v string) error {
+		c.DatabaseDSN = v
+		return nil
+	}},
+	{"migrate-on-start", "DIARY_MIGRATE_ON_START", "apply pending schema migrations on server start", boolSetter(func(c *Config) *bool { return &c.MigrateOnStart })},
+	{"read-timeout", "DIARY_READ_TIMEOUT", "HTTP read timeout", durationSetter(func(c *Config) *Duration { return &c.ReadTimeout })},
+	{"write-timeout", "DIARY_WRITE_TIMEOUT", "HTTP write timeout", durationSetter(func(c *Config) *Duration { return &c.WriteTimeout })},
+	{"idle-timeout", "DIARY_IDLE_TIMEOUT", "HTTP idle timeout", durationSetter(func(c *Config) *Duration { return &c.IdleTimeout })},
+	{"shutdown-timeout", "DIARY_SHUTDOWN_TIMEOUT", "graceful shutdown timeout", durationSetter(func(c *Config) *Duration { return &c.ShutdownTimeout })},
+	{"request-timeout", "DIARY_REQUEST_TIMEOUT", "per-request processing timeout, 0 disables", durationSetter(func(c *Config) *Duration { return &c.RequestTimeout })},
+	{"trash-retention", "DIARY_TRASH_RETENTION", "how long deleted entries stay in trash, 0 disables purge", durationSetter(func(c *Config) *Duration { return &c.TrashRetention })},
+	{"trash-purge-interval", "DIARY_TRASH_PURGE_INTERVAL", "trash purge interval", durationSetter(func(c *Config) *Duration { return &c.TrashPurgeInterval })},
+	{"max-title-length", "DIARY_MAX_TITLE_LENGTH", "maximum entry title length in characters", intSetter(func(c *Config) *int { return &c.Limits.MaxTitleLength })},
+	{"max-content-length", "DIARY_MAX_CONTENT_LENGTH", "maximum entry content length in characters", intSetter(func(c *Config) *int { return &c.Limits.MaxContentLength })},
+	{"max-tags", "DIARY_MAX_TAGS", "maximum number of tags per entry", intSetter(func(c *Config) *int { return &c.Limits.MaxTags })},
+	{"auth", "DIARY_AUTH", "authentication: supertokens, jwt or none", func(c *Config, v string) error {
+		c.Auth = v
+		return nil
+	}},
+	{"jwt-secret", "DIARY_JWT_SECRET", "shared secret of HS256 tokens", func(c *Config, v string) error {
+		c.JWT.Secret = v
+		return nil
+	}},
+	{"jwt-jwks-file", "DIARY_JWT_JWKS_FILE", "JWKS file with public keys of RS256 tokens", func(c *Config, v string) error {
+		c.JWT.JWKSFile = v
+		return nil
+	}},
+	{"jwt-issuer", "DIARY_JWT_ISSUER", "required iss claim of tokens", func(c *Config, v string) error {
+		c.JWT.Issuer = v
+		return nil
+	}},
+	{"jwt-audience", "DIARY_JWT_AUDIENCE", "required aud claim of tokens", func(c *Config, v string) error {
+		c.JWT.Audience = v
+		return nil
+	}},
+	{"single-user-id", "DIARY_SINGLE_USER_ID", "user ID of all requests with auth none", func(c *Config, v string) error {
+		c.SingleUserID = v
+		return nil
+	}},
+	{"admins", "DIARY_ADMINS", "comma-separated user IDs with the admin role", func(c *Config, v string) error {
+		c.Admins = nil
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				c.Admins = append(c.Admins, id)
+			}
+		}
+		return nil
+	}},
+	{"supertokens-uri", "DIARY_SUPERTOKENS_URI", "SuperTokens core connection URI", func(c *Config, v string) error {
+		c.SuperTokens.ConnectionURI = v
+		return nil
+	}},
+	{"supertokens-api-key", "DIARY_SUPERTOKENS_API_KEY", "SuperTokens core API key", func(c *Config, v string) error {
+		c.SuperTokens.APIKey = v
+		return nil
+	}},
+	{"app-name", "DIARY_APP_NAME", "application name", func(c *Config, v string) error {
+		c.SuperTokens.AppName = v
+		return nil
+	}},
+	{"api-domain", "DIARY_API_DOMAIN", "public API domain", func(c *Config, v string) error {
+		c.SuperTokens.APIDomain = v
+		return nil
+	}},
+	{"website-domain", "DIARY_WEBSITE_DOMAIN", "frontend website domain", func(c *Config, v string) error {
+		c.SuperTokens.WebsiteDomain = v
+		return nil
+	}},
+	{"api-base-path", "DIARY_API_BASE_PATH", "SuperTokens auth API base path", func(c *Config, v string) error {
+		c.SuperTokens.APIBasePath = v
+		return nil
+	}},
+}
+
+func durationSetter(field func(c *Config) *Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = Duration(d)
+		return nil
+	}
+}
+
+func intSetter(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolSetter(field func(c *Config) *bool) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// --- Duration с поддержкой "15s" в JSON ---
+
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// Допускаем число секунд
+		var n float64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
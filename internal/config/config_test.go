@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	// Act
+	cfg, err := Load("diary", nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	// Arrange - файл задает все три значения, окружение перекрывает два, флаг - одно
+	path := filepath.Join(t.TempDir(), "diary.json")
+	err := os.WriteFile(path, []byte(`{
+		"addr": ":9000",
+		"database_path": "from-file.db",
+		"shutdown_timeout": "5s",
+		"supertokens": {"connection_uri": "http://core:3567"}
+	}`), 0o600)
+	require.NoError(t, err)
+
+	t.Setenv("DIARY_DB_PATH", "from-env.db")
+	t.Setenv("DIARY_ADDR", ":9100")
+
+	// Act
+	cfg, err := Load("diary", []string{"-config", path, "-addr", ":9200"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, ":9200", cfg.Addr)
+	assert.Equal(t, "from-env.db", cfg.DatabasePath)
+	assert.Equal(t, 5*time.Second, cfg.ShutdownTimeout.Std())
+	assert.Equal(t, "http://core:3567", cfg.SuperTokens.ConnectionURI)
+	// Не указанные в файле поля сохраняют значения по умолчанию
+	assert.Equal(t, "diary", cfg.SuperTokens.AppName)
+}
+
+func TestLoadInvalidDuration(t *testing.T) {
+	// Act
+	_, err := Load("diary", []string{"-shutdown-timeout", "soon"})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	// Act
+	_, err := Load("diary", []string{"-config", filepath.Join(t.TempDir(), "missing.json")})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestLoadLimits(t *testing.T) {
+	// Arrange
+	t.Setenv("DIARY_MAX_CONTENT_LENGTH", "5000")
+
+	// Act
+	cfg, err := Load("diary", []string{"-max-title-length", "120"})
+	_, tooLong := Load("diary", []string{"-max-title-length", "300"})
+
+	// Assert - заголовок не может превышать размер колонки
+	require.NoError(t, err)
+	assert.Equal(t, 120, cfg.Limits.MaxTitleLength)
+	assert.Equal(t, 5000, cfg.Limits.MaxContentLength)
+	assert.Error(t, tooLong)
+}
+
+func TestLoadMigrateOnStart(t *testing.T) {
+	// Arrange
+	t.Setenv("DIARY_MIGRATE_ON_START", "false")
+
+	// Act
+	fromEnv, err := Load("diary", nil)
+	require.NoError(t, err)
+	fromFlag, err := Load("diary", []string{"-migrate-on-start", "true"})
+	require.NoError(t, err)
+	_, invalid := Load("diary", []string{"-migrate-on-start", "sometimes"})
+
+	// Assert
+	assert.False(t, fromEnv.MigrateOnStart)
+	assert.True(t, fromFlag.MigrateOnStart)
+	assert.Error(t, invalid)
+}
+
+func TestLoadDatabaseDriver(t *testing.T) {
+	// Arrange
+	t.Setenv("DIARY_DB_DSN", "postgres://diary@localhost/diary")
+
+	// Act
+	postgres, err := Load("diary", []string{"-db-driver", "postgres"})
+	require.NoError(t, err)
+	_, noDSN := Load("diary", []string{"-db-driver", "postgres", "-db-dsn", ""})
+	_, unknown := Load("diary", []string{"-db-driver", "mysql"})
+
+	// Assert
+	assert.Equal(t, "postgres://diary@localhost/diary", postgres.DataSource())
+	assert.Equal(t, "diary.db", Default().DataSource())
+	assert.Error(t, noDSN)
+	assert.Error(t, unknown)
+}
+
+func TestLoadStorage(t *testing.T) {
+	// Arrange
+	t.Setenv("DIARY_FILES_PATH", "/var/lib/diary")
+
+	// Act
+	files, err := Load("diary", []string{"-storage", "files", "-db", ""})
+	require.NoError(t, err)
+	_, noPath := Load("diary", []string{"-storage", "files", "-files-path", ""})
+	_, unknown := Load("diary", []string{"-storage", "s3"})
+	memory, err := Load("diary", []string{"-storage", "memory", "-memory-snapshot", "demo.json"})
+	require.NoError(t, err)
+
+	// Assert - настройки базы для файлового хранилища не проверяются
+	assert.Equal(t, StorageDatabase, Default().Storage)
+	assert.Equal(t, StorageFiles, files.Storage)
+	assert.Equal(t, "/var/lib/diary", files.FilesPath)
+	assert.Error(t, noPath)
+	assert.Error(t, unknown)
+	assert.Equal(t, StorageMemory, memory.Storage)
+	assert.Equal(t, "demo.json", memory.MemorySnapshot)
+}
+
+func TestLoadAuth(t *testing.T) {
+	// Arrange
+	secret := strings.Repeat("s", 32)
+	t.Setenv("DIARY_JWT_SECRET", secret)
+
+	// Act
+	jwt, err := Load("diary", []string{"-auth", "jwt", "-jwt-issuer", "https://auth.example.com"})
+	require.NoError(t, err)
+	_, bothKeys := Load("diary", []string{"-auth", "jwt", "-jwt-jwks-file", "jwks.json"})
+	_, shortSecret := Load("diary", []string{"-auth", "jwt", "-jwt-secret", "short"})
+	none, err := Load("diary", []string{"-auth", "none", "-supertokens-uri", ""})
+	require.NoError(t, err)
+	_, nilUser := Load("diary", []string{"-auth", "none", "-single-user-id", "00000000-0000-0000-0000-000000000000"})
+	_, unknown := Load("diary", []string{"-auth", "basic"})
+
+	// Assert - адрес SuperTokens проверяется только для auth=supertokens
+	assert.Equal(t, AuthSuperTokens, Default().Auth)
+	assert.Equal(t, AuthJWT, jwt.Auth)
+	assert.Equal(t, secret, jwt.JWT.Secret)
+	assert.Equal(t, "https://auth.example.com", jwt.JWT.Issuer)
+	assert.Error(t, bothKeys)
+	assert.Error(t, shortSecret)
+	assert.Equal(t, AuthNone, none.Auth)
+	assert.Equal(t, Default().SingleUserID, none.SingleUserID)
+	assert.Error(t, nilUser)
+	assert.Error(t, unknown)
+}
+
+func TestLoadAdmins(t *testing.T) {
+	// Arrange
+	first, second := uuid.NewString(), uuid.NewString()
+	t.Setenv("DIARY_ADMINS", first+", "+second)
+
+	// Act
+	cfg, err := Load("diary", nil)
+	require.NoError(t, err)
+	ids, idsErr := cfg.AdminIDs()
+	_, invalid := Load("diary", []string{"-admins", "root"})
+	_, nilID := Load("diary", []string{"-admins", uuid.Nil.String()})
+
+	// Assert - пробелы вокруг ID в списке допускаются
+	require.NoError(t, idsErr)
+	assert.Equal(t, []string{first, second}, cfg.Admins)
+	assert.Equal(t, []uuid.UUID{uuid.MustParse(first), uuid.MustParse(second)}, ids)
+	assert.Empty(t, Default().Admins)
+	assert.Error(t, invalid)
+	assert.Error(t, nilID)
+}
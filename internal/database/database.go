@@ -0,0 +1,70 @@
+// Package database открывает базу данных выбранным драйвером.
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Поддерживаемые драйверы; совпадают с именами диалектов gorm
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Open открывает базу. dsn - путь к файлу для SQLite или строка
+// подключения для PostgreSQL. Схема не создается: это делают миграции.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(dsn))
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		// Время храним в UTC, чтобы сравнения в фильтрах были однозначными
+		NowFunc: func() time.Time { return time.Now().UTC() },
+		// Ошибки драйвера переводятся в gorm.ErrDuplicatedKey и другие
+		// общие ошибки, чтобы репозитории не разбирали их текст
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	return db, nil
+}
+
+// Параметры SQLite для конкурентной записи из нескольких соединений пула,
+// если они не заданы в dsn явно: ожидание блокировки вместо немедленного
+// "database is locked" и захват блокировки на запись в начале транзакции,
+// чтобы две транзакции не ждали друг друга при повышении блокировки.
+var sqliteDefaults = []string{"_busy_timeout=5000", "_txlock=immediate"}
+
+func sqliteDSN(dsn string) string {
+	for _, param := range sqliteDefaults {
+		name, _, _ := strings.Cut(param, "=")
+		if strings.Contains(dsn, name+"=") {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + param
+		} else {
+			dsn += "?" + param
+		}
+	}
+	return dsn
+}
+
+// Драйвер, которым открыта база
+func Driver(db *gorm.DB) string {
+	return db.Dialector.Name()
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"diary.db", "diary.db?_busy_timeout=5000&_txlock=immediate"},
+		{"file:/tmp/d.db?_journal_mode=MEMORY", "file:/tmp/d.db?_journal_mode=MEMORY&_busy_timeout=5000&_txlock=immediate"},
+		{"diary.db?_busy_timeout=100&_txlock=deferred", "diary.db?_busy_timeout=100&_txlock=deferred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			assert.Equal(t, tt.want, sqliteDSN(tt.dsn))
+		})
+	}
+}
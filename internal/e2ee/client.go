@@ -0,0 +1,168 @@
+package e2ee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --- HTTP-клиент ---
+
+// Client работает с API дневника и шифрует записи до отправки:
+// сервер получает только шифротекст.
+type Client struct {
+	// Адрес сервера без /api, например https://diary.example.com
+	BaseURL string
+	// По умолчанию http.DefaultClient
+	HTTP *http.Client
+	// Добавляет к запросу учетные данные, например заголовок Authorization
+	Authorize func(r *http.Request)
+}
+
+// Зашифрованная запись с открытыми метаданными
+type Entry struct {
+	// Назначается сервером при создании
+	ID      string
+	Title   string
+	Content string
+	Tags    []string
+	// RFC 3339 или YYYY-MM-DD; пустая дата - текущий момент
+	EntryDate string
+	TimeZone  string
+	Version   int
+}
+
+// Ошибка API в едином формате {"error": {"code": ..., "message": ...}}
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("diary api: %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Тело запроса и ответа записи в части, которую использует клиент
+type entryPayload struct {
+	ID        string   `json:"id,omitempty"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	EntryDate string   `json:"entry_date,omitempty"`
+	TimeZone  string   `json:"timezone,omitempty"`
+	Version   int      `json:"version,omitempty"`
+	Encrypted *Sealed  `json:"encrypted"`
+}
+
+// PutKey сохраняет обертку ключа на сервере
+func (c *Client) PutKey(ctx context.Context, wrapped *WrappedKey) error {
+	return c.do(ctx, http.MethodPut, "/api/keys/"+url.PathEscape(wrapped.KeyID), wrapped, nil)
+}
+
+// GetKey получает обертку ключа; развернуть ее можно через Unwrap
+func (c *Client) GetKey(ctx context.Context, keyID string) (*WrappedKey, error) {
+	var wrapped WrappedKey
+	if err := c.do(ctx, http.MethodGet, "/api/keys/"+url.PathEscape(keyID), nil, &wrapped); err != nil {
+		return nil, err
+	}
+	return &wrapped, nil
+}
+
+// CreateEntry шифрует заголовок и текст ключом key и создает запись.
+// Возвращает ID созданной записи.
+func (c *Client) CreateEntry(ctx context.Context, key *Key, entry Entry) (string, error) {
+	sealed, err := key.Seal(Plaintext{Title: entry.Title, Content: entry.Content})
+	if err != nil {
+		return "", err
+	}
+	payload := entryPayload{
+		Tags:      entry.Tags,
+		EntryDate: entry.EntryDate,
+		TimeZone:  entry.TimeZone,
+		Encrypted: sealed,
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/entries/", payload, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// GetEntry получает запись и расшифровывает ее ключом key
+func (c *Client) GetEntry(ctx context.Context, key *Key, id string) (*Entry, error) {
+	var payload entryPayload
+	if err := c.do(ctx, http.MethodGet, "/api/entries/"+url.PathEscape(id), nil, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Encrypted == nil {
+		return nil, fmt.Errorf("e2ee: entry %s is not encrypted", id)
+	}
+	plaintext, err := key.Open(payload.Encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		ID:        payload.ID,
+		Title:     plaintext.Title,
+		Content:   plaintext.Content,
+		Tags:      payload.Tags,
+		EntryDate: payload.EntryDate,
+		TimeZone:  payload.TimeZone,
+		Version:   payload.Version,
+	}, nil
+}
+
+// Отправляет запрос с телом in (JSON) и разбирает ответ в out
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Authorize != nil {
+		c.Authorize(req)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var problem struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&problem)
+		return &APIError{Status: resp.StatusCode, Code: problem.Error.Code, Message: problem.Error.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
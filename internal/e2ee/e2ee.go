@@ -0,0 +1,205 @@
+// Package e2ee - эталонная реализация клиентского шифрования записей.
+//
+// Схема:
+//   - ключ записей - случайные 32 байта, один на все записи пользователя;
+//   - ключ обертки выводится из пароля через PBKDF2-SHA256 со случайной солью;
+//   - ключ записей оборачивается ключом обертки (AES-256-GCM) и хранится на
+//     сервере вместе с солью и числом итераций (PUT /api/keys/{id});
+//   - заголовок и текст записи шифруются ключом записей (AES-256-GCM) как
+//     JSON {"title": ..., "content": ...}.
+//
+// Идентификатор ключа входит в дополнительные данные (AAD) обоих шифрований:
+// обертку или шифротекст, подмененные на сервере под другой ключ, клиент
+// не примет. Дата, теги и настроение записи остаются открытыми.
+package e2ee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"diary/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	keySize  = 32
+	saltSize = 16
+	// Рекомендация OWASP для PBKDF2-HMAC-SHA256
+	DefaultIterations = 600_000
+)
+
+var (
+	// Неверный пароль или поврежденная обертка: GCM не отличает одно от другого
+	ErrWrongPassphrase = errors.New("e2ee: wrong passphrase or corrupted key")
+	// Шифротекст поврежден или зашифрован другим ключом
+	ErrDecrypt = errors.New("e2ee: cannot decrypt entry")
+	// Запись зашифрована ключом с другим идентификатором
+	ErrWrongKey = errors.New("e2ee: entry is encrypted with another key")
+)
+
+// --- Ключ записей ---
+
+// Развернутый ключ записей; существует только на клиенте
+type Key struct {
+	ID     string
+	secret []byte
+}
+
+// NewKey создает случайный ключ записей
+func NewKey(id string) (*Key, error) {
+	secret := make([]byte, keySize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &Key{ID: id, secret: secret}, nil
+}
+
+// Обертка ключа в формате API /api/keys; бинарные поля передаются в base64
+type WrappedKey struct {
+	KeyID      string `json:"key_id"`
+	Algorithm  string `json:"algorithm"`
+	Nonce      []byte `json:"nonce"`
+	WrappedKey []byte `json:"wrapped_key"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+}
+
+// Wrap оборачивает ключ ключом, выведенным из пароля. Для смены пароля
+// достаточно обернуть тот же ключ заново и сохранить под тем же ID.
+func (k *Key) Wrap(passphrase string, iterations int) (*WrappedKey, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	kek, err := deriveKey(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce, sealed, err := seal(kek, k.secret, []byte(k.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &WrappedKey{
+		KeyID:      k.ID,
+		Algorithm:  models.EncryptionA256GCM,
+		Nonce:      nonce,
+		WrappedKey: sealed,
+		KDF:        models.KDFPBKDF2SHA256,
+		Salt:       salt,
+		Iterations: iterations,
+	}, nil
+}
+
+// Unwrap разворачивает ключ паролем пользователя
+func Unwrap(wrapped *WrappedKey, passphrase string) (*Key, error) {
+	if wrapped.Algorithm != models.EncryptionA256GCM || wrapped.KDF != models.KDFPBKDF2SHA256 {
+		return nil, fmt.Errorf("e2ee: unsupported key wrapping %s/%s", wrapped.Algorithm, wrapped.KDF)
+	}
+	kek, err := deriveKey(passphrase, wrapped.Salt, wrapped.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := open(kek, wrapped.Nonce, wrapped.WrappedKey, []byte(wrapped.KeyID))
+	if err != nil || len(secret) != keySize {
+		return nil, ErrWrongPassphrase
+	}
+	return &Key{ID: wrapped.KeyID, secret: secret}, nil
+}
+
+// --- Шифрование записей ---
+
+// Открытый текст записи, который видит только клиент
+type Plaintext struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Шифротекст записи в формате поля encrypted API записей
+type Sealed struct {
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal шифрует заголовок и текст записи; nonce каждый раз новый
+func (k *Key) Seal(plaintext Plaintext) (*Sealed, error) {
+	data, err := json.Marshal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext, err := seal(k.secret, data, []byte(k.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{
+		Algorithm:  models.EncryptionA256GCM,
+		KeyID:      k.ID,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Open расшифровывает запись, зашифрованную этим ключом
+func (k *Key) Open(sealed *Sealed) (*Plaintext, error) {
+	if sealed.KeyID != k.ID {
+		return nil, ErrWrongKey
+	}
+	if sealed.Algorithm != models.EncryptionA256GCM {
+		return nil, fmt.Errorf("e2ee: unsupported algorithm %q", sealed.Algorithm)
+	}
+	data, err := open(k.secret, sealed.Nonce, sealed.Ciphertext, []byte(sealed.KeyID))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	var plaintext Plaintext
+	if err := json.Unmarshal(data, &plaintext); err != nil {
+		return nil, ErrDecrypt
+	}
+	return &plaintext, nil
+}
+
+// --- Примитивы ---
+
+func deriveKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	if iterations <= 0 {
+		return nil, fmt.Errorf("e2ee: invalid iteration count %d", iterations)
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(key, plaintext, aad []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func open(key, nonce, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("e2ee: invalid nonce size")
+	}
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
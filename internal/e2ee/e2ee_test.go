@@ -0,0 +1,96 @@
+package e2ee
+
+import (
+	"diary/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Минимум, который принимает сервер; в тестах быстрее значения по умолчанию
+const testIterations = 100_000
+
+func TestWrapAndUnwrap(t *testing.T) {
+	// Arrange
+	key, err := NewKey("main")
+	require.NoError(t, err)
+
+	// Act
+	wrapped, err := key.Wrap("correct horse", testIterations)
+	require.NoError(t, err)
+	unwrapped, unwrapErr := Unwrap(wrapped, "correct horse")
+	_, wrongErr := Unwrap(wrapped, "wrong horse")
+
+	// Assert - в обертке нет ключа в открытом виде
+	require.NoError(t, unwrapErr)
+	assert.Equal(t, key.secret, unwrapped.secret)
+	assert.Equal(t, "main", unwrapped.ID)
+	assert.Equal(t, models.EncryptionA256GCM, wrapped.Algorithm)
+	assert.Equal(t, models.KDFPBKDF2SHA256, wrapped.KDF)
+	assert.Len(t, wrapped.Nonce, 12)
+	assert.Len(t, wrapped.Salt, saltSize)
+	assert.NotContains(t, string(wrapped.WrappedKey), string(key.secret))
+	assert.ErrorIs(t, wrongErr, ErrWrongPassphrase)
+}
+
+func TestUnwrapRejectsSwappedKeyID(t *testing.T) {
+	// Arrange - сервер выдал обертку одного ключа под именем другого
+	key, err := NewKey("main")
+	require.NoError(t, err)
+	wrapped, err := key.Wrap("passphrase", testIterations)
+	require.NoError(t, err)
+	wrapped.KeyID = "other"
+
+	// Act
+	_, err = Unwrap(wrapped, "passphrase")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestSealAndOpen(t *testing.T) {
+	// Arrange
+	key, err := NewKey("main")
+	require.NoError(t, err)
+	plaintext := Plaintext{Title: "Секрет", Content: "Текст \"в кавычках\"\nи перевод строки"}
+
+	// Act
+	first, err := key.Seal(plaintext)
+	require.NoError(t, err)
+	second, err := key.Seal(plaintext)
+	require.NoError(t, err)
+	opened, openErr := key.Open(first)
+
+	// Assert - nonce каждый раз новый, поэтому и шифротекст разный
+	require.NoError(t, openErr)
+	assert.Equal(t, plaintext, *opened)
+	assert.NotEqual(t, first.Nonce, second.Nonce)
+	assert.NotEqual(t, first.Ciphertext, second.Ciphertext)
+	assert.NotContains(t, string(first.Ciphertext), "Секрет")
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	// Arrange
+	key, err := NewKey("main")
+	require.NoError(t, err)
+	other, err := NewKey("main")
+	require.NoError(t, err)
+	sealed, err := key.Seal(Plaintext{Title: "Title"})
+	require.NoError(t, err)
+	tampered := *sealed
+	tampered.Ciphertext = append([]byte(nil), sealed.Ciphertext...)
+	tampered.Ciphertext[0] ^= 1
+	relabeled := *sealed
+	relabeled.KeyID = "other"
+
+	// Act
+	_, tamperedErr := key.Open(&tampered)
+	_, otherKeyErr := other.Open(sealed)
+	_, relabeledErr := key.Open(&relabeled)
+
+	// Assert
+	assert.ErrorIs(t, tamperedErr, ErrDecrypt)
+	assert.ErrorIs(t, otherKeyErr, ErrDecrypt)
+	assert.ErrorIs(t, relabeledErr, ErrWrongKey)
+}
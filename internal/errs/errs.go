@@ -0,0 +1,102 @@
+// Package errs описывает типизированные ошибки предметной области.
+//
+// Репозитории и сервисы возвращают *Error одного из видов ErrNotFound,
+// ErrForbidden, ErrValidation или ErrConflict; обработчики HTTP определяют
+// по виду код ответа. Проверка вида: errors.Is(err, errs.ErrNotFound).
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+// Виды ошибок
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+	ErrConflict   = errors.New("conflict")
+)
+
+// Ошибка конкретного поля запроса
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+type Error struct {
+	// Один из видов ErrNotFound, ErrForbidden, ErrValidation, ErrConflict
+	Kind error
+	// Сообщение для клиента
+	Message string
+	// Ошибки отдельных полей (для ErrValidation)
+	Fields []FieldError
+	// Исходная ошибка, например gorm.ErrRecordNotFound
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.Error()
+	}
+	if len(e.Fields) > 0 {
+		parts := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			parts = append(parts, f.Field+": "+f.Message)
+		}
+		msg += " (" + strings.Join(parts, "; ") + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// errors.Is находит и вид ошибки, и исходную ошибку
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Копия ошибки с исходной причиной. Сравнение errors.Is с исходной
+// ошибкой-образцом сохраняется.
+func (e *Error) Wrap(cause error) *Error {
+	return &Error{Kind: e, Message: e.Message, Fields: e.Fields, Err: cause}
+}
+
+// --- Конструкторы ---
+
+func NotFound(message string) *Error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Message: message, Fields: fields}
+}
+
+// Ошибка валидации одного поля
+func Field(field, message string) *Error {
+	return Validation(message, FieldError{Field: field, Message: message})
+}
+
+// --- Разбор ошибок ---
+
+// Сообщение и ошибки полей первой *Error в цепочке
+func Details(err error) (message string, fields []FieldError) {
+	var e *Error
+	if !errors.As(err, &e) {
+		return "", nil
+	}
+	return e.Message, e.Fields
+}
@@ -0,0 +1,44 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	// Arrange
+	notFound := NotFound("entry not found")
+	cause := errors.New("record not found")
+
+	// Act
+	wrapped := notFound.Wrap(cause)
+
+	// Assert - доступны и вид ошибки, и образец, и исходная причина
+	assert.ErrorIs(t, wrapped, ErrNotFound)
+	assert.ErrorIs(t, wrapped, notFound)
+	assert.ErrorIs(t, wrapped, cause)
+	assert.NotErrorIs(t, wrapped, ErrConflict)
+	assert.Equal(t, "entry not found: record not found", wrapped.Error())
+}
+
+func TestDetails(t *testing.T) {
+	// Arrange
+	err := Validation("invalid entry",
+		FieldError{Field: "title", Message: "must not be empty"},
+		FieldError{Field: "mood", Message: "must be between 1 and 5"})
+
+	// Act
+	message, fields := Details(err)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "invalid entry", message)
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "invalid entry (title: must not be empty; mood: must be between 1 and 5)", err.Error())
+
+	message, fields = Details(errors.New("plain"))
+	assert.Empty(t, message)
+	assert.Nil(t, fields)
+}
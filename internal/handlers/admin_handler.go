@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"diary/internal/models"
+	"diary/internal/services"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// --- Admin Handler Interface ---
+
+type AdminHandler interface {
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	Usage(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Admin Handler Implementation ---
+
+type adminHandler struct {
+	service services.AdminService
+}
+
+func NewAdminHandler(service services.AdminService) AdminHandler {
+	return &adminHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type UserUsageResponse struct {
+	UserID      string  `json:"user_id"`
+	Entries     int64   `json:"entries"`
+	Trashed     int64   `json:"trashed"`
+	Tokens      int64   `json:"tokens"`
+	LastEntryAt *string `json:"last_entry_at"`
+}
+
+type UserListResponse struct {
+	Users []UserUsageResponse `json:"users"`
+}
+
+type UsageResponse struct {
+	Users   int64 `json:"users"`
+	Entries int64 `json:"entries"`
+	Trashed int64 `json:"trashed"`
+	Tokens  int64 `json:"tokens"`
+}
+
+// --- Admin Handlers ---
+
+// GET /api/admin/users - пользователи, у которых есть записи или токены
+func (h *adminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.service.ListUserUsage(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := UserListResponse{Users: make([]UserUsageResponse, 0, len(usage))}
+	for _, item := range usage {
+		response.Users = append(response.Users, newUserUsageResponse(item))
+	}
+	render.JSON(w, r, response)
+}
+
+// GET /api/admin/usage
+func (h *adminHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	totals, err := h.service.UsageTotals(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, UsageResponse{
+		Users:   totals.Users,
+		Entries: totals.Entries,
+		Trashed: totals.Trashed,
+		Tokens:  totals.Tokens,
+	})
+}
+
+func newUserUsageResponse(usage *models.UserUsage) UserUsageResponse {
+	return UserUsageResponse{
+		UserID:      usage.UserID.String(),
+		Entries:     usage.Entries,
+		Trashed:     usage.Trashed,
+		Tokens:      usage.Tokens,
+		LastEntryAt: formatOptionalTime(usage.LastEntryAt),
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"diary/internal/models"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/supertokens/supertokens-golang/recipe/session"
+)
+
+// --- Authenticator Interface ---
+
+// Проверяет, кто выполняет запрос. Verify сам отвечает на запросы без
+// действительных учетных данных, а проверенным запросам кладет
+// пользователя в контекст через WithUserID или WithPrincipal. Что
+// пользователю можно, решает политика маршрута (policy.go).
+type Authenticator interface {
+	Verify(next http.HandlerFunc) http.HandlerFunc
+}
+
+// --- SuperTokens ---
+
+// Сессии SuperTokens; требует supertokens.Init и supertokens.Middleware
+type superTokensAuthenticator struct{}
+
+func NewSuperTokensAuthenticator() Authenticator {
+	return &superTokensAuthenticator{}
+}
+
+func (a *superTokensAuthenticator) Verify(next http.HandlerFunc) http.HandlerFunc {
+	return session.VerifySession(nil, func(w http.ResponseWriter, r *http.Request) {
+		sessionContainer := session.GetSessionFromRequestContext(r.Context())
+		userID, err := uuid.Parse(sessionContainer.GetUserID())
+		if err != nil {
+			writeError(w, r, errInvalidUserID)
+			return
+		}
+		next(w, r.WithContext(WithUserID(r.Context(), userID)))
+	})
+}
+
+// --- Без аутентификации ---
+
+// Однопользовательский режим для самостоятельного размещения: все
+// запросы выполняются от имени одного пользователя без проверки.
+// Сервер в этом режиме нельзя открывать в общую сеть.
+type singleUserAuthenticator struct {
+	userID uuid.UUID
+}
+
+func NewSingleUserAuthenticator(userID uuid.UUID) Authenticator {
+	return &singleUserAuthenticator{userID: userID}
+}
+
+func (a *singleUserAuthenticator) Verify(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(WithUserID(r.Context(), a.userID)))
+	}
+}
+
+// --- Пользователь в контексте запроса ---
+
+// Кто выполняет запрос. Права сессии определяет роль пользователя, права
+// запроса с персональным токеном дополнительно ограничены самим токеном.
+type Principal struct {
+	UserID uuid.UUID
+	// Токен запроса; nil - запрос из сессии
+	Token *models.PersonalAccessToken
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Запрос из сессии пользователя
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return WithPrincipal(ctx, Principal{UserID: userID})
+}
+
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	return principal.UserID, ok
+}
+
+// ID пользователя текущей сессии. Сервисы ограничивают доступ этим
+// пользователем, поэтому обработчики не сверяют владельца записи сами.
+func sessionUserID(r *http.Request) (uuid.UUID, error) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, errUnauthorized
+	}
+	return userID, nil
+}
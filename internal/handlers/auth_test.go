@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTSecret = []byte(strings.Repeat("k", 32))
+
+// Выполняет запрос через аутентификатор и возвращает пользователя,
+// которого увидел обработчик
+func verifyRequest(auth Authenticator, authorization string) (*httptest.ResponseRecorder, uuid.UUID) {
+	var seen uuid.UUID
+	handler := auth.Verify(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := sessionUserID(r)
+		if err == nil {
+			seen = userID
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/entries/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec, seen
+}
+
+func signHS256(t *testing.T, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testJWTSecret)
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func validClaims(userID uuid.UUID) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Issuer:    "https://auth.example.com",
+		Audience:  jwt.ClaimStrings{"diary"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestHS256Authenticator(t *testing.T) {
+	userID := uuid.New()
+	auth := NewHS256Authenticator(testJWTSecret, JWTOptions{Issuer: "https://auth.example.com", Audience: "diary"})
+
+	expired := validClaims(userID)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := validClaims(userID)
+	noExpiry.ExpiresAt = nil
+	otherIssuer := validClaims(userID)
+	otherIssuer.Issuer = "https://evil.example.com"
+	otherAudience := validClaims(userID)
+	otherAudience.Audience = jwt.ClaimStrings{"billing"}
+	badSubject := validClaims(userID)
+	badSubject.Subject = "alice"
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(userID)).SignedString([]byte(strings.Repeat("x", 32)))
+	require.NoError(t, err)
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims(userID)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"valid", signHS256(t, validClaims(userID)), http.StatusNoContent},
+		{"lowercase scheme", strings.Replace(signHS256(t, validClaims(userID)), "Bearer", "bearer", 1), http.StatusNoContent},
+		{"missing header", "", http.StatusUnauthorized},
+		{"basic scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"malformed", "Bearer not.a.token", http.StatusUnauthorized},
+		{"expired", signHS256(t, expired), http.StatusUnauthorized},
+		{"no expiry", signHS256(t, noExpiry), http.StatusUnauthorized},
+		{"other issuer", signHS256(t, otherIssuer), http.StatusUnauthorized},
+		{"other audience", signHS256(t, otherAudience), http.StatusUnauthorized},
+		{"wrong secret", "Bearer " + forged, http.StatusUnauthorized},
+		{"alg none", "Bearer " + unsigned, http.StatusUnauthorized},
+		{"subject is not uuid", signHS256(t, badSubject), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec, seen := verifyRequest(auth, tt.authorization)
+
+			// Assert
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status == http.StatusNoContent {
+				assert.Equal(t, userID, seen)
+			} else {
+				assert.Equal(t, uuid.Nil, seen)
+			}
+			if tt.status == http.StatusUnauthorized {
+				assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func rsaJWKS(key *rsa.PublicKey, kid string) []byte {
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	return []byte(fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":%q,"alg":"RS256","use":"sig","n":%q,"e":%q}]}`, kid, n, e))
+}
+
+func TestJWKSAuthenticator(t *testing.T) {
+	// Arrange
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	auth, err := NewJWKSAuthenticator(rsaJWKS(&key.PublicKey, "main"), JWTOptions{})
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	sign := func(signer *rsa.PrivateKey, kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims(userID))
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(signer)
+		require.NoError(t, err)
+		return "Bearer " + signed
+	}
+
+	// Act
+	valid, seen := verifyRequest(auth, sign(key, "main"))
+	unknownKid, _ := verifyRequest(auth, sign(key, "rotated"))
+	wrongKey, _ := verifyRequest(auth, sign(other, "main"))
+
+	// Подмена алгоритма: HS256 с открытым ключом в качестве секрета
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(userID))
+	confused.Header["kid"] = "main"
+	confusedToken, err := confused.SignedString(key.PublicKey.N.Bytes())
+	require.NoError(t, err)
+	algConfusion, _ := verifyRequest(auth, "Bearer "+confusedToken)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, valid.Code, valid.Body.String())
+	assert.Equal(t, userID, seen)
+	assert.Equal(t, http.StatusUnauthorized, unknownKid.Code)
+	assert.Equal(t, http.StatusUnauthorized, wrongKey.Code)
+	assert.Equal(t, http.StatusUnauthorized, algConfusion.Code)
+}
+
+func TestJWKSAuthenticatorInvalidKeys(t *testing.T) {
+	for _, raw := range []string{`not json`, `{"keys":[]}`} {
+		// Act
+		auth, err := NewJWKSAuthenticator([]byte(raw), JWTOptions{})
+
+		// Assert
+		assert.Error(t, err, raw)
+		assert.Nil(t, auth)
+	}
+}
+
+func TestSingleUserAuthenticator(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+
+	// Act - заголовки не нужны и не проверяются
+	rec, seen := verifyRequest(NewSingleUserAuthenticator(userID), "")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, userID, seen)
+}
+
+func TestSessionUserIDWithoutAuthenticator(t *testing.T) {
+	// Act
+	_, err := sessionUserID(httptest.NewRequest(http.MethodGet, "/api/entries/", nil))
+
+	// Assert - маршрут без аутентификатора не получает пользователя
+	assert.ErrorIs(t, err, errUnauthorized)
+}
@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"diary/internal/errs"
 	"diary/internal/models"
+	"diary/internal/repos"
 	"diary/internal/services"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
-	"github.com/google/uuid"
-	"github.com/supertokens/supertokens-golang/recipe/session"
 )
 
 // --- Entry Handler Interface ---
@@ -18,6 +22,7 @@ type EntryHandler interface {
 	CreateEntry(w http.ResponseWriter, r *http.Request)
 	GetEntry(w http.ResponseWriter, r *http.Request)
 	UpdateEntry(w http.ResponseWriter, r *http.Request)
+	PatchEntry(w http.ResponseWriter, r *http.Request)
 	DeleteEntry(w http.ResponseWriter, r *http.Request)
 	ListEntries(w http.ResponseWriter, r *http.Request)
 }
@@ -37,48 +42,108 @@ func NewEntryHandler(service services.EntryService) EntryHandler {
 type EntryRequest struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	// При обновлении отсутствующее поле оставляет теги без изменений,
+	// пустой массив удаляет все теги
+	Tags []string `json:"tags"`
+
+	Mood      *int   `json:"mood"`
+	MoodEmoji string `json:"mood_emoji"`
+	MoodLabel string `json:"mood_label"`
+	Energy    *int   `json:"energy"`
+
+	// Дата записи (RFC 3339 или YYYY-MM-DD) и часовой пояс IANA.
+	// При обновлении отсутствующие поля не меняются.
+	EntryDate string `json:"entry_date"`
+	TimeZone  string `json:"timezone"`
+
+	// Зашифрованная на клиенте запись: заголовок и текст есть только
+	// в шифротексте, title и content пусты. Режим шифрования записи после
+	// создания не меняется.
+	Encrypted *EncryptedContent `json:"encrypted,omitempty"`
+}
+
+// Шифротекст записи; бинарные поля передаются в base64
+type EncryptedContent struct {
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
 }
 
 type EntryResponse struct {
-	ID        string `json:"id"`
-	UserID    string `json:"user_id"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_at"`
+	ID        string   `json:"id"`
+	UserID    string   `json:"user_id"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	Mood      *int     `json:"mood"`
+	MoodEmoji string   `json:"mood_emoji,omitempty"`
+	MoodLabel string   `json:"mood_label,omitempty"`
+	Energy    *int     `json:"energy"`
+	EntryDate string   `json:"entry_date"`
+	TimeZone  string   `json:"timezone"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	Version   int      `json:"version"`
+
+	Encrypted *EncryptedContent `json:"encrypted,omitempty"`
 }
 
+type EntryListResponse struct {
+	Entries    []EntryResponse `json:"entries"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+var (
+	errInvalidEntryDate = errs.Field("entry_date", "invalid entry date or timezone")
+	// Открытый текст остался бы в истории ревизий
+	errEncryptionChange = errs.Field("encrypted", "encryption mode of an existing entry cannot be changed")
+)
+
 // --- Entry Handlers ---
 
 func (h *entryHandler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 	// Получаем userID из сессии
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
-	userIDStr := sessionContainer.GetUserID()
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := sessionUserID(r)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	// Декодируем запрос
 	var req EntryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		writeError(w, r, errInvalidPayload)
+		return
+	}
+
+	entryDate, err := parseEntryDate(req.EntryDate, req.TimeZone)
+	if err != nil {
+		writeError(w, r, errInvalidEntryDate)
 		return
 	}
 
 	// Создаем запись
 	entry := &models.Entry{
-		UserID:  userID,
-		Title:   req.Title,
-		Content: req.Content,
+		Title:     req.Title,
+		Content:   req.Content,
+		Tags:      newTags(req.Tags),
+		Mood:      req.Mood,
+		MoodEmoji: req.MoodEmoji,
+		MoodLabel: req.MoodLabel,
+		Energy:    req.Energy,
+		EntryDate: entryDate,
+		TimeZone:  req.TimeZone,
 	}
+	setEncryptedContent(entry, req.Encrypted)
 
-	if err := h.service.CreateEntry(entry); err != nil {
-		http.Error(w, "Failed to create entry", http.StatusInternalServerError)
+	if err := h.service.CreateEntry(r.Context(), userID, entry); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	// Возвращаем ответ
+	w.Header().Set("ETag", entryETag(entry))
 	render.Status(r, http.StatusCreated)
 	render.JSON(w, r, map[string]interface{}{
 		"id":      entry.ID.String(),
@@ -90,95 +155,207 @@ func (h *entryHandler) GetEntry(w http.ResponseWriter, r *http.Request) {
 	// Получаем ID записи из URL
 	entryID := chi.URLParam(r, "id")
 
-	// Получаем запись
-	entry, err := h.service.GetEntryByID(entryID)
+	userID, err := sessionUserID(r)
 	if err != nil {
-		http.Error(w, "Entry not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	// Проверка доступа (только владелец может видеть свою запись)
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
-	userIDStr := sessionContainer.GetUserID()
-	if entry.UserID.String() != userIDStr {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	// Получаем запись; чужая запись не найдется
+	entry, err := h.service.GetEntryByID(r.Context(), userID, entryID)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Формируем ответ
-	response := EntryResponse{
-		ID:        entry.ID.String(),
-		UserID:    entry.UserID.String(),
-		Title:     entry.Title,
-		Content:   entry.Content,
-		CreatedAt: entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	// Клиент уже получил эту версию записи
+	etag := entryETag(entry)
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
 
-	render.JSON(w, r, response)
+	// Формируем ответ
+	render.JSON(w, r, newEntryResponse(entry))
 }
 
 func (h *entryHandler) UpdateEntry(w http.ResponseWriter, r *http.Request) {
 	// Получаем ID записи из URL
 	entryID := chi.URLParam(r, "id")
 
-	// Получаем существующую запись
-	existingEntry, err := h.service.GetEntryByID(entryID)
+	userID, err := sessionUserID(r)
 	if err != nil {
-		http.Error(w, "Entry not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	// Проверка доступа (только владелец может обновлять свою запись)
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
-	userIDStr := sessionContainer.GetUserID()
-	if existingEntry.UserID.String() != userIDStr {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	// Получаем существующую запись; чужая запись не найдется
+	existingEntry, err := h.service.GetEntryByID(r.Context(), userID, entryID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Запись могла измениться на другом устройстве
+	if !checkIfMatch(w, r, existingEntry) {
 		return
 	}
 
 	// Декодируем запрос
 	var req EntryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		writeError(w, r, errInvalidPayload)
 		return
 	}
 
 	// Обновляем запись
-	existingEntry.Title = req.Title
-	existingEntry.Content = req.Content
+	if err := applyEntryRequest(existingEntry, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	if err := h.service.UpdateEntry(existingEntry); err != nil {
-		http.Error(w, "Failed to update entry", http.StatusInternalServerError)
+	if err := h.service.UpdateEntry(r.Context(), userID, existingEntry); err != nil {
+		if errors.Is(err, repos.ErrVersionConflict) {
+			writeError(w, r, errPreconditionFailed)
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", entryETag(existingEntry))
 	render.JSON(w, r, map[string]interface{}{
 		"message": "Entry updated successfully",
 	})
 }
 
+// PatchEntry изменяет отдельные поля записи. Патч накладывается на
+// JSON-представление EntryRequest текущей записи, поэтому новые поля
+// запроса становятся доступны для PATCH без изменений в этом коде.
+func (h *entryHandler) PatchEntry(w http.ResponseWriter, r *http.Request) {
+	// Получаем ID записи из URL
+	entryID := chi.URLParam(r, "id")
+
+	// Определяем формат патча до обращения к хранилищу
+	mediaType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType != mediaTypeMergePatch && mediaType != mediaTypeJSONPatch && mediaType != "application/json" {
+		w.Header().Set("Accept-Patch", mediaTypeMergePatch+", "+mediaTypeJSONPatch)
+		writeError(w, r, errUnsupportedPatchType)
+		return
+	}
+
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Получаем существующую запись; чужая запись не найдется
+	existingEntry, err := h.service.GetEntryByID(r.Context(), userID, entryID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Запись могла измениться на другом устройстве
+	if !checkIfMatch(w, r, existingEntry) {
+		return
+	}
+
+	// Текущее состояние записи в виде JSON-документа
+	var doc any
+	current, err := json.Marshal(newEntryRequest(existingEntry))
+	if err == nil {
+		err = json.Unmarshal(current, &doc)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Накладываем патч
+	if mediaType == mediaTypeJSONPatch {
+		var ops []jsonPatchOp
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			writeError(w, r, errInvalidJSONPatch)
+			return
+		}
+		doc, err = applyJSONPatch(doc, ops)
+	} else {
+		var patch any
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeError(w, r, errInvalidPayload)
+			return
+		}
+		doc = mergePatch(doc, patch)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req EntryRequest
+	if err := decodePatchedDocument(doc, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Удаление поля патчем означает пустое значение, а не «без изменений»
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+	if req.TimeZone == "" {
+		req.TimeZone = "UTC"
+	}
+	if req.EntryDate == "" {
+		writeError(w, r, errs.Field("entry_date", "entry date is required"))
+		return
+	}
+
+	if err := applyEntryRequest(existingEntry, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.service.UpdateEntry(r.Context(), userID, existingEntry); err != nil {
+		if errors.Is(err, repos.ErrVersionConflict) {
+			writeError(w, r, errPreconditionFailed)
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", entryETag(existingEntry))
+	render.JSON(w, r, newEntryResponse(existingEntry))
+}
+
 func (h *entryHandler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
 	// Получаем ID записи из URL
 	entryID := chi.URLParam(r, "id")
 
-	// Получаем существующую запись для проверки владельца
-	existingEntry, err := h.service.GetEntryByID(entryID)
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Получаем существующую запись для проверки версии
+	existingEntry, err := h.service.GetEntryByID(r.Context(), userID, entryID)
 	if err != nil {
-		http.Error(w, "Entry not found", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	// Проверка доступа (только владелец может удалять свою запись)
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
-	userIDStr := sessionContainer.GetUserID()
-	if existingEntry.UserID.String() != userIDStr {
-		http.Error(w, "Unauthorized", http.StatusForbidden)
+	if !checkIfMatch(w, r, existingEntry) {
 		return
 	}
 
 	// Удаляем запись
-	if err := h.service.DeleteEntry(entryID); err != nil {
-		http.Error(w, "Failed to delete entry", http.StatusInternalServerError)
+	if err := h.service.DeleteEntry(r.Context(), userID, entryID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -188,30 +365,232 @@ func (h *entryHandler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *entryHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
-	// Получаем список всех записей
-	entries, err := h.service.ListEntries()
+	// Получаем userID из сессии: выборка ограничена записями пользователя
+	userID, err := sessionUserID(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve entries", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	// Получаем userID для фильтрации записей (пользователь должен видеть только свои записи)
-	sessionContainer := session.GetSessionFromRequestContext(r.Context())
-	userIDStr := sessionContainer.GetUserID()
+	// Фильтры, сортировка и пагинация из query-параметров
+	filter, err := parseEntryFilter(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	entries, nextCursor, err := h.service.ListEntriesByUser(r.Context(), userID, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	// Фильтруем записи и формируем ответ
-	var response []EntryResponse
+	// Формируем ответ
+	response := EntryListResponse{
+		Entries:    make([]EntryResponse, 0, len(entries)),
+		NextCursor: nextCursor,
+	}
 	for _, entry := range entries {
-		if entry.UserID.String() == userIDStr {
-			response = append(response, EntryResponse{
-				ID:        entry.ID.String(),
-				UserID:    entry.UserID.String(),
-				Title:     entry.Title,
-				Content:   entry.Content,
-				CreatedAt: entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			})
-		}
+		response.Entries = append(response.Entries, newEntryResponse(entry))
 	}
 
 	render.JSON(w, r, response)
 }
+
+// Разбирает параметры from, to, title, tags, tag_match, sort, order, limit и cursor.
+// Диапазон from/to относится к дате записи (entry_date). Даты принимаются
+// в RFC 3339 или как YYYY-MM-DD; дата без времени в "to" включает весь указанный день.
+func parseEntryFilter(r *http.Request) (models.EntryFilter, error) {
+	q := r.URL.Query()
+	filter := models.EntryFilter{
+		Title:  strings.TrimSpace(q.Get("title")),
+		Cursor: q.Get("cursor"),
+	}
+
+	if raw := q.Get("from"); raw != "" {
+		from, _, err := parseDateParam(raw, time.UTC)
+		if err != nil {
+			return filter, errs.Field("from", "invalid from date")
+		}
+		filter.From = &from
+	}
+	if raw := q.Get("to"); raw != "" {
+		to, dateOnly, err := parseDateParam(raw, time.UTC)
+		if err != nil {
+			return filter, errs.Field("to", "invalid to date")
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errs.Field("from", "from must be before to")
+	}
+
+	// tags=work,travel; tag_match=any (по умолчанию) или all
+	if raw := q.Get("tags"); raw != "" {
+		filter.Tags = strings.Split(raw, ",")
+	}
+	if raw := q.Get("tag_match"); raw != "" {
+		filter.TagMatch = models.TagMatch(strings.ToLower(raw))
+		if !filter.TagMatch.Valid() {
+			return filter, errs.Field("tag_match", "invalid tag_match, expected any or all")
+		}
+	}
+
+	if raw := q.Get("sort"); raw != "" {
+		filter.SortBy = models.EntrySortField(raw)
+		if !filter.SortBy.Valid() {
+			return filter, errs.Field("sort", "invalid sort field")
+		}
+	}
+	if raw := q.Get("order"); raw != "" {
+		filter.SortOrder = models.SortOrder(strings.ToLower(raw))
+		if !filter.SortOrder.Valid() {
+			return filter, errs.Field("order", "invalid sort order, expected asc or desc")
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			return filter, errs.Field("limit", "invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// Дата в RFC 3339 или YYYY-MM-DD; дата без времени отсчитывается от полуночи в loc
+func parseDateParam(raw string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, raw, loc)
+	return t, true, err
+}
+
+func newEntryResponse(entry *models.Entry) EntryResponse {
+	return EntryResponse{
+		ID:        entry.ID.String(),
+		UserID:    entry.UserID.String(),
+		Title:     entry.Title,
+		Content:   entry.Content,
+		Tags:      tagNames(entry.Tags),
+		Mood:      entry.Mood,
+		MoodEmoji: entry.MoodEmoji,
+		MoodLabel: entry.MoodLabel,
+		Energy:    entry.Energy,
+		EntryDate: entry.EntryDate.In(entryLocation(entry)).Format(time.RFC3339),
+		TimeZone:  entry.TimeZone,
+		CreatedAt: entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: entry.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Version:   entry.Version,
+		Encrypted: newEncryptedContent(entry),
+	}
+}
+
+func newEntryRequest(entry *models.Entry) EntryRequest {
+	return EntryRequest{
+		Title:     entry.Title,
+		Content:   entry.Content,
+		Tags:      tagNames(entry.Tags),
+		Mood:      entry.Mood,
+		MoodEmoji: entry.MoodEmoji,
+		MoodLabel: entry.MoodLabel,
+		Energy:    entry.Energy,
+		EntryDate: entry.EntryDate.In(entryLocation(entry)).Format(time.RFC3339),
+		TimeZone:  entry.TimeZone,
+		Encrypted: newEncryptedContent(entry),
+	}
+}
+
+func newEncryptedContent(entry *models.Entry) *EncryptedContent {
+	if !entry.Encrypted() {
+		return nil
+	}
+	return &EncryptedContent{
+		Algorithm:  entry.Algorithm,
+		KeyID:      entry.KeyID,
+		Nonce:      entry.Nonce,
+		Ciphertext: entry.Ciphertext,
+	}
+}
+
+func setEncryptedContent(entry *models.Entry, content *EncryptedContent) {
+	if content == nil {
+		return
+	}
+	entry.Algorithm = content.Algorithm
+	entry.KeyID = content.KeyID
+	entry.Nonce = content.Nonce
+	entry.Ciphertext = content.Ciphertext
+}
+
+// Переносит поля запроса в запись с семантикой PUT: отсутствующие теги,
+// дата и часовой пояс остаются без изменений
+func applyEntryRequest(entry *models.Entry, req *EntryRequest) error {
+	if (req.Encrypted != nil) != entry.Encrypted() {
+		return errEncryptionChange
+	}
+	setEncryptedContent(entry, req.Encrypted)
+	entry.Title = req.Title
+	entry.Content = req.Content
+	if req.Tags != nil {
+		entry.Tags = newTags(req.Tags)
+	}
+	entry.Mood = req.Mood
+	entry.MoodEmoji = req.MoodEmoji
+	entry.MoodLabel = req.MoodLabel
+	entry.Energy = req.Energy
+	if req.TimeZone != "" {
+		entry.TimeZone = req.TimeZone
+	}
+	if req.EntryDate != "" {
+		date, err := parseEntryDate(req.EntryDate, entry.TimeZone)
+		if err != nil {
+			return errInvalidEntryDate
+		}
+		entry.EntryDate = date
+	}
+	return nil
+}
+
+// Разбирает дату записи; дата без времени означает полночь в поясе пользователя.
+// Пустая строка означает, что дата не указана.
+func parseEntryDate(raw, timeZone string) (time.Time, error) {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, _, err := parseDateParam(raw, loc)
+	return t, err
+}
+
+func entryLocation(entry *models.Entry) *time.Location {
+	if loc, err := time.LoadLocation(entry.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func newTags(names []string) []models.Tag {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.Tag{Name: name})
+	}
+	return tags
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
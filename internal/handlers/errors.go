@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"diary/internal/errs"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// --- Ответы с ошибками ---
+
+// Единый формат ошибки:
+//
+//	{"error": {"code": "validation_failed", "message": "...",
+//	           "fields": [{"field": "mood", "message": "..."}], "request_id": "..."}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string               `json:"code"`
+	Message   string               `json:"message"`
+	Fields    []FieldErrorResponse `json:"fields,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Ошибки протокола HTTP, у которых нет аналога в предметной области
+type statusError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// Нестандартный статус (nginx) для запросов, прерванных клиентом
+const statusClientClosedRequest = 499
+
+var (
+	errInvalidUserID        = &statusError{http.StatusBadRequest, "bad_request", "invalid user ID"}
+	errInvalidPayload       = &statusError{http.StatusBadRequest, "bad_request", "invalid request payload"}
+	errUnauthorized         = &statusError{http.StatusUnauthorized, "unauthorized", "authentication is required"}
+	errInsufficientScope    = &statusError{http.StatusForbidden, "insufficient_scope", "access token does not grant this operation"}
+	errNotPermitted         = &statusError{http.StatusForbidden, "forbidden", "operation is not permitted for this user"}
+	errPreconditionRequired = &statusError{http.StatusPreconditionRequired, "precondition_required", "If-Match header is required"}
+	errPreconditionFailed   = &statusError{http.StatusPreconditionFailed, "precondition_failed", "entry has been modified"}
+	errUnsupportedPatchType = &statusError{http.StatusUnsupportedMediaType, "unsupported_media_type", "unsupported patch document type"}
+)
+
+// Переводит ошибку в HTTP-статус и JSON-ответ. Неизвестные ошибки
+// считаются внутренними: клиент получает общее сообщение, подробности
+// пишутся в лог вместе с ID запроса.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := middleware.GetReqID(r.Context())
+	body := ErrorBody{RequestID: requestID}
+	status := http.StatusInternalServerError
+
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		status, body.Code, body.Message = se.status, se.code, se.message
+	case errors.Is(err, errs.ErrNotFound):
+		status, body.Code = http.StatusNotFound, "not_found"
+	case errors.Is(err, errs.ErrForbidden):
+		status, body.Code = http.StatusForbidden, "forbidden"
+	case errors.Is(err, errs.ErrValidation):
+		// Запрос разобран, но значения полей недопустимы
+		status, body.Code = http.StatusUnprocessableEntity, "validation_failed"
+	case errors.Is(err, errs.ErrConflict):
+		status, body.Code = http.StatusConflict, "conflict"
+	// Драйвер базы не всегда возвращает ошибку контекста как есть,
+	// поэтому проверяем и сам контекст запроса
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(r.Context().Err(), context.DeadlineExceeded):
+		status, body.Code, body.Message = http.StatusGatewayTimeout, "timeout", "request timed out"
+	case errors.Is(err, context.Canceled) || errors.Is(r.Context().Err(), context.Canceled):
+		// Клиент отключился, ответ никто не прочитает
+		status, body.Code, body.Message = statusClientClosedRequest, "canceled", "request canceled"
+	default:
+		log.Printf("diary: request %s %s %s failed: %v", requestID, r.Method, r.URL.Path, err)
+		body.Code, body.Message = "internal", "internal server error"
+	}
+
+	if body.Message == "" {
+		message, fields := errs.Details(err)
+		body.Message = message
+		for _, f := range fields {
+			body.Fields = append(body.Fields, FieldErrorResponse{Field: f.Field, Message: f.Message})
+		}
+	}
+	if body.Message == "" {
+		body.Message = err.Error()
+	}
+
+	render.Status(r, status)
+	render.JSON(w, r, ErrorResponse{Error: body})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"diary/internal/errs"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		fields int
+	}{
+		{"not found", errs.NotFound("entry not found").Wrap(errors.New("record not found")), http.StatusNotFound, "not_found", 0},
+		{"forbidden", errs.Forbidden("acting user is required"), http.StatusForbidden, "forbidden", 0},
+		{"validation", errs.Field("mood", "invalid mood"), http.StatusUnprocessableEntity, "validation_failed", 1},
+		{"bad request", errInvalidPayload, http.StatusBadRequest, "bad_request", 0},
+		{"conflict", errs.Conflict("entry has been modified"), http.StatusConflict, "conflict", 0},
+		{"precondition", errPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed", 0},
+		{"timeout", fmt.Errorf("query entries: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout", 0},
+		{"canceled", context.Canceled, statusClientClosedRequest, "canceled", 0},
+		{"internal", errors.New("database is locked"), http.StatusInternalServerError, "internal", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			rec := httptest.NewRecorder()
+			handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, tt.err)
+			}))
+
+			// Act
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/entries/1", nil))
+
+			// Assert
+			assert.Equal(t, tt.status, rec.Code)
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Error.Code)
+			assert.NotEmpty(t, response.Error.Message)
+			assert.NotEmpty(t, response.Error.RequestID)
+			assert.Len(t, response.Error.Fields, tt.fields)
+		})
+	}
+}
+
+func TestWriteErrorHidesInternalDetails(t *testing.T) {
+	// Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/entries", nil)
+
+	// Act
+	writeError(rec, req, errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+
+	// Assert
+	assert.NotContains(t, rec.Body.String(), "10.0.0.5")
+}
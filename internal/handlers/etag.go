@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"diary/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// --- ETag и условные запросы ---
+
+// ETag записи строится по счетчику версий
+func entryETag(entry *models.Entry) string {
+	return `"` + strconv.Itoa(entry.Version) + `"`
+}
+
+// Проверяет список ETag из If-Match/If-None-Match ("*" или значения через запятую).
+// При слабом сравнении (If-None-Match) префикс W/ игнорируется,
+// при сильном (If-Match) слабые ETag не совпадают ни с чем.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Изменение записи требует If-Match с ее текущим ETag: без заголовка
+// возвращается 428, при несовпадении - 412. При ошибке ответ уже записан.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, entry *models.Entry) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		writeError(w, r, errPreconditionRequired)
+		return false
+	}
+	if !etagMatches(header, entryETag(entry), false) {
+		w.Header().Set("ETag", entryETag(entry))
+		writeError(w, r, errPreconditionFailed)
+		return false
+	}
+	return true
+}
@@ -5,31 +5,91 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/supertokens/supertokens-golang/recipe/session"
 )
 
 // Главный интерфейс объединяет все подобработчики
 type Handler interface {
 	EntryHandler
+	SearchHandler
+	TagHandler
+	StatsHandler
+	TrashHandler
+	RevisionHandler
+	TokenHandler
+	AdminHandler
+	KeyHandler
 	RegisterRoutes(r *chi.Mux)
 }
 
 // --- Комбинирующий обработчик ---
 
 type handler struct {
-	entryHandler EntryHandler
+	entryHandler    EntryHandler
+	searchHandler   SearchHandler
+	tagHandler      TagHandler
+	statsHandler    StatsHandler
+	trashHandler    TrashHandler
+	revisionHandler RevisionHandler
+	tokenHandler    TokenHandler
+	adminHandler    AdminHandler
+	keyHandler      KeyHandler
+	// Персональные токены или основной аутентификатор
+	auth  Authenticator
+	roles Roles
 }
 
-// Регистрация маршрутов для всего приложения
+// Регистрация маршрутов для всего приложения. Каждый маршрут объявляет
+// политику доступа (policy.go); h.allow проверяет аутентификацию и политику.
 func (h *handler) RegisterRoutes(r *chi.Mux) {
 	r.Route("/api/entries", func(r chi.Router) {
-		// Все маршруты требуют аутентификации
-		// Заворачиваем каждый маршрут в session.VerifySession для проверки авторизации
-		r.Post("/", session.VerifySession(nil, h.CreateEntry))
-		r.Get("/{id}", session.VerifySession(nil, h.GetEntry))
-		r.Put("/{id}", session.VerifySession(nil, h.UpdateEntry))
-		r.Delete("/{id}", session.VerifySession(nil, h.DeleteEntry))
-		r.Get("/", session.VerifySession(nil, h.ListEntries))
+		r.Post("/", h.allow(writeEntries, h.CreateEntry))
+		r.Get("/{id}", h.allow(readEntries, h.GetEntry))
+		r.Put("/{id}", h.allow(writeEntries, h.UpdateEntry))
+		r.Patch("/{id}", h.allow(writeEntries, h.PatchEntry))
+		r.Delete("/{id}", h.allow(writeEntries, h.DeleteEntry))
+		r.Get("/", h.allow(readEntries, h.ListEntries))
+		r.Get("/search", h.allow(readEntries, h.SearchEntries))
+		r.Post("/{id}/restore", h.allow(writeEntries, h.RestoreEntry))
+		r.Get("/{id}/revisions", h.allow(readEntries, h.ListRevisions))
+		r.Get("/{id}/revisions/diff", h.allow(readEntries, h.DiffRevisions))
+		r.Get("/{id}/revisions/{rev}", h.allow(readEntries, h.GetRevision))
+		r.Post("/{id}/revisions/{rev}/restore", h.allow(writeEntries, h.RestoreRevision))
+	})
+
+	r.Route("/api/trash", func(r chi.Router) {
+		r.Get("/", h.allow(readEntries, h.ListTrash))
+		r.Delete("/{id}", h.allow(writeEntries, h.PurgeEntry))
+	})
+
+	r.Route("/api/tags", func(r chi.Router) {
+		r.Get("/", h.allow(readEntries, h.ListTags))
+		r.Post("/merge", h.allow(writeEntries, h.MergeTags))
+		r.Put("/{name}", h.allow(writeEntries, h.RenameTag))
+	})
+
+	r.Route("/api/stats", func(r chi.Router) {
+		r.Get("/mood", h.allow(readEntries, h.MoodStats))
+	})
+
+	// Право tokens:manage есть только у сессий: токен не может выпустить
+	// другой токен или расширить свои права
+	r.Route("/api/tokens", func(r chi.Router) {
+		r.Post("/", h.allow(manageTokens, h.CreateToken))
+		r.Get("/", h.allow(manageTokens, h.ListTokens))
+		r.Delete("/{id}", h.allow(manageTokens, h.RevokeToken))
+	})
+
+	// Обернутые ключи шифрования записей: без ключа зашифрованные записи
+	// не прочитать, поэтому и чтение, и запись - права на записи
+	r.Route("/api/keys", func(r chi.Router) {
+		r.Get("/", h.allow(readEntries, h.ListKeys))
+		r.Get("/{id}", h.allow(readEntries, h.GetKey))
+		r.Put("/{id}", h.allow(writeEntries, h.PutKey))
+	})
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Get("/users", h.allow(readAdmin, h.ListUsers))
+		r.Get("/usage", h.allow(readAdmin, h.Usage))
 	})
 }
 
@@ -47,6 +107,10 @@ func (h *handler) UpdateEntry(w http.ResponseWriter, r *http.Request) {
 	h.entryHandler.UpdateEntry(w, r)
 }
 
+func (h *handler) PatchEntry(w http.ResponseWriter, r *http.Request) {
+	h.entryHandler.PatchEntry(w, r)
+}
+
 func (h *handler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
 	h.entryHandler.DeleteEntry(w, r)
 }
@@ -55,10 +119,116 @@ func (h *handler) ListEntries(w http.ResponseWriter, r *http.Request) {
 	h.entryHandler.ListEntries(w, r)
 }
 
+// Прокси-методы SearchHandler
+
+func (h *handler) SearchEntries(w http.ResponseWriter, r *http.Request) {
+	h.searchHandler.SearchEntries(w, r)
+}
+
+// Прокси-методы TagHandler
+
+func (h *handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	h.tagHandler.ListTags(w, r)
+}
+
+func (h *handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	h.tagHandler.RenameTag(w, r)
+}
+
+func (h *handler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	h.tagHandler.MergeTags(w, r)
+}
+
+// Прокси-методы StatsHandler
+
+func (h *handler) MoodStats(w http.ResponseWriter, r *http.Request) {
+	h.statsHandler.MoodStats(w, r)
+}
+
+// Прокси-методы TrashHandler
+
+func (h *handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	h.trashHandler.ListTrash(w, r)
+}
+
+func (h *handler) RestoreEntry(w http.ResponseWriter, r *http.Request) {
+	h.trashHandler.RestoreEntry(w, r)
+}
+
+func (h *handler) PurgeEntry(w http.ResponseWriter, r *http.Request) {
+	h.trashHandler.PurgeEntry(w, r)
+}
+
+// Прокси-методы RevisionHandler
+
+func (h *handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	h.revisionHandler.ListRevisions(w, r)
+}
+
+func (h *handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	h.revisionHandler.GetRevision(w, r)
+}
+
+func (h *handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	h.revisionHandler.DiffRevisions(w, r)
+}
+
+func (h *handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	h.revisionHandler.RestoreRevision(w, r)
+}
+
+// Прокси-методы TokenHandler
+
+func (h *handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	h.tokenHandler.CreateToken(w, r)
+}
+
+func (h *handler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	h.tokenHandler.ListTokens(w, r)
+}
+
+func (h *handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	h.tokenHandler.RevokeToken(w, r)
+}
+
+// Прокси-методы AdminHandler
+
+func (h *handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	h.adminHandler.ListUsers(w, r)
+}
+
+func (h *handler) Usage(w http.ResponseWriter, r *http.Request) {
+	h.adminHandler.Usage(w, r)
+}
+
+// Прокси-методы KeyHandler
+
+func (h *handler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	h.keyHandler.ListKeys(w, r)
+}
+
+func (h *handler) GetKey(w http.ResponseWriter, r *http.Request) {
+	h.keyHandler.GetKey(w, r)
+}
+
+func (h *handler) PutKey(w http.ResponseWriter, r *http.Request) {
+	h.keyHandler.PutKey(w, r)
+}
+
 // --- Конструктор комбинирующего обработчика ---
 
-func NewHandler(service services.Service) Handler {
+func NewHandler(service services.Service, auth Authenticator, roles Roles) Handler {
 	return &handler{
-		entryHandler: NewEntryHandler(service),
+		entryHandler:    NewEntryHandler(service),
+		searchHandler:   NewSearchHandler(service),
+		tagHandler:      NewTagHandler(service),
+		statsHandler:    NewStatsHandler(service),
+		trashHandler:    NewTrashHandler(service),
+		revisionHandler: NewRevisionHandler(service),
+		tokenHandler:    NewTokenHandler(service),
+		adminHandler:    NewAdminHandler(service),
+		keyHandler:      NewKeyHandler(service),
+		auth:            NewTokenAuthenticator(service, auth),
+		roles:           roles,
 	}
 }
@@ -0,0 +1,1013 @@
+package handlers
+
+import (
+	"context"
+	"diary/internal/e2ee"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"diary/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// --- Тестовая аутентификация ---
+
+// Пользователь запроса передается заголовком вместо сессии SuperTokens;
+// заголовок с правами превращает запрос в запрос с токеном
+const (
+	testUserHeader   = "X-Test-User"
+	testScopesHeader = "X-Test-Scopes"
+)
+
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) Verify(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := r.Header.Get(testUserHeader)
+		if raw == "" {
+			writeError(w, r, errUnauthorized)
+			return
+		}
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, r, errInvalidUserID)
+			return
+		}
+		principal := Principal{UserID: userID}
+		if scopes, ok := r.Header[testScopesHeader]; ok {
+			principal.Token = &models.PersonalAccessToken{UserID: userID, Scopes: strings.Join(scopes, " ")}
+		}
+		next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// Роль без прав: с ней недоступен ни один маршрут
+type guestRoles struct{}
+
+func (guestRoles) Role(ctx context.Context, userID uuid.UUID) models.Role {
+	return "guest"
+}
+
+// Сервис, у которого отдельные методы возвращают заданную ошибку
+type failingService struct {
+	services.Service
+	err error
+}
+
+func (s *failingService) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	return nil, s.err
+}
+
+func (s *failingService) GetEntryByID(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	return nil, s.err
+}
+
+// Все маршруты RegisterRoutes и их политики; TestRoutesAreCovered сверяет
+// список с роутером
+var testRoutes = []struct {
+	method, path string
+	policy       Policy
+}{
+	{http.MethodPost, "/api/entries/", writeEntries},
+	{http.MethodGet, "/api/entries/{id}", readEntries},
+	{http.MethodPut, "/api/entries/{id}", writeEntries},
+	{http.MethodPatch, "/api/entries/{id}", writeEntries},
+	{http.MethodDelete, "/api/entries/{id}", writeEntries},
+	{http.MethodGet, "/api/entries/", readEntries},
+	{http.MethodGet, "/api/entries/search", readEntries},
+	{http.MethodPost, "/api/entries/{id}/restore", writeEntries},
+	{http.MethodGet, "/api/entries/{id}/revisions", readEntries},
+	{http.MethodGet, "/api/entries/{id}/revisions/diff", readEntries},
+	{http.MethodGet, "/api/entries/{id}/revisions/{rev}", readEntries},
+	{http.MethodPost, "/api/entries/{id}/revisions/{rev}/restore", writeEntries},
+	{http.MethodGet, "/api/trash/", readEntries},
+	{http.MethodDelete, "/api/trash/{id}", writeEntries},
+	{http.MethodGet, "/api/tags/", readEntries},
+	{http.MethodPost, "/api/tags/merge", writeEntries},
+	{http.MethodPut, "/api/tags/{name}", writeEntries},
+	{http.MethodGet, "/api/stats/mood", readEntries},
+	{http.MethodPost, "/api/tokens/", manageTokens},
+	{http.MethodGet, "/api/tokens/", manageTokens},
+	{http.MethodDelete, "/api/tokens/{id}", manageTokens},
+	{http.MethodGet, "/api/keys/", readEntries},
+	{http.MethodGet, "/api/keys/{id}", readEntries},
+	{http.MethodPut, "/api/keys/{id}", writeEntries},
+	{http.MethodGet, "/api/admin/users", readAdmin},
+	{http.MethodGet, "/api/admin/usage", readAdmin},
+}
+
+// Путь маршрута с подставленными параметрами
+func routePath(pattern string) string {
+	return strings.NewReplacer("{id}", uuid.NewString(), "{rev}", "1", "{name}", "work").Replace(pattern)
+}
+
+func newTestRouter(service services.Service, roles Roles) *chi.Mux {
+	router := chi.NewRouter()
+	NewHandler(service, fakeAuthenticator{}, roles).RegisterRoutes(router)
+	return router
+}
+
+type HandlerTestSuite struct {
+	suite.Suite
+	service services.Service
+	router  *chi.Mux
+	userID  uuid.UUID
+	adminID uuid.UUID
+}
+
+func (suite *HandlerTestSuite) SetupTest() {
+	suite.service = services.NewService(repos.NewMemoryRepository(), services.DefaultEntryLimits())
+	suite.adminID = uuid.New()
+	suite.router = newTestRouter(suite.service, NewStaticRoles([]uuid.UUID{suite.adminID}))
+	suite.userID = uuid.New()
+}
+
+// Запрос от имени пользователя; пары заголовков передаются через headers
+func (suite *HandlerTestSuite) do(userID uuid.UUID, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(testUserHeader, userID.String())
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *HandlerTestSuite) createEntry(body string) (string, string) {
+	rec := suite.do(suite.userID, http.MethodPost, "/api/entries/", body)
+	suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+	var response struct {
+		ID string `json:"id"`
+	}
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.ID, rec.Header().Get("ETag")
+}
+
+func (suite *HandlerTestSuite) decode(rec *httptest.ResponseRecorder, v any) {
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+}
+
+func (suite *HandlerTestSuite) assertError(rec *httptest.ResponseRecorder, status int, code string) ErrorResponse {
+	suite.Require().Equal(status, rec.Code, rec.Body.String())
+	var response ErrorResponse
+	suite.decode(rec, &response)
+	assert.Equal(suite.T(), code, response.Error.Code)
+	return response
+}
+
+// --- Доступ ---
+
+func (suite *HandlerTestSuite) TestRoutesAreCovered() {
+	// Arrange
+	var registered []string
+	suite.Require().NoError(chi.Walk(suite.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered = append(registered, method+" "+route)
+		return nil
+	}))
+	var expected []string
+	for _, route := range testRoutes {
+		expected = append(expected, route.method+" "+route.path)
+	}
+
+	// Assert - новый маршрут нужно добавить в testRoutes
+	sort.Strings(registered)
+	sort.Strings(expected)
+	assert.Equal(suite.T(), expected, registered)
+}
+
+func (suite *HandlerTestSuite) TestUnauthenticatedRequests() {
+	for _, route := range testRoutes {
+		suite.Run(route.method+" "+route.path, func() {
+			// Arrange
+			req := httptest.NewRequest(route.method, routePath(route.path), strings.NewReader(`{}`))
+
+			// Act
+			rec := httptest.NewRecorder()
+			suite.router.ServeHTTP(rec, req)
+
+			// Assert
+			suite.assertError(rec, http.StatusUnauthorized, "unauthorized")
+		})
+	}
+}
+
+func (suite *HandlerTestSuite) TestRoutePolicies() {
+	guests := newTestRouter(suite.service, guestRoles{})
+	for _, route := range testRoutes {
+		suite.Run(route.method+" "+route.path, func() {
+			// Arrange
+			request := func(userID uuid.UUID, scopes ...string) *http.Request {
+				req := httptest.NewRequest(route.method, routePath(route.path), strings.NewReader(`{}`))
+				req.Header.Set(testUserHeader, userID.String())
+				for _, scope := range scopes {
+					req.Header.Add(testScopesHeader, scope)
+				}
+				return req
+			}
+
+			// Act
+			guest := httptest.NewRecorder()
+			guests.ServeHTTP(guest, request(suite.adminID))
+			noScopes := httptest.NewRecorder()
+			suite.router.ServeHTTP(noScopes, request(suite.adminID, ""))
+			granted := httptest.NewRecorder()
+			suite.router.ServeHTTP(granted, request(suite.adminID, string(route.policy.scope)))
+
+			// Assert - роль без прав и токен без прав не проходят ни один
+			// маршрут, право из политики открывает маршрут
+			suite.assertError(guest, http.StatusForbidden, "forbidden")
+			suite.assertError(noScopes, http.StatusForbidden, "insufficient_scope")
+			assert.NotEqual(suite.T(), http.StatusForbidden, granted.Code, granted.Body.String())
+		})
+	}
+}
+
+func (suite *HandlerTestSuite) TestZeroPolicyDeniesAll() {
+	// Arrange
+	router := chi.NewRouter()
+	h := NewHandler(suite.service, fakeAuthenticator{}, NewStaticRoles([]uuid.UUID{suite.adminID})).(*handler)
+	router.Get("/", h.allow(Policy{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(testUserHeader, suite.adminID.String())
+
+	// Act
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// Assert
+	suite.assertError(rec, http.StatusForbidden, "forbidden")
+}
+
+func (suite *HandlerTestSuite) TestInvalidUserID() {
+	// Arrange
+	req := httptest.NewRequest(http.MethodGet, "/api/tags/", nil)
+	req.Header.Set(testUserHeader, "not-a-uuid")
+
+	// Act
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	// Assert
+	suite.assertError(rec, http.StatusBadRequest, "bad_request")
+}
+
+func (suite *HandlerTestSuite) TestNilUserIsForbidden() {
+	// Act - сервис отказывает запросам без действующего пользователя
+	rec := suite.do(uuid.Nil, http.MethodGet, "/api/entries/", "")
+
+	// Assert
+	suite.assertError(rec, http.StatusForbidden, "forbidden")
+}
+
+func (suite *HandlerTestSuite) TestForeignEntryIsNotFound() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Private","content":"Secret"}`)
+	other := uuid.New()
+
+	// Act & Assert - чужая запись не отличается от несуществующей
+	suite.assertError(suite.do(other, http.MethodGet, "/api/entries/"+id, ""), http.StatusNotFound, "not_found")
+	suite.assertError(suite.do(other, http.MethodPut, "/api/entries/"+id, `{"title":"Stolen"}`, "If-Match", etag), http.StatusNotFound, "not_found")
+	suite.assertError(suite.do(other, http.MethodDelete, "/api/entries/"+id, "", "If-Match", etag), http.StatusNotFound, "not_found")
+	suite.assertError(suite.do(other, http.MethodGet, "/api/entries/"+id+"/revisions/1", ""), http.StatusNotFound, "not_found")
+
+	rec := suite.do(suite.userID, http.MethodGet, "/api/entries/"+id, "")
+	suite.Require().Equal(http.StatusOK, rec.Code)
+	var entry EntryResponse
+	suite.decode(rec, &entry)
+	assert.Equal(suite.T(), "Private", entry.Title)
+}
+
+// --- Записи ---
+
+func (suite *HandlerTestSuite) TestCreateAndGetEntry() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"First","content":"Hello","tags":["work"],"mood":4,"entry_date":"2024-05-01"}`)
+
+	// Act
+	rec := suite.do(suite.userID, http.MethodGet, "/api/entries/"+id, "")
+	notModified := suite.do(suite.userID, http.MethodGet, "/api/entries/"+id, "", "If-None-Match", etag)
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, rec.Code)
+	assert.Equal(suite.T(), `"1"`, etag)
+	assert.Equal(suite.T(), etag, rec.Header().Get("ETag"))
+	var entry EntryResponse
+	suite.decode(rec, &entry)
+	assert.Equal(suite.T(), suite.userID.String(), entry.UserID)
+	assert.Equal(suite.T(), []string{"work"}, entry.Tags)
+	assert.Equal(suite.T(), 4, *entry.Mood)
+	assert.Equal(suite.T(), http.StatusNotModified, notModified.Code)
+	assert.Empty(suite.T(), notModified.Body.String())
+}
+
+func (suite *HandlerTestSuite) TestCreateEntryBadPayload() {
+	// Act
+	rec := suite.do(suite.userID, http.MethodPost, "/api/entries/", `{"title":`)
+
+	// Assert
+	suite.assertError(rec, http.StatusBadRequest, "bad_request")
+}
+
+func (suite *HandlerTestSuite) TestCreateEntryValidation() {
+	// Act
+	rec := suite.do(suite.userID, http.MethodPost, "/api/entries/", `{"title":" ","content":"Text","mood":9}`)
+
+	// Assert - ошибки по полям возвращаются все сразу
+	response := suite.assertError(rec, http.StatusUnprocessableEntity, "validation_failed")
+	var fields []string
+	for _, f := range response.Error.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(suite.T(), []string{"title", "mood"}, fields)
+}
+
+func (suite *HandlerTestSuite) TestUpdateEntryPreconditions() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Draft","content":"Text"}`)
+	path := "/api/entries/" + id
+
+	// Act & Assert
+	suite.assertError(suite.do(suite.userID, http.MethodPut, path, `{"title":"Final"}`), http.StatusPreconditionRequired, "precondition_required")
+	suite.assertError(suite.do(suite.userID, http.MethodPut, path, `{"title":"Final"}`, "If-Match", `"7"`), http.StatusPreconditionFailed, "precondition_failed")
+	suite.assertError(suite.do(suite.userID, http.MethodPut, path, `{"title":`, "If-Match", etag), http.StatusBadRequest, "bad_request")
+
+	rec := suite.do(suite.userID, http.MethodPut, path, `{"title":"Final"}`, "If-Match", etag)
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(suite.T(), `"2"`, rec.Header().Get("ETag"))
+
+	// Старая версия после обновления не принимается
+	suite.assertError(suite.do(suite.userID, http.MethodPut, path, `{"title":"Again"}`, "If-Match", etag), http.StatusPreconditionFailed, "precondition_failed")
+}
+
+func (suite *HandlerTestSuite) TestPatchEntry() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Draft","content":"Text","tags":["work"]}`)
+	path := "/api/entries/" + id
+
+	// Act
+	unsupported := suite.do(suite.userID, http.MethodPatch, path, `{"title":"Final"}`, "Content-Type", "text/plain", "If-Match", etag)
+	missing := suite.do(suite.userID, http.MethodPatch, path, `{"title":"Final"}`, "Content-Type", mediaTypeMergePatch)
+	invalid := suite.do(suite.userID, http.MethodPatch, path, `[{"op":"jump"}]`, "Content-Type", mediaTypeJSONPatch, "If-Match", etag)
+	rec := suite.do(suite.userID, http.MethodPatch, path, `{"title":"Final","tags":null}`, "Content-Type", mediaTypeMergePatch, "If-Match", etag)
+
+	// Assert
+	suite.assertError(unsupported, http.StatusUnsupportedMediaType, "unsupported_media_type")
+	assert.Contains(suite.T(), unsupported.Header().Get("Accept-Patch"), mediaTypeMergePatch)
+	suite.assertError(missing, http.StatusPreconditionRequired, "precondition_required")
+	assert.GreaterOrEqual(suite.T(), invalid.Code, http.StatusBadRequest)
+	assert.Less(suite.T(), invalid.Code, http.StatusInternalServerError)
+
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	var entry EntryResponse
+	suite.decode(rec, &entry)
+	assert.Equal(suite.T(), "Final", entry.Title)
+	assert.Equal(suite.T(), "Text", entry.Content)
+	assert.Empty(suite.T(), entry.Tags)
+}
+
+func (suite *HandlerTestSuite) TestDeleteAndRestoreEntry() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Draft","content":"Text"}`)
+	path := "/api/entries/" + id
+
+	// Act & Assert - удаление требует If-Match и переносит запись в корзину
+	suite.assertError(suite.do(suite.userID, http.MethodDelete, path, ""), http.StatusPreconditionRequired, "precondition_required")
+	suite.Require().Equal(http.StatusOK, suite.do(suite.userID, http.MethodDelete, path, "", "If-Match", etag).Code)
+	suite.assertError(suite.do(suite.userID, http.MethodGet, path, ""), http.StatusNotFound, "not_found")
+
+	rec := suite.do(suite.userID, http.MethodGet, "/api/trash/", "")
+	suite.Require().Equal(http.StatusOK, rec.Code)
+	var trash TrashListResponse
+	suite.decode(rec, &trash)
+	suite.Require().Len(trash.Entries, 1)
+	assert.Equal(suite.T(), id, trash.Entries[0].ID)
+	assert.NotEmpty(suite.T(), trash.Entries[0].DeletedAt)
+
+	suite.Require().Equal(http.StatusOK, suite.do(suite.userID, http.MethodPost, path+"/restore", "").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.do(suite.userID, http.MethodGet, path, "").Code)
+	suite.assertError(suite.do(suite.userID, http.MethodPost, path+"/restore", ""), http.StatusNotFound, "not_found")
+}
+
+func (suite *HandlerTestSuite) TestPurgeEntry() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Draft","content":"Text"}`)
+
+	// Act & Assert - окончательно удалить можно только запись из корзины
+	suite.assertError(suite.do(suite.userID, http.MethodDelete, "/api/trash/"+id, ""), http.StatusNotFound, "not_found")
+	suite.Require().Equal(http.StatusOK, suite.do(suite.userID, http.MethodDelete, "/api/entries/"+id, "", "If-Match", etag).Code)
+	suite.Require().Equal(http.StatusOK, suite.do(suite.userID, http.MethodDelete, "/api/trash/"+id, "").Code)
+	suite.assertError(suite.do(suite.userID, http.MethodPost, "/api/entries/"+id+"/restore", ""), http.StatusNotFound, "not_found")
+}
+
+func (suite *HandlerTestSuite) TestListEntries() {
+	// Arrange
+	suite.createEntry(`{"title":"Alpha","content":"Text","tags":["work"]}`)
+	suite.createEntry(`{"title":"Beta","content":"Text","tags":["home"]}`)
+	suite.createEntry(`{"title":"Gamma","content":"Text","tags":["work"]}`)
+
+	// Act
+	rec := suite.do(suite.userID, http.MethodGet, "/api/entries/?tags=work&sort=title&order=asc&limit=1", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	var page EntryListResponse
+	suite.decode(rec, &page)
+	suite.Require().Len(page.Entries, 1)
+	assert.Equal(suite.T(), "Alpha", page.Entries[0].Title)
+	suite.Require().NotEmpty(page.NextCursor)
+
+	rec = suite.do(suite.userID, http.MethodGet, "/api/entries/?tags=work&sort=title&order=asc&limit=1&cursor="+page.NextCursor, "")
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	suite.decode(rec, &page)
+	suite.Require().Len(page.Entries, 1)
+	assert.Equal(suite.T(), "Gamma", page.Entries[0].Title)
+}
+
+func (suite *HandlerTestSuite) TestListEntriesInvalidQuery() {
+	for _, query := range []string{"limit=abc", "from=yesterday", "sort=mood", "order=up", "tag_match=some", "cursor=broken"} {
+		suite.Run(query, func() {
+			// Act
+			rec := suite.do(suite.userID, http.MethodGet, "/api/entries/?"+query, "")
+
+			// Assert
+			suite.assertError(rec, http.StatusUnprocessableEntity, "validation_failed")
+		})
+	}
+}
+
+// --- Поиск ---
+
+func (suite *HandlerTestSuite) TestSearchEntries() {
+	// Arrange
+	suite.createEntry(`{"title":"Mountains","content":"Trip to the mountains"}`)
+	suite.createEntry(`{"title":"Groceries","content":"Milk"}`)
+
+	// Act
+	rec := suite.do(suite.userID, http.MethodGet, "/api/entries/search?q=mountains", "")
+	foreign := suite.do(uuid.New(), http.MethodGet, "/api/entries/search?q=mountains", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	var response SearchResponse
+	suite.decode(rec, &response)
+	assert.Equal(suite.T(), "mountains", response.Query)
+	suite.Require().Len(response.Results, 1)
+	assert.Equal(suite.T(), "Mountains", response.Results[0].Entry.Title)
+
+	suite.Require().Equal(http.StatusOK, foreign.Code)
+	suite.decode(foreign, &response)
+	assert.Empty(suite.T(), response.Results)
+}
+
+func (suite *HandlerTestSuite) TestSearchEntriesInvalidQuery() {
+	// Act & Assert
+	suite.assertError(suite.do(suite.userID, http.MethodGet, "/api/entries/search?q=+", ""), http.StatusUnprocessableEntity, "validation_failed")
+	suite.assertError(suite.do(suite.userID, http.MethodGet, "/api/entries/search?q=x&limit=-1", ""), http.StatusUnprocessableEntity, "validation_failed")
+}
+
+// --- Ревизии ---
+
+func (suite *HandlerTestSuite) TestRevisions() {
+	// Arrange
+	id, etag := suite.createEntry(`{"title":"Draft","content":"First line"}`)
+	path := "/api/entries/" + id
+	suite.Require().Equal(http.StatusOK, suite.do(suite.userID, http.MethodPut, path, `{"title":"Draft","content":"Second line"}`, "If-Match", etag).Code)
+
+	// Act
+	list := suite.do(suite.userID, http.MethodGet, path+"/revisions", "")
+	first := suite.do(suite.userID, http.MethodGet, path+"/revisions/1", "")
+	diff := suite.do(suite.userID, http.MethodGet, path+"/revisions/diff?from=1&to=2&mode=word", "")
+	restored := suite.do(suite.userID, http.MethodPost, path+"/revisions/1/restore", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, list.Code, list.Body.String())
+	var revisions RevisionListResponse
+	suite.decode(list, &revisions)
+	suite.Require().Len(revisions.Revisions, 2)
+	assert.Equal(suite.T(), 2, revisions.Revisions[0].Number)
+
+	suite.Require().Equal(http.StatusOK, first.Code, first.Body.String())
+	var revision RevisionResponse
+	suite.decode(first, &revision)
+	assert.Equal(suite.T(), "First line", revision.Content)
+
+	suite.Require().Equal(http.StatusOK, diff.Code, diff.Body.String())
+	var delta RevisionDiffResponse
+	suite.decode(diff, &delta)
+	assert.Equal(suite.T(), "word", delta.Mode)
+	assert.NotEmpty(suite.T(), delta.Content)
+
+	suite.Require().Equal(http.StatusOK, restored.Code, restored.Body.String())
+	assert.Equal(suite.T(), `"3"`, restored.Header().Get("ETag"))
+	var entry EntryResponse
+	suite.decode(restored, &entry)
+	assert.Equal(suite.T(), "First line", entry.Content)
+}
+
+func (suite *HandlerTestSuite) TestRevisionsInvalidRequests() {
+	// Arrange
+	id, _ := suite.createEntry(`{"title":"Draft","content":"Text"}`)
+	path := "/api/entries/" + id
+
+	// Act & Assert
+	suite.assertError(suite.do(suite.userID, http.MethodGet, path+"/revisions/first", ""), http.StatusUnprocessableEntity, "validation_failed")
+	suite.assertError(suite.do(suite.userID, http.MethodPost, path+"/revisions/first/restore", ""), http.StatusUnprocessableEntity, "validation_failed")
+	suite.assertError(suite.do(suite.userID, http.MethodGet, path+"/revisions/diff?from=1", ""), http.StatusUnprocessableEntity, "validation_failed")
+	suite.assertError(suite.do(suite.userID, http.MethodGet, path+"/revisions/9", ""), http.StatusNotFound, "not_found")
+}
+
+// --- Теги ---
+
+func (suite *HandlerTestSuite) TestTags() {
+	// Arrange
+	suite.createEntry(`{"title":"One","content":"Text","tags":["work","ideas"]}`)
+	suite.createEntry(`{"title":"Two","content":"Text","tags":["home"]}`)
+
+	// Act
+	renamed := suite.do(suite.userID, http.MethodPut, "/api/tags/ideas", `{"name":"plans"}`)
+	merged := suite.do(suite.userID, http.MethodPost, "/api/tags/merge", `{"sources":["home","plans"],"target":"work"}`)
+	list := suite.do(suite.userID, http.MethodGet, "/api/tags/", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, renamed.Code, renamed.Body.String())
+	suite.Require().Equal(http.StatusOK, merged.Code, merged.Body.String())
+	suite.Require().Equal(http.StatusOK, list.Code)
+	var tags []TagResponse
+	suite.decode(list, &tags)
+	assert.Equal(suite.T(), []TagResponse{{Name: "work", Count: 2}}, tags)
+}
+
+func (suite *HandlerTestSuite) TestTagsInvalidRequests() {
+	// Arrange
+	suite.createEntry(`{"title":"One","content":"Text","tags":["work"]}`)
+
+	// Act & Assert
+	suite.assertError(suite.do(suite.userID, http.MethodPut, "/api/tags/work", `{"name":`), http.StatusBadRequest, "bad_request")
+	suite.assertError(suite.do(suite.userID, http.MethodPost, "/api/tags/merge", `[]`), http.StatusBadRequest, "bad_request")
+	suite.assertError(suite.do(suite.userID, http.MethodPut, "/api/tags/absent", `{"name":"other"}`), http.StatusNotFound, "not_found")
+	suite.assertError(suite.do(uuid.New(), http.MethodPut, "/api/tags/work", `{"name":"other"}`), http.StatusNotFound, "not_found")
+}
+
+// --- Статистика ---
+
+func (suite *HandlerTestSuite) TestMoodStats() {
+	// Arrange
+	suite.createEntry(`{"title":"One","content":"Text","mood":2,"entry_date":"2024-05-01"}`)
+	suite.createEntry(`{"title":"Two","content":"Text","mood":4,"entry_date":"2024-05-01"}`)
+
+	// Act
+	rec := suite.do(suite.userID, http.MethodGet, "/api/stats/mood?from=2024-05-01&to=2024-05-02&tz=UTC", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	var stats MoodStatsResponse
+	suite.decode(rec, &stats)
+	assert.Equal(suite.T(), "day", stats.Period)
+	var withEntries []MoodBucketResponse
+	for _, bucket := range stats.Buckets {
+		if bucket.Entries > 0 {
+			withEntries = append(withEntries, bucket)
+		}
+	}
+	suite.Require().Len(withEntries, 1)
+	assert.Equal(suite.T(), "2024-05-01", withEntries[0].Start)
+	assert.Equal(suite.T(), 3.0, withEntries[0].Mood.Avg)
+}
+
+func (suite *HandlerTestSuite) TestMoodStatsInvalidQuery() {
+	for _, query := range []string{"period=year", "tz=Mars/Base", "to=tomorrow", "from=yesterday"} {
+		suite.Run(query, func() {
+			// Act
+			rec := suite.do(suite.userID, http.MethodGet, "/api/stats/mood?"+query, "")
+
+			// Assert
+			suite.assertError(rec, http.StatusUnprocessableEntity, "validation_failed")
+		})
+	}
+}
+
+// --- Персональные токены ---
+
+// Создает токен из сессии и возвращает его ID и секрет
+func (suite *HandlerTestSuite) createToken(body string) (string, string) {
+	rec := suite.do(suite.userID, http.MethodPost, "/api/tokens/", body)
+	suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+	var response CreateTokenResponse
+	suite.decode(rec, &response)
+	return response.ID, response.Token
+}
+
+// Запрос с персональным токеном вместо сессии
+func (suite *HandlerTestSuite) doWithToken(secret, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+secret)
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *HandlerTestSuite) TestCreateAndListTokens() {
+	// Act
+	created := suite.do(suite.userID, http.MethodPost, "/api/tokens/", `{"name":"cron","scopes":["entries:read"],"expires_at":"2999-01-01T00:00:00Z"}`)
+	list := suite.do(suite.userID, http.MethodGet, "/api/tokens/", "")
+	foreign := suite.do(uuid.New(), http.MethodGet, "/api/tokens/", "")
+
+	// Assert - секрет показывается только при создании
+	suite.Require().Equal(http.StatusCreated, created.Code, created.Body.String())
+	var token CreateTokenResponse
+	suite.decode(created, &token)
+	assert.True(suite.T(), strings.HasPrefix(token.Token, services.TokenPrefix))
+	assert.Equal(suite.T(), []string{"entries:read"}, token.Scopes)
+	suite.Require().NotNil(token.ExpiresAt)
+	assert.Equal(suite.T(), "2999-01-01T00:00:00Z", *token.ExpiresAt)
+	assert.Nil(suite.T(), token.LastUsedAt)
+
+	suite.Require().Equal(http.StatusOK, list.Code)
+	assert.NotContains(suite.T(), list.Body.String(), token.Token)
+	var tokens TokenListResponse
+	suite.decode(list, &tokens)
+	suite.Require().Len(tokens.Tokens, 1)
+	assert.Equal(suite.T(), token.ID, tokens.Tokens[0].ID)
+	assert.Equal(suite.T(), "cron", tokens.Tokens[0].Name)
+
+	suite.Require().Equal(http.StatusOK, foreign.Code)
+	suite.decode(foreign, &tokens)
+	assert.Empty(suite.T(), tokens.Tokens)
+}
+
+func (suite *HandlerTestSuite) TestCreateTokenInvalidRequests() {
+	// Act & Assert
+	suite.assertError(suite.do(suite.userID, http.MethodPost, "/api/tokens/", `{"name":`), http.StatusBadRequest, "bad_request")
+	suite.assertError(suite.do(suite.userID, http.MethodPost, "/api/tokens/", `{"name":"cron","scopes":["entries:read"],"expires_at":"tomorrow"}`), http.StatusUnprocessableEntity, "validation_failed")
+	response := suite.assertError(suite.do(suite.userID, http.MethodPost, "/api/tokens/", `{"name":"","scopes":["admin"]}`), http.StatusUnprocessableEntity, "validation_failed")
+	suite.Require().Len(response.Error.Fields, 2)
+	assert.Equal(suite.T(), "name", response.Error.Fields[0].Field)
+	assert.Equal(suite.T(), "scopes", response.Error.Fields[1].Field)
+}
+
+func (suite *HandlerTestSuite) TestTokenScopes() {
+	// Arrange
+	id, _ := suite.createEntry(`{"title":"Diary","content":"Text"}`)
+	_, reader := suite.createToken(`{"name":"reader","scopes":["entries:read"]}`)
+	_, writer := suite.createToken(`{"name":"writer","scopes":["entries:write"]}`)
+
+	// Act
+	read := suite.doWithToken(reader, http.MethodGet, "/api/entries/"+id, "")
+	search := suite.doWithToken(reader, http.MethodGet, "/api/entries/search?q=diary", "")
+	denied := suite.doWithToken(reader, http.MethodPost, "/api/entries/", `{"title":"From script","content":"Text"}`)
+	written := suite.doWithToken(writer, http.MethodPost, "/api/entries/", `{"title":"From script","content":"Text"}`)
+	writerRead := suite.doWithToken(writer, http.MethodGet, "/api/entries/"+id, "")
+
+	// Assert - запись включает чтение, чтение не включает запись
+	suite.Require().Equal(http.StatusOK, read.Code, read.Body.String())
+	var entry EntryResponse
+	suite.decode(read, &entry)
+	assert.Equal(suite.T(), suite.userID.String(), entry.UserID)
+	assert.Equal(suite.T(), http.StatusOK, search.Code)
+	suite.assertError(denied, http.StatusForbidden, "insufficient_scope")
+	assert.Contains(suite.T(), denied.Header().Get("WWW-Authenticate"), `scope="entries:write"`)
+	assert.Equal(suite.T(), http.StatusCreated, written.Code, written.Body.String())
+	assert.Equal(suite.T(), http.StatusOK, writerRead.Code)
+
+	// Время последнего использования видно в списке токенов
+	var tokens TokenListResponse
+	suite.decode(suite.do(suite.userID, http.MethodGet, "/api/tokens/", ""), &tokens)
+	for _, token := range tokens.Tokens {
+		assert.NotNil(suite.T(), token.LastUsedAt, token.Name)
+	}
+}
+
+func (suite *HandlerTestSuite) TestReadOnlyToken() {
+	// Arrange
+	suite.createEntry(`{"title":"One","content":"Text","tags":["work"]}`)
+	_, reader := suite.createToken(`{"name":"reader","scopes":["entries:read"]}`)
+
+	// Act
+	tags := suite.doWithToken(reader, http.MethodGet, "/api/tags/", "")
+	stats := suite.doWithToken(reader, http.MethodGet, "/api/stats/mood", "")
+	trash := suite.doWithToken(reader, http.MethodGet, "/api/trash/", "")
+	rename := suite.doWithToken(reader, http.MethodPut, "/api/tags/work", `{"name":"job"}`)
+
+	// Assert - токен на чтение читает все данные записей, но ничего не меняет
+	assert.Equal(suite.T(), http.StatusOK, tags.Code, tags.Body.String())
+	assert.Equal(suite.T(), http.StatusOK, stats.Code, stats.Body.String())
+	assert.Equal(suite.T(), http.StatusOK, trash.Code, trash.Body.String())
+	suite.assertError(rename, http.StatusForbidden, "insufficient_scope")
+}
+
+func (suite *HandlerTestSuite) TestTokenCannotManageTokens() {
+	// Arrange
+	_, secret := suite.createToken(`{"name":"writer","scopes":["entries:write"]}`)
+
+	// Act
+	list := suite.doWithToken(secret, http.MethodGet, "/api/tokens/", "")
+	created := suite.doWithToken(secret, http.MethodPost, "/api/tokens/", `{"name":"copy","scopes":["entries:write"]}`)
+
+	// Assert - токены управляются только из сессии
+	suite.assertError(list, http.StatusForbidden, "insufficient_scope")
+	assert.Contains(suite.T(), list.Header().Get("WWW-Authenticate"), `scope="tokens:manage"`)
+	suite.assertError(created, http.StatusForbidden, "insufficient_scope")
+}
+
+func (suite *HandlerTestSuite) TestRevokeToken() {
+	// Arrange
+	id, secret := suite.createToken(`{"name":"cron","scopes":["entries:read"]}`)
+	suite.Require().Equal(http.StatusOK, suite.doWithToken(secret, http.MethodGet, "/api/entries/", "").Code)
+
+	// Act
+	foreign := suite.do(uuid.New(), http.MethodDelete, "/api/tokens/"+id, "")
+	revoked := suite.do(suite.userID, http.MethodDelete, "/api/tokens/"+id, "")
+
+	// Assert - отозванный токен сразу перестает действовать
+	suite.assertError(foreign, http.StatusNotFound, "not_found")
+	suite.Require().Equal(http.StatusOK, revoked.Code, revoked.Body.String())
+	rejected := suite.doWithToken(secret, http.MethodGet, "/api/entries/", "")
+	suite.assertError(rejected, http.StatusUnauthorized, "unauthorized")
+	assert.Contains(suite.T(), rejected.Header().Get("WWW-Authenticate"), "invalid_token")
+	suite.assertError(suite.do(suite.userID, http.MethodDelete, "/api/tokens/"+id, ""), http.StatusNotFound, "not_found")
+}
+
+func (suite *HandlerTestSuite) TestUnknownToken() {
+	// Act
+	rec := suite.doWithToken(services.TokenPrefix+"forged", http.MethodGet, "/api/entries/", "")
+
+	// Assert
+	suite.assertError(rec, http.StatusUnauthorized, "unauthorized")
+}
+
+// --- Администрирование ---
+
+func (suite *HandlerTestSuite) TestAdminListsUsersAndUsage() {
+	// Arrange
+	suite.createEntry(`{"title":"One","content":"Text"}`)
+	id, etag := suite.createEntry(`{"title":"Two","content":"Text"}`)
+	deleted := suite.do(suite.userID, http.MethodDelete, "/api/entries/"+id, "", "If-Match", etag)
+	suite.Require().Equal(http.StatusOK, deleted.Code, deleted.Body.String())
+	suite.createToken(`{"name":"cron","scopes":["entries:read"]}`)
+
+	// Act
+	users := suite.do(suite.adminID, http.MethodGet, "/api/admin/users", "")
+	usage := suite.do(suite.adminID, http.MethodGet, "/api/admin/usage", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, users.Code, users.Body.String())
+	var list UserListResponse
+	suite.decode(users, &list)
+	suite.Require().Len(list.Users, 1)
+	assert.Equal(suite.T(), suite.userID.String(), list.Users[0].UserID)
+	assert.Equal(suite.T(), int64(1), list.Users[0].Entries)
+	assert.Equal(suite.T(), int64(1), list.Users[0].Trashed)
+	assert.Equal(suite.T(), int64(1), list.Users[0].Tokens)
+	assert.NotNil(suite.T(), list.Users[0].LastEntryAt)
+
+	suite.Require().Equal(http.StatusOK, usage.Code, usage.Body.String())
+	var totals UsageResponse
+	suite.decode(usage, &totals)
+	assert.Equal(suite.T(), UsageResponse{Users: 1, Entries: 1, Trashed: 1, Tokens: 1}, totals)
+}
+
+func (suite *HandlerTestSuite) TestAdminRoutesRequireAdmin() {
+	// Arrange
+	rec := suite.do(suite.adminID, http.MethodPost, "/api/tokens/", `{"name":"admin","scopes":["entries:write"]}`)
+	suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+	var token CreateTokenResponse
+	suite.decode(rec, &token)
+
+	// Act
+	user := suite.do(suite.userID, http.MethodGet, "/api/admin/users", "")
+	adminToken := suite.doWithToken(token.Token, http.MethodGet, "/api/admin/usage", "")
+	adminEntries := suite.do(suite.adminID, http.MethodGet, "/api/entries/", "")
+
+	// Assert - токен администратора не дает прав администратора, а сам
+	// администратор видит только свои записи
+	suite.assertError(user, http.StatusForbidden, "forbidden")
+	suite.assertError(adminToken, http.StatusForbidden, "insufficient_scope")
+	suite.Require().Equal(http.StatusOK, adminEntries.Code)
+	assert.NotContains(suite.T(), adminEntries.Body.String(), suite.userID.String())
+}
+
+// --- Шифрование на клиенте ---
+
+// Эталонный клиент поверх тестового сервера от имени userID
+func (suite *HandlerTestSuite) e2eeClient(userID uuid.UUID) *e2ee.Client {
+	server := httptest.NewServer(suite.router)
+	suite.T().Cleanup(server.Close)
+	return &e2ee.Client{
+		BaseURL: server.URL,
+		HTTP:    server.Client(),
+		Authorize: func(r *http.Request) {
+			r.Header.Set(testUserHeader, userID.String())
+		},
+	}
+}
+
+// Ключ, сохраненный на сервере
+func (suite *HandlerTestSuite) putKey(client *e2ee.Client, keyID, passphrase string) *e2ee.Key {
+	key, err := e2ee.NewKey(keyID)
+	suite.Require().NoError(err)
+	wrapped, err := key.Wrap(passphrase, services.MinKDFIterations)
+	suite.Require().NoError(err)
+	suite.Require().NoError(client.PutKey(context.Background(), wrapped))
+	return key
+}
+
+func (suite *HandlerTestSuite) TestEncryptedEntryRoundTrip() {
+	// Arrange - ключ создан на одном устройстве
+	ctx := context.Background()
+	client := suite.e2eeClient(suite.userID)
+	suite.putKey(client, "main", "correct horse")
+
+	// Act - другое устройство получает обертку и разворачивает ее паролем
+	wrapped, err := client.GetKey(ctx, "main")
+	suite.Require().NoError(err)
+	key, err := e2ee.Unwrap(wrapped, "correct horse")
+	suite.Require().NoError(err)
+	id, err := client.CreateEntry(ctx, key, e2ee.Entry{Title: "Секрет", Content: "Никому не говорить", Tags: []string{"private"}, EntryDate: "2024-05-01"})
+	suite.Require().NoError(err)
+	found, err := client.GetEntry(ctx, key, id)
+	suite.Require().NoError(err)
+
+	// Assert - клиент читает текст, сервер хранит и отдает только шифротекст
+	assert.Equal(suite.T(), "Секрет", found.Title)
+	assert.Equal(suite.T(), "Никому не говорить", found.Content)
+	assert.Equal(suite.T(), []string{"private"}, found.Tags)
+
+	rec := suite.do(suite.userID, http.MethodGet, "/api/entries/"+id, "")
+	suite.Require().Equal(http.StatusOK, rec.Code)
+	assert.NotContains(suite.T(), rec.Body.String(), "Никому")
+	var entry EntryResponse
+	suite.decode(rec, &entry)
+	assert.Empty(suite.T(), entry.Title)
+	assert.Empty(suite.T(), entry.Content)
+	suite.Require().NotNil(entry.Encrypted)
+	assert.Equal(suite.T(), "main", entry.Encrypted.KeyID)
+
+	search := suite.do(suite.userID, http.MethodGet, "/api/entries/search?q=Никому", "")
+	suite.Require().Equal(http.StatusOK, search.Code, search.Body.String())
+	assert.NotContains(suite.T(), search.Body.String(), id)
+}
+
+func (suite *HandlerTestSuite) TestEncryptedEntryRequiresStoredKey() {
+	// Arrange
+	client := suite.e2eeClient(suite.userID)
+	key, err := e2ee.NewKey("unsaved")
+	suite.Require().NoError(err)
+
+	// Act
+	_, err = client.CreateEntry(context.Background(), key, e2ee.Entry{Title: "Lost"})
+
+	// Assert - запись без сохраненного ключа никто не смог бы прочитать
+	var apiErr *e2ee.APIError
+	suite.Require().ErrorAs(err, &apiErr)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, apiErr.Status)
+	assert.Equal(suite.T(), "validation_failed", apiErr.Code)
+}
+
+func (suite *HandlerTestSuite) TestEncryptionModeCannotChange() {
+	// Arrange
+	client := suite.e2eeClient(suite.userID)
+	key := suite.putKey(client, "main", "passphrase")
+	encryptedID, err := client.CreateEntry(context.Background(), key, e2ee.Entry{Title: "Secret"})
+	suite.Require().NoError(err)
+	plainID, plainETag := suite.createEntry(`{"title":"Plain","content":"Text"}`)
+	sealed, err := json.Marshal(map[string]any{"title": "", "content": "", "encrypted": map[string]any{
+		"algorithm": "A256GCM", "key_id": "main", "nonce": make([]byte, 12), "ciphertext": []byte("ciphertext"),
+	}})
+	suite.Require().NoError(err)
+
+	// Act
+	encrypt := suite.do(suite.userID, http.MethodPut, "/api/entries/"+plainID, string(sealed), "If-Match", plainETag)
+	decrypt := suite.do(suite.userID, http.MethodPatch, "/api/entries/"+encryptedID, `{"encrypted":null,"title":"Plain"}`,
+		"Content-Type", mediaTypeMergePatch, "If-Match", `"1"`)
+	resealed := suite.do(suite.userID, http.MethodPatch, "/api/entries/"+encryptedID, `{"encrypted":{"ciphertext":"AAECAwQFBgcICQoLDA0ODxAREhM="}}`,
+		"Content-Type", mediaTypeMergePatch, "If-Match", `"1"`)
+
+	// Assert - открытый текст остался бы в истории ревизий
+	response := suite.assertError(encrypt, http.StatusUnprocessableEntity, "validation_failed")
+	suite.Require().Len(response.Error.Fields, 1)
+	assert.Equal(suite.T(), "encrypted", response.Error.Fields[0].Field)
+	suite.assertError(decrypt, http.StatusUnprocessableEntity, "validation_failed")
+
+	// Шифротекст зашифрованной записи меняется патчем
+	suite.Require().Equal(http.StatusOK, resealed.Code, resealed.Body.String())
+	var entry EntryResponse
+	suite.decode(resealed, &entry)
+	suite.Require().NotNil(entry.Encrypted)
+	assert.Len(suite.T(), entry.Encrypted.Ciphertext, 20)
+	assert.Equal(suite.T(), 2, entry.Version)
+}
+
+func (suite *HandlerTestSuite) TestKeys() {
+	// Arrange
+	client := suite.e2eeClient(suite.userID)
+	suite.putKey(client, "main", "first passphrase")
+	first := suite.do(suite.userID, http.MethodGet, "/api/keys/main", "")
+	suite.Require().Equal(http.StatusOK, first.Code)
+	var before KeyResponse
+	suite.decode(first, &before)
+
+	// Act - смена пароля: тот же ключ обернут заново
+	key, err := e2ee.Unwrap(&e2ee.WrappedKey{
+		KeyID: before.KeyID, Algorithm: before.Algorithm, Nonce: before.Nonce, WrappedKey: before.WrappedKey,
+		KDF: before.KDF, Salt: before.Salt, Iterations: before.Iterations,
+	}, "first passphrase")
+	suite.Require().NoError(err)
+	rewrapped, err := key.Wrap("second passphrase", services.MinKDFIterations)
+	suite.Require().NoError(err)
+	suite.Require().NoError(client.PutKey(context.Background(), rewrapped))
+	list := suite.do(suite.userID, http.MethodGet, "/api/keys/", "")
+	foreign := suite.do(uuid.New(), http.MethodGet, "/api/keys/main", "")
+
+	// Assert
+	suite.Require().Equal(http.StatusOK, list.Code)
+	var response KeyListResponse
+	suite.decode(list, &response)
+	suite.Require().Len(response.Keys, 1)
+	assert.Equal(suite.T(), rewrapped.WrappedKey, response.Keys[0].WrappedKey)
+	assert.Equal(suite.T(), before.CreatedAt, response.Keys[0].CreatedAt)
+	suite.assertError(foreign, http.StatusNotFound, "not_found")
+
+	stored, err := client.GetKey(context.Background(), "main")
+	suite.Require().NoError(err)
+	_, err = e2ee.Unwrap(stored, "first passphrase")
+	assert.ErrorIs(suite.T(), err, e2ee.ErrWrongPassphrase)
+	_, err = e2ee.Unwrap(stored, "second passphrase")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *HandlerTestSuite) TestKeysInvalidRequests() {
+	// Act
+	badPayload := suite.do(suite.userID, http.MethodPut, "/api/keys/main", `{"nonce":"not base64!"}`)
+	invalid := suite.do(suite.userID, http.MethodPut, "/api/keys/main", `{"algorithm":"A256GCM","kdf":"PBKDF2-SHA256","iterations":1}`)
+	badID := suite.do(suite.userID, http.MethodPut, "/api/keys/bad%20id", `{}`)
+
+	// Assert
+	suite.assertError(badPayload, http.StatusBadRequest, "bad_request")
+	response := suite.assertError(invalid, http.StatusUnprocessableEntity, "validation_failed")
+	var fields []string
+	for _, f := range response.Error.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(suite.T(), []string{"nonce", "wrapped_key", "salt", "iterations"}, fields)
+	badIDResponse := suite.assertError(badID, http.StatusUnprocessableEntity, "validation_failed")
+	assert.Equal(suite.T(), "key_id", badIDResponse.Error.Fields[0].Field)
+}
+
+func TestHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(HandlerTestSuite))
+}
+
+// --- Ошибки сервиса ---
+
+func TestServiceErrorMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"internal", errors.New("database is locked"), http.StatusInternalServerError, "internal"},
+		{"timeout", context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout"},
+		{"not found", repos.ErrEntryNotFound, http.StatusNotFound, "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newTestRouter(&failingService{err: tt.err}, NewStaticRoles(nil))
+			userID := uuid.NewString()
+
+			for _, path := range []string{"/api/tags/", "/api/entries/" + uuid.NewString()} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set(testUserHeader, userID)
+
+				// Act
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				// Assert - подробности внутренних ошибок клиенту не отдаются
+				assert.Equal(t, tt.status, rec.Code, path)
+				var response ErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, tt.code, response.Error.Code)
+				assert.NotContains(t, rec.Body.String(), "database is locked")
+			}
+		})
+	}
+}
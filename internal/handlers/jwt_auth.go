@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// --- JWT ---
+
+// Проверка токенов, выпущенных своим сервером авторизации
+type JWTOptions struct {
+	// Ожидаемые iss и aud; пустое значение не проверяется
+	Issuer   string
+	Audience string
+}
+
+// Bearer-токен из заголовка Authorization. Пользователь - claim sub
+// (UUID); токен без exp не принимается.
+type jwtAuthenticator struct {
+	keyfunc jwt.Keyfunc
+	parser  *jwt.Parser
+}
+
+// Токены HS256 с общим секретом
+func NewHS256Authenticator(secret []byte, opts JWTOptions) Authenticator {
+	return newJWTAuthenticator(func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.SigningMethodHS256.Alg(), opts)
+}
+
+// Токены RS256, открытые ключи берутся из JWKS (RFC 7517). Ключ выбирается
+// по kid из заголовка токена.
+func NewJWKSAuthenticator(jwksJSON []byte, opts JWTOptions) (Authenticator, error) {
+	jwks, err := keyfunc.NewJSON(json.RawMessage(jwksJSON))
+	if err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+	if jwks.Len() == 0 {
+		return nil, errors.New("parse JWKS: no keys")
+	}
+	return newJWTAuthenticator(jwks.Keyfunc, jwt.SigningMethodRS256.Alg(), opts), nil
+}
+
+func newJWTAuthenticator(keyfunc jwt.Keyfunc, alg string, opts JWTOptions) *jwtAuthenticator {
+	// Алгоритм фиксирован, иначе токен мог бы подменить его (alg=none,
+	// HS256 с открытым ключом RSA в качестве секрета)
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{alg})}
+	if opts.Issuer != "" {
+		options = append(options, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		options = append(options, jwt.WithAudience(opts.Audience))
+	}
+	return &jwtAuthenticator{keyfunc: keyfunc, parser: jwt.NewParser(options...)}
+}
+
+func (a *jwtAuthenticator) Verify(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subject, err := a.subject(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, r, errUnauthorized)
+			return
+		}
+		userID, err := uuid.Parse(subject)
+		if err != nil {
+			writeError(w, r, errInvalidUserID)
+			return
+		}
+		next(w, r.WithContext(WithUserID(r.Context(), userID)))
+	}
+}
+
+// Проверяет подпись и сроки токена и возвращает его sub
+func (a *jwtAuthenticator) subject(r *http.Request) (string, error) {
+	raw, ok := bearerToken(r)
+	if !ok {
+		return "", errors.New("missing bearer token")
+	}
+	var claims jwt.RegisteredClaims
+	if _, err := a.parser.ParseWithClaims(raw, &claims, a.keyfunc); err != nil {
+		return "", err
+	}
+	if claims.ExpiresAt == nil {
+		return "", errors.New("token has no expiration")
+	}
+	return claims.Subject, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"diary/internal/models"
+	"diary/internal/services"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// --- Key Handler Interface ---
+
+// Обернутые ключи шифрования записей (E2EE). Клиент сохраняет обертку
+// один раз и получает ее на каждом устройстве; развернуть ключ может
+// только он, зная пароль.
+type KeyHandler interface {
+	ListKeys(w http.ResponseWriter, r *http.Request)
+	GetKey(w http.ResponseWriter, r *http.Request)
+	PutKey(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Key Handler Implementation ---
+
+type keyHandler struct {
+	service services.EntryKeyService
+}
+
+func NewKeyHandler(service services.EntryKeyService) KeyHandler {
+	return &keyHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+// Бинарные поля передаются в base64
+type KeyRequest struct {
+	// Алгоритм обертки (A256GCM), ее nonce и обернутый ключ записей
+	Algorithm  string `json:"algorithm"`
+	Nonce      []byte `json:"nonce"`
+	WrappedKey []byte `json:"wrapped_key"`
+	// Вывод ключа обертки из пароля (PBKDF2-SHA256)
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+}
+
+type KeyResponse struct {
+	KeyID string `json:"key_id"`
+	KeyRequest
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type KeyListResponse struct {
+	Keys []KeyResponse `json:"keys"`
+}
+
+// --- Key Handlers ---
+
+func (h *keyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	keys, err := h.service.ListEntryKeys(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := KeyListResponse{Keys: make([]KeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, newKeyResponse(key))
+	}
+	render.JSON(w, r, response)
+}
+
+func (h *keyHandler) GetKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Чужой ключ не найдется
+	key, err := h.service.GetEntryKey(r.Context(), userID, keyID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	render.JSON(w, r, newKeyResponse(key))
+}
+
+// PutKey создает ключ или заменяет его обертку. Удаления нет: без ключа
+// зашифрованные им записи не прочитать.
+func (h *keyHandler) PutKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req KeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidPayload)
+		return
+	}
+
+	key := &models.EntryKey{
+		KeyID:      keyID,
+		Algorithm:  req.Algorithm,
+		Nonce:      req.Nonce,
+		WrappedKey: req.WrappedKey,
+		KDF:        req.KDF,
+		Salt:       req.Salt,
+		Iterations: req.Iterations,
+	}
+	if err := h.service.PutEntryKey(r.Context(), userID, key); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Время создания замененного ключа знает только хранилище
+	stored, err := h.service.GetEntryKey(r.Context(), userID, keyID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	render.JSON(w, r, newKeyResponse(stored))
+}
+
+func newKeyResponse(key *models.EntryKey) KeyResponse {
+	return KeyResponse{
+		KeyID: key.KeyID,
+		KeyRequest: KeyRequest{
+			Algorithm:  key.Algorithm,
+			Nonce:      key.Nonce,
+			WrappedKey: key.WrappedKey,
+			KDF:        key.KDF,
+			Salt:       key.Salt,
+			Iterations: key.Iterations,
+		},
+		CreatedAt: key.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: key.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// --- JSON Merge Patch (RFC 7396) и JSON Patch (RFC 6902) ---
+
+const (
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Накладывает merge patch на документ: null удаляет ключ, объекты
+// сливаются рекурсивно, остальные значения заменяются целиком
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+var (
+	errInvalidJSONPatch = &statusError{http.StatusBadRequest, "bad_request", "invalid JSON Patch document"}
+	errJSONPatchTest    = errs.Conflict("JSON Patch test operation failed")
+)
+
+// Применяет операции по порядку; при ошибке документ целиком отбрасывается
+func applyJSONPatch(doc any, ops []jsonPatchOp) (any, error) {
+	for _, op := range ops {
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			// Отсутствующее value отличается от null: RawMessage получит "null"
+			if len(op.Value) == 0 {
+				return nil, errInvalidJSONPatch
+			}
+			var value any
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, errInvalidJSONPatch
+			}
+			switch op.Op {
+			case "add":
+				doc, err = pointerAdd(doc, path, value)
+			case "replace":
+				if doc, _, err = pointerRemove(doc, path); err == nil {
+					doc, err = pointerAdd(doc, path, value)
+				}
+			case "test":
+				var current any
+				if current, err = pointerGet(doc, path); err == nil && !reflect.DeepEqual(current, value) {
+					err = errJSONPatchTest
+				}
+			}
+
+		case "remove":
+			doc, _, err = pointerRemove(doc, path)
+
+		case "move", "copy":
+			from, ferr := parsePointer(op.From)
+			if ferr != nil {
+				return nil, ferr
+			}
+			var value any
+			if op.Op == "move" {
+				doc, value, err = pointerRemove(doc, from)
+			} else if value, err = pointerGet(doc, from); err == nil {
+				value = deepCopy(value)
+			}
+			if err == nil {
+				doc, err = pointerAdd(doc, path, value)
+			}
+
+		default:
+			return nil, errInvalidJSONPatch
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// --- JSON Pointer (RFC 6901) ---
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errInvalidJSONPatch
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func pathNotFound(path []string) error {
+	return errs.Field("/"+strings.Join(path, "/"), "path does not exist")
+}
+
+// Индекс элемента массива; "-" (после последнего) допустим только для add
+func arrayIndex(token string, length int, appendAllowed bool) (int, bool) {
+	if token == "-" && appendAllowed {
+		return length, true
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, false
+	}
+	limit := length - 1
+	if appendAllowed {
+		limit = length
+	}
+	return i, i <= limit
+}
+
+func pointerGet(doc any, path []string) (any, error) {
+	current := doc
+	for i, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, pathNotFound(path[:i+1])
+			}
+			current = value
+		case []any:
+			idx, ok := arrayIndex(token, len(node), false)
+			if !ok {
+				return nil, pathNotFound(path[:i+1])
+			}
+			current = node[idx]
+		default:
+			return nil, pathNotFound(path[:i+1])
+		}
+	}
+	return current, nil
+}
+
+// Вставляет значение и возвращает новый корень документа
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		idx, ok := arrayIndex(last, len(node), true)
+		if !ok {
+			return nil, pathNotFound(path)
+		}
+		updated := append(node[:idx:idx], append([]any{value}, node[idx:]...)...)
+		return pointerSet(doc, path[:len(path)-1], updated)
+	default:
+		return nil, pathNotFound(path)
+	}
+}
+
+// Удаляет значение и возвращает новый корень документа и удаленное значение
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			return nil, nil, pathNotFound(path)
+		}
+		delete(node, last)
+		return doc, value, nil
+	case []any:
+		idx, ok := arrayIndex(last, len(node), false)
+		if !ok {
+			return nil, nil, pathNotFound(path)
+		}
+		value := node[idx]
+		updated := append(node[:idx:idx], node[idx+1:]...)
+		doc, err = pointerSet(doc, path[:len(path)-1], updated)
+		return doc, value, err
+	default:
+		return nil, nil, pathNotFound(path)
+	}
+}
+
+// Заменяет значение по существующему пути (нужно для массивов,
+// которые при вставке и удалении пересоздаются)
+func pointerSet(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+	case []any:
+		idx, _ := arrayIndex(last, len(node), false)
+		node[idx] = value
+	}
+	return doc, nil
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// --- Документ после изменения ---
+
+// Разбирает изменённый документ в структуру запроса. Неизвестные поля
+// и значения неверного типа возвращаются как ошибки валидации полей.
+func decodePatchedDocument(doc any, dst any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return errs.Field(typeErr.Field, fmt.Sprintf("must be %s", typeErr.Type))
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return errs.Field(strings.Trim(field, `"`), "unknown field")
+		}
+		return errs.Validation("patched document is not a valid entry")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSON(t *testing.T, raw string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(raw), &v))
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	// Примеры из приложения A RFC 7396
+	tests := []struct {
+		target, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			result := mergePatch(decodeJSON(t, tt.target), decodeJSON(t, tt.patch))
+			assert.Equal(t, decodeJSON(t, tt.expected), result)
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, expected string
+	}{
+		{"add field", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add to array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"append to array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"remove field", `{"a":1,"b":2}`, `[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{"remove from array", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`},
+		{"replace", `{"a":1}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`},
+		{"move", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"copy", `{"a":[1]}`, `[{"op":"copy","from":"/a","path":"/b"},{"op":"add","path":"/b/-","value":2}]`, `{"a":[1],"b":[1,2]}`},
+		{"test then replace", `{"a":"x"}`, `[{"op":"test","path":"/a","value":"x"},{"op":"replace","path":"/a","value":"y"}]`, `{"a":"y"}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []jsonPatchOp
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &ops))
+
+			result, err := applyJSONPatch(decodeJSON(t, tt.doc), ops)
+
+			require.NoError(t, err)
+			assert.Equal(t, decodeJSON(t, tt.expected), result)
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name, patch string
+		kind        error
+	}{
+		{"unknown op", `[{"op":"merge","path":"/a"}]`, errInvalidJSONPatch},
+		{"missing value", `[{"op":"add","path":"/a"}]`, errInvalidJSONPatch},
+		{"relative pointer", `[{"op":"remove","path":"a"}]`, errInvalidJSONPatch},
+		{"missing path", `[{"op":"remove","path":"/missing"}]`, errs.ErrValidation},
+		{"replace missing", `[{"op":"replace","path":"/missing","value":1}]`, errs.ErrValidation},
+		{"index out of range", `[{"op":"add","path":"/tags/5","value":"x"}]`, errs.ErrValidation},
+		{"failed test", `[{"op":"test","path":"/a","value":2}]`, errs.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []jsonPatchOp
+			require.NoError(t, json.Unmarshal([]byte(tt.patch), &ops))
+
+			_, err := applyJSONPatch(decodeJSON(t, `{"a":1,"tags":["x"]}`), ops)
+
+			assert.ErrorIs(t, err, tt.kind)
+		})
+	}
+}
+
+func TestDecodePatchedDocument(t *testing.T) {
+	var req EntryRequest
+	require.NoError(t, decodePatchedDocument(decodeJSON(t, `{"title":"T","mood":3,"tags":["a"]}`), &req))
+	assert.Equal(t, "T", req.Title)
+	assert.Equal(t, 3, *req.Mood)
+	assert.Equal(t, []string{"a"}, req.Tags)
+
+	err := decodePatchedDocument(decodeJSON(t, `{"title":"T","colour":"red"}`), &EntryRequest{})
+	assert.ErrorIs(t, err, errs.ErrValidation)
+	_, fields := errs.Details(err)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "colour", fields[0].Field)
+
+	err = decodePatchedDocument(decodeJSON(t, `{"mood":"happy"}`), &EntryRequest{})
+	assert.ErrorIs(t, err, errs.ErrValidation)
+	_, fields = errs.Details(err)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "mood", fields[0].Field)
+}
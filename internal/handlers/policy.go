@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"diary/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// --- Роли ---
+
+// Определяет роль пользователя, выполняющего запрос
+type Roles interface {
+	Role(ctx context.Context, userID uuid.UUID) models.Role
+}
+
+// Роли из конфигурации: перечисленные пользователи - администраторы,
+// остальные - обычные пользователи
+type staticRoles struct {
+	admins map[uuid.UUID]bool
+}
+
+func NewStaticRoles(admins []uuid.UUID) Roles {
+	r := &staticRoles{admins: make(map[uuid.UUID]bool, len(admins))}
+	for _, id := range admins {
+		r.admins[id] = true
+	}
+	return r
+}
+
+func (r *staticRoles) Role(ctx context.Context, userID uuid.UUID) models.Role {
+	if r.admins[userID] {
+		return models.RoleAdmin
+	}
+	return models.RoleUser
+}
+
+// --- Политики маршрутов ---
+
+// Политика доступа к маршруту: право, которое должно быть и у роли
+// пользователя, и у токена запроса, если запрос пришел с токеном.
+// Нулевая политика запрещает все запросы, поэтому маршрут без явной
+// политики недоступен.
+type Policy struct {
+	scope models.TokenScope
+}
+
+func RequireScope(scope models.TokenScope) Policy {
+	return Policy{scope: scope}
+}
+
+// Политики маршрутов RegisterRoutes. Корзина, теги и статистика - те же
+// записи, поэтому у них права на записи.
+var (
+	readEntries  = RequireScope(models.ScopeEntriesRead)
+	writeEntries = RequireScope(models.ScopeEntriesWrite)
+	manageTokens = RequireScope(models.ScopeTokensManage)
+	readAdmin    = RequireScope(models.ScopeAdminRead)
+)
+
+func (p Policy) check(role models.Role, principal Principal) error {
+	if p.scope == "" || !role.HasScope(p.scope) {
+		return errNotPermitted
+	}
+	if principal.Token != nil && !principal.Token.HasScope(p.scope) {
+		return errInsufficientScope
+	}
+	return nil
+}
+
+// Аутентифицирует запрос и пропускает его к обработчику, только если это
+// разрешает политика маршрута
+func (h *handler) allow(policy Policy, next http.HandlerFunc) http.HandlerFunc {
+	return h.auth.Verify(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			writeError(w, r, errUnauthorized)
+			return
+		}
+
+		err := policy.check(h.roles.Role(r.Context(), principal.UserID), principal)
+		if errors.Is(err, errInsufficientScope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, policy.scope))
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// --- Revision Handler Interface ---
+
+type RevisionHandler interface {
+	ListRevisions(w http.ResponseWriter, r *http.Request)
+	GetRevision(w http.ResponseWriter, r *http.Request)
+	DiffRevisions(w http.ResponseWriter, r *http.Request)
+	RestoreRevision(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Revision Handler Implementation ---
+
+type revisionHandler struct {
+	revisions services.RevisionService
+}
+
+func NewRevisionHandler(revisions services.RevisionService) RevisionHandler {
+	return &revisionHandler{revisions: revisions}
+}
+
+// --- Request/Response Structs ---
+
+type RevisionSummaryResponse struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	CreatedAt string `json:"created_at"`
+}
+
+type RevisionResponse struct {
+	RevisionSummaryResponse
+	Content string `json:"content"`
+}
+
+type RevisionListResponse struct {
+	Revisions []RevisionSummaryResponse `json:"revisions"`
+}
+
+type DiffOpResponse struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type RevisionDiffResponse struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Mode    string           `json:"mode"`
+	Title   []DiffOpResponse `json:"title"`
+	Content []DiffOpResponse `json:"content"`
+}
+
+var errInvalidRevision = errs.Field("rev", "invalid revision number")
+
+// --- Revision Handlers ---
+
+func (h *revisionHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := revisionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.revisions.ListRevisions(r.Context(), userID, entryID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := RevisionListResponse{Revisions: make([]RevisionSummaryResponse, 0, len(revisions))}
+	for _, revision := range revisions {
+		response.Revisions = append(response.Revisions, newRevisionSummaryResponse(revision))
+	}
+	render.JSON(w, r, response)
+}
+
+func (h *revisionHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := revisionTarget(w, r)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		writeError(w, r, errInvalidRevision)
+		return
+	}
+
+	revision, err := h.revisions.GetRevision(r.Context(), userID, entryID, number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, RevisionResponse{
+		RevisionSummaryResponse: newRevisionSummaryResponse(revision),
+		Content:                 revision.Content,
+	})
+}
+
+// Параметры: from и to - номера ревизий, mode - line (по умолчанию) или word
+func (h *revisionHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := revisionTarget(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	from, errFrom := strconv.Atoi(q.Get("from"))
+	to, errTo := strconv.Atoi(q.Get("to"))
+	if errFrom != nil || errTo != nil {
+		writeError(w, r, errs.Validation("from and to must be revision numbers"))
+		return
+	}
+
+	diff, err := h.revisions.DiffRevisions(r.Context(), userID, entryID, from, to, models.DiffMode(q.Get("mode")))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, RevisionDiffResponse{
+		From:    diff.From,
+		To:      diff.To,
+		Mode:    string(diff.Mode),
+		Title:   newDiffOpResponses(diff.Title),
+		Content: newDiffOpResponses(diff.Content),
+	})
+}
+
+func (h *revisionHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := revisionTarget(w, r)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		writeError(w, r, errInvalidRevision)
+		return
+	}
+
+	entry, err := h.revisions.RestoreRevision(r.Context(), userID, entryID, number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", entryETag(entry))
+	render.JSON(w, r, newEntryResponse(entry))
+}
+
+// Пользователь сессии и запись из URL. Доступ к записи проверяет сервис.
+// При ошибке ответ уже записан.
+func revisionTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return uuid.Nil, "", false
+	}
+	return userID, chi.URLParam(r, "id"), true
+}
+
+func newRevisionSummaryResponse(revision *models.EntryRevision) RevisionSummaryResponse {
+	return RevisionSummaryResponse{
+		Number:    revision.Number,
+		Title:     revision.Title,
+		CreatedAt: revision.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func newDiffOpResponses(ops []models.DiffOp) []DiffOpResponse {
+	response := make([]DiffOpResponse, 0, len(ops))
+	for _, op := range ops {
+		response = append(response, DiffOpResponse{Type: string(op.Type), Text: op.Text})
+	}
+	return response
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"diary/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// --- Search Handler Interface ---
+
+type SearchHandler interface {
+	SearchEntries(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Search Handler Implementation ---
+
+type searchHandler struct {
+	service services.SearchService
+}
+
+func NewSearchHandler(service services.SearchService) SearchHandler {
+	return &searchHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type SearchResultResponse struct {
+	Entry          EntryResponse `json:"entry"`
+	Rank           float64       `json:"rank"`
+	TitleSnippet   string        `json:"title_snippet"`
+	ContentSnippet string        `json:"content_snippet"`
+}
+
+type SearchResponse struct {
+	Query   string                 `json:"query"`
+	Results []SearchResultResponse `json:"results"`
+}
+
+// --- Search Handlers ---
+
+func (h *searchHandler) SearchEntries(w http.ResponseWriter, r *http.Request) {
+	// Поиск выполняется только по записям пользователя из сессии
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, r, errs.Field("q", "missing search query"))
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			writeError(w, r, errs.Field("limit", "invalid limit"))
+			return
+		}
+	}
+
+	results, err := h.service.SearchEntries(r.Context(), userID, query, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Формируем ответ
+	response := SearchResponse{
+		Query:   query,
+		Results: make([]SearchResultResponse, 0, len(results)),
+	}
+	for _, result := range results {
+		response.Results = append(response.Results, SearchResultResponse{
+			Entry:          newEntryResponse(result.Entry),
+			Rank:           result.Rank,
+			TitleSnippet:   result.TitleSnippet,
+			ContentSnippet: result.ContentSnippet,
+		})
+	}
+
+	render.JSON(w, r, response)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// --- Stats Handler Interface ---
+
+type StatsHandler interface {
+	MoodStats(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Stats Handler Implementation ---
+
+type statsHandler struct {
+	service services.StatsService
+}
+
+func NewStatsHandler(service services.StatsService) StatsHandler {
+	return &statsHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type ScaleAggregateResponse struct {
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	Min   int     `json:"min"`
+	Max   int     `json:"max"`
+}
+
+type MoodBucketResponse struct {
+	Start   string                  `json:"start"`
+	Entries int                     `json:"entries"`
+	Mood    *ScaleAggregateResponse `json:"mood"`
+	Energy  *ScaleAggregateResponse `json:"energy"`
+}
+
+type MoodStatsResponse struct {
+	Period   string               `json:"period"`
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	TimeZone string               `json:"timezone"`
+	Buckets  []MoodBucketResponse `json:"buckets"`
+}
+
+// Период по умолчанию, если from не указан
+const defaultStatsRange = 30 * 24 * time.Hour
+
+// --- Stats Handlers ---
+
+// GET /api/stats/mood?from=&to=&period=day|week|month&tz=Europe/Moscow
+func (h *statsHandler) MoodStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	q := r.URL.Query()
+
+	period := models.PeriodDay
+	if raw := q.Get("period"); raw != "" {
+		period = models.StatsPeriod(raw)
+		if !period.Valid() {
+			writeError(w, r, errs.Field("period", "invalid period, expected day, week or month"))
+			return
+		}
+	}
+
+	loc := time.UTC
+	if raw := q.Get("tz"); raw != "" {
+		loc, err = time.LoadLocation(raw)
+		if err != nil {
+			writeError(w, r, errs.Field("tz", "invalid timezone"))
+			return
+		}
+	}
+
+	to := time.Now()
+	if raw := q.Get("to"); raw != "" {
+		parsed, dateOnly, err := parseDateParam(raw, loc)
+		if err != nil {
+			writeError(w, r, errs.Field("to", "invalid to date"))
+			return
+		}
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultStatsRange)
+	if raw := q.Get("from"); raw != "" {
+		from, _, err = parseDateParam(raw, loc)
+		if err != nil {
+			writeError(w, r, errs.Field("from", "invalid from date"))
+			return
+		}
+	}
+
+	buckets, err := h.service.MoodTrend(r.Context(), userID, from, to, period, loc)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Формируем ответ
+	response := MoodStatsResponse{
+		Period:   string(period),
+		From:     from.In(loc).Format(time.RFC3339),
+		To:       to.In(loc).Format(time.RFC3339),
+		TimeZone: loc.String(),
+		Buckets:  make([]MoodBucketResponse, 0, len(buckets)),
+	}
+	for _, bucket := range buckets {
+		response.Buckets = append(response.Buckets, MoodBucketResponse{
+			Start:   bucket.Start.Format(time.DateOnly),
+			Entries: bucket.Entries,
+			Mood:    newScaleAggregateResponse(bucket.Mood),
+			Energy:  newScaleAggregateResponse(bucket.Energy),
+		})
+	}
+
+	render.JSON(w, r, response)
+}
+
+func newScaleAggregateResponse(a *models.ScaleAggregate) *ScaleAggregateResponse {
+	if a == nil {
+		return nil
+	}
+	return &ScaleAggregateResponse{Count: a.Count, Avg: a.Avg, Min: a.Min, Max: a.Max}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"diary/internal/services"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// --- Tag Handler Interface ---
+
+type TagHandler interface {
+	ListTags(w http.ResponseWriter, r *http.Request)
+	RenameTag(w http.ResponseWriter, r *http.Request)
+	MergeTags(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Tag Handler Implementation ---
+
+type tagHandler struct {
+	service services.TagService
+}
+
+func NewTagHandler(service services.TagService) TagHandler {
+	return &tagHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type TagResponse struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+type MergeTagsRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+// --- Tag Handlers ---
+
+func (h *tagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	counts, err := h.service.ListTags(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]TagResponse, 0, len(counts))
+	for _, c := range counts {
+		response = append(response, TagResponse{Name: c.Name, Count: c.Count})
+	}
+
+	render.JSON(w, r, response)
+}
+
+// Переименование; если тег с новым именем уже есть, теги сливаются
+func (h *tagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, r, services.ErrInvalidTag.Wrap(err))
+		return
+	}
+
+	var req RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidPayload)
+		return
+	}
+
+	if err := h.service.RenameTag(r.Context(), userID, name, req.Name); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"message": "Tag renamed successfully",
+	})
+}
+
+func (h *tagHandler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req MergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidPayload)
+		return
+	}
+
+	if err := h.service.MergeTags(r.Context(), userID, req.Sources, req.Target); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"message": "Tags merged successfully",
+	})
+}
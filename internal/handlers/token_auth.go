@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"diary/internal/services"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// --- Персональные токены ---
+
+// Принимает персональные токены (Authorization: Bearer dpat_...), остальные
+// запросы передает основному аутентификатору. Права токена проверяет
+// политика маршрута: токен в контексте ограничивает права роли владельца.
+type tokenAuthenticator struct {
+	tokens   services.TokenService
+	fallback Authenticator
+}
+
+func NewTokenAuthenticator(tokens services.TokenService, fallback Authenticator) Authenticator {
+	return &tokenAuthenticator{tokens: tokens, fallback: fallback}
+}
+
+func (a *tokenAuthenticator) Verify(next http.HandlerFunc) http.HandlerFunc {
+	fallback := a.fallback.Verify(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := bearerToken(r)
+		if !ok || !strings.HasPrefix(secret, services.TokenPrefix) {
+			fallback(w, r)
+			return
+		}
+
+		token, err := a.tokens.AuthenticateToken(r.Context(), secret)
+		if errors.Is(err, services.ErrInvalidToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, r, errUnauthorized)
+			return
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next(w, r.WithContext(WithPrincipal(r.Context(), Principal{UserID: token.UserID, Token: token})))
+	}
+}
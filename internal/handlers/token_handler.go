@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/services"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// --- Token Handler Interface ---
+
+type TokenHandler interface {
+	CreateToken(w http.ResponseWriter, r *http.Request)
+	ListTokens(w http.ResponseWriter, r *http.Request)
+	RevokeToken(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Token Handler Implementation ---
+
+type tokenHandler struct {
+	service services.TokenService
+}
+
+func NewTokenHandler(service services.TokenService) TokenHandler {
+	return &tokenHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// RFC 3339; без срока токен действует до отзыва
+	ExpiresAt string `json:"expires_at"`
+}
+
+type TokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// Секрет возвращается только в ответе на создание
+type CreateTokenResponse struct {
+	TokenResponse
+	Token string `json:"token"`
+}
+
+type TokenListResponse struct {
+	Tokens []TokenResponse `json:"tokens"`
+}
+
+var errInvalidExpiresAt = errs.Field("expires_at", "invalid expiration time, expected RFC 3339")
+
+// --- Token Handlers ---
+
+func (h *tokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidPayload)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			writeError(w, r, errInvalidExpiresAt)
+			return
+		}
+		expiresAt = &parsed
+	}
+	scopes := make([]models.TokenScope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = models.TokenScope(scope)
+	}
+
+	token, secret, err := h.service.CreateToken(r.Context(), userID, req.Name, scopes, expiresAt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, CreateTokenResponse{
+		TokenResponse: newTokenResponse(token),
+		Token:         secret,
+	})
+}
+
+func (h *tokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tokens, err := h.service.ListTokens(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := TokenListResponse{Tokens: make([]TokenResponse, 0, len(tokens))}
+	for _, token := range tokens {
+		response.Tokens = append(response.Tokens, newTokenResponse(token))
+	}
+	render.JSON(w, r, response)
+}
+
+func (h *tokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := chi.URLParam(r, "id")
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Отозвать можно только свой токен
+	if err := h.service.RevokeToken(r.Context(), userID, tokenID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"message": "Token revoked successfully",
+	})
+}
+
+func newTokenResponse(token *models.PersonalAccessToken) TokenResponse {
+	scopes := make([]string, 0, len(token.ScopeList()))
+	for _, scope := range token.ScopeList() {
+		scopes = append(scopes, string(scope))
+	}
+	return TokenResponse{
+		ID:         token.ID.String(),
+		Name:       token.Name,
+		Scopes:     scopes,
+		ExpiresAt:  formatOptionalTime(token.ExpiresAt),
+		LastUsedAt: formatOptionalTime(token.LastUsedAt),
+		CreatedAt:  token.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.UTC().Format(time.RFC3339)
+	return &formatted
+}
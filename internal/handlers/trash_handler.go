@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"diary/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// --- Trash Handler Interface ---
+
+type TrashHandler interface {
+	ListTrash(w http.ResponseWriter, r *http.Request)
+	RestoreEntry(w http.ResponseWriter, r *http.Request)
+	PurgeEntry(w http.ResponseWriter, r *http.Request)
+}
+
+// --- Trash Handler Implementation ---
+
+type trashHandler struct {
+	service services.TrashService
+}
+
+func NewTrashHandler(service services.TrashService) TrashHandler {
+	return &trashHandler{service: service}
+}
+
+// --- Request/Response Structs ---
+
+type TrashEntryResponse struct {
+	EntryResponse
+	DeletedAt string `json:"deleted_at"`
+}
+
+type TrashListResponse struct {
+	Entries []TrashEntryResponse `json:"entries"`
+}
+
+// --- Trash Handlers ---
+
+func (h *trashHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	entries, err := h.service.ListTrash(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := TrashListResponse{Entries: make([]TrashEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, TrashEntryResponse{
+			EntryResponse: newEntryResponse(entry),
+			DeletedAt:     entry.DeletedAt.Time.UTC().Format(time.RFC3339),
+		})
+	}
+
+	render.JSON(w, r, response)
+}
+
+func (h *trashHandler) RestoreEntry(w http.ResponseWriter, r *http.Request) {
+	entryID := chi.URLParam(r, "id")
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Восстановить можно только свою запись из корзины
+	if err := h.service.RestoreEntry(r.Context(), userID, entryID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"message": "Entry restored successfully",
+	})
+}
+
+func (h *trashHandler) PurgeEntry(w http.ResponseWriter, r *http.Request) {
+	entryID := chi.URLParam(r, "id")
+	userID, err := sessionUserID(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Окончательно удалить можно только свою запись из корзины
+	if err := h.service.PurgeEntry(r.Context(), userID, entryID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"message": "Entry permanently deleted",
+	})
+}
@@ -0,0 +1,323 @@
+// Package migrations применяет версионированные миграции схемы базы данных.
+//
+// Миграции хранятся в sql/<драйвер>/ как пары файлов NNNN_name.up.sql и
+// NNNN_name.down.sql и встраиваются в бинарник. Номера версий у драйверов
+// совпадают: одна версия описывает одно изменение схемы для всех баз. Примененные версии
+// записываются в таблицу schema_migrations; каждая миграция выполняется
+// в одной транзакции вместе с этой записью.
+package migrations
+
+import (
+	"context"
+	"diary/internal/database"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var files embed.FS
+
+// База создана более новой версией приложения: ее схему эта версия не знает
+var ErrUnknownVersion = errors.New("database schema is newer than this build supports")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Состояние миграции в базе; AppliedAt пустое, если миграция не применена
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Версия есть в базе, но неизвестна этой сборке
+	Unknown bool
+}
+
+// Запись таблицы schema_migrations
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version integer PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	applied_at timestamp NOT NULL
+)`
+
+// --- Загрузка ---
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load читает миграции из каталога dir. У каждой версии должны быть оба
+// файла, номера версий не повторяются.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrations: unexpected file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migrations: invalid version in %s", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations: version %d has different names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: version %d (%s) needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// --- Migrator ---
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	now        func() time.Time
+}
+
+// New создает Migrator со встроенными миграциями для драйвера базы
+func New(db *gorm.DB) (*Migrator, error) {
+	driver := database.Driver(db)
+	dir := path.Join("sql", driver)
+	if _, err := fs.Stat(files, dir); err != nil {
+		return nil, fmt.Errorf("migrations: no migrations for database driver %q", driver)
+	}
+	migrations, err := Load(files, dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithMigrations(db, migrations), nil
+}
+
+func NewWithMigrations(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations, now: time.Now}
+}
+
+// Последняя версия, известная этой сборке
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Текущая версия схемы базы; 0 - миграции не применялись
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Check возвращает ErrUnknownVersion, если база уже обновлена более новой
+// версией приложения. Запускаться поверх такой схемы нельзя: старый код
+// может повредить данные, о которых не знает.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrUnknownVersion, version, m.Latest())
+	}
+	return nil
+}
+
+// Up применяет все непримененные миграции по порядку и возвращает их
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.Check(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if applied[migration.Version] {
+			continue
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: m.now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrations: apply %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down откатывает последнюю примененную миграцию. Возвращает nil,
+// если откатывать нечего.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	if err := m.Check(ctx); err != nil {
+		return nil, err
+	}
+	version, err := m.Version(ctx)
+	if err != nil || version == 0 {
+		return nil, err
+	}
+
+	migration := m.find(version)
+	if migration == nil {
+		return nil, fmt.Errorf("migrations: version %d is applied but missing from this build", version)
+	}
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := execScript(tx, migration.Down); err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, "version = ?", version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrations: revert %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return migration, nil
+}
+
+// Status перечисляет известные миграции и версии из базы, неизвестные сборке
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]schemaMigration, len(applied))
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := byVersion[migration.Version]; ok {
+			appliedAt := a.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(byVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range applied {
+		if _, ok := byVersion[a.Version]; ok {
+			appliedAt := a.AppliedAt
+			statuses = append(statuses, Status{Version: a.Version, Name: a.Name, AppliedAt: &appliedAt, Unknown: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// --- Вспомогательные функции ---
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// Примененные миграции по возрастанию версии; таблица создается при первом обращении
+func (m *Migrator) applied(ctx context.Context) ([]schemaMigration, error) {
+	db := m.db.WithContext(ctx)
+	if err := db.Exec(createSchemaMigrations).Error; err != nil {
+		return nil, err
+	}
+	var applied []schemaMigration
+	if err := db.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		versions[a.Version] = true
+	}
+	return versions, nil
+}
+
+// Выполняет SQL-скрипт миграции. Скрипт из одних комментариев допустим:
+// так оформляются миграции, которые нечего откатывать.
+func execScript(tx *gorm.DB, script string) error {
+	if isBlank(script) {
+		return nil
+	}
+	return tx.Exec(script).Error
+}
+
+func isBlank(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"context"
+	"diary/internal/database"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+func TestUpCreatesModelSchema(t *testing.T) {
+	dbtest.Run(t, testUpCreatesModelSchema)
+}
+
+func testUpCreatesModelSchema(t *testing.T, backend dbtest.Backend) {
+	// Arrange
+	db := backend.Open(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	// Act
+	applied, err := migrator.Up(context.Background())
+
+	// Assert - каждое поле моделей есть в схеме
+	require.NoError(t, err)
+	assert.Len(t, applied, migrator.Latest())
+	for _, model := range []any{&models.Entry{}, &models.Tag{}, &models.EntryRevision{}, &models.PersonalAccessToken{}, &models.EntryKey{}} {
+		s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+		for _, field := range s.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", s.Table, field.DBName)
+			}
+		}
+		for _, index := range s.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "%s %s", s.Table, index.Name)
+		}
+	}
+	assert.True(t, db.Migrator().HasTable("entry_tags"))
+
+	version, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), version)
+
+	// Повторный запуск ничего не делает
+	applied, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+// До появления миграций схему SQLite создавал AutoMigrate; PostgreSQL
+// поддерживается только с миграциями
+func TestUpAdoptsAutoMigratedDatabase(t *testing.T) {
+	// Arrange - база, созданная AutoMigrate до появления миграций
+	db := dbtest.Backends()[0].Open(t)
+	require.NoError(t, db.AutoMigrate(&models.Entry{}, &models.Tag{}, &models.EntryRevision{}))
+	// Колонок, которые добавили более поздние миграции, у такой базы не было
+	for _, column := range []string{"ciphertext", "nonce", "algorithm", "key_id"} {
+		require.NoError(t, db.Migrator().DropColumn(&models.Entry{}, column))
+	}
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	id := uuid.New()
+	require.NoError(t, db.Exec(
+		"INSERT INTO entries (id, user_id, title, content, created_at) VALUES (?, ?, ?, ?, ?)",
+		id, uuid.New(), "Old", "Old entry", created,
+	).Error)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	// Act
+	_, err = migrator.Up(context.Background())
+
+	// Assert - данные сохранены, даты заполнены
+	require.NoError(t, err)
+	var entry models.Entry
+	require.NoError(t, db.First(&entry, "id = ?", id).Error)
+	assert.Equal(t, "Old entry", entry.Content)
+	assert.True(t, created.Equal(entry.EntryDate))
+	assert.True(t, created.Equal(entry.UpdatedAt))
+}
+
+func TestDownAndStatus(t *testing.T) {
+	dbtest.Run(t, testDownAndStatus)
+}
+
+func testDownAndStatus(t *testing.T, backend dbtest.Backend) {
+	// Arrange
+	db := backend.Open(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	// Act
+	reverted, err := migrator.Down(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, reverted)
+	assert.Equal(t, migrator.Latest(), reverted.Version)
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, migrator.Latest())
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	// Откат до пустой базы удаляет таблицы
+	for {
+		reverted, err := migrator.Down(context.Background())
+		require.NoError(t, err)
+		if reverted == nil {
+			break
+		}
+	}
+	assert.False(t, db.Migrator().HasTable("entries"))
+	assert.True(t, db.Migrator().HasTable("schema_migrations"))
+}
+
+func TestUnknownFutureVersion(t *testing.T) {
+	dbtest.Run(t, testUnknownFutureVersion)
+}
+
+func testUnknownFutureVersion(t *testing.T, backend dbtest.Backend) {
+	// Arrange - базу уже обновила более новая сборка
+	db := backend.Open(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&schemaMigration{Version: migrator.Latest() + 1, Name: "from_the_future", AppliedAt: time.Now()}).Error)
+
+	// Act
+	checkErr := migrator.Check(context.Background())
+	_, upErr := migrator.Up(context.Background())
+	_, downErr := migrator.Down(context.Background())
+
+	// Assert
+	assert.ErrorIs(t, checkErr, ErrUnknownVersion)
+	assert.ErrorIs(t, upErr, ErrUnknownVersion)
+	assert.ErrorIs(t, downErr, ErrUnknownVersion)
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	assert.True(t, statuses[len(statuses)-1].Unknown)
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	dbtest.Run(t, testFailedMigrationRollsBack)
+}
+
+func testFailedMigrationRollsBack(t *testing.T, backend dbtest.Backend) {
+	// Arrange
+	db := backend.Open(t)
+	migrator := NewWithMigrations(db, []Migration{
+		{Version: 1, Name: "broken", Up: "CREATE TABLE notes (id integer); INSERT INTO missing VALUES (1);", Down: "DROP TABLE notes;"},
+	})
+
+	// Act
+	_, err := migrator.Up(context.Background())
+
+	// Assert - ни таблица, ни запись о версии не сохранились
+	assert.Error(t, err)
+	assert.False(t, db.Migrator().HasTable("notes"))
+	version, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, version)
+}
+
+func TestDriversShareVersions(t *testing.T) {
+	// Act
+	sqliteMigrations, err := Load(files, "sql/"+database.DriverSQLite)
+	require.NoError(t, err)
+	postgresMigrations, err := Load(files, "sql/"+database.DriverPostgres)
+	require.NoError(t, err)
+
+	// Assert - одна версия означает одно изменение схемы для всех баз
+	require.Len(t, postgresMigrations, len(sqliteMigrations))
+	for i := range sqliteMigrations {
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		ok    bool
+	}{
+		{"valid", fstest.MapFS{
+			"m/0002_second.up.sql":   {Data: []byte("SELECT 2;")},
+			"m/0002_second.down.sql": {Data: []byte("-- nothing")},
+			"m/0001_first.up.sql":    {Data: []byte("SELECT 1;")},
+			"m/0001_first.down.sql":  {Data: []byte("SELECT 1;")},
+		}, true},
+		{"missing down", fstest.MapFS{
+			"m/0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		}, false},
+		{"name mismatch", fstest.MapFS{
+			"m/0001_first.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+		}, false},
+		{"unexpected file", fstest.MapFS{
+			"m/README.md": {Data: []byte("notes")},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files, "m")
+			if !tt.ok {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, migrations, 2)
+			assert.Equal(t, 1, migrations[0].Version)
+			assert.Equal(t, "second", migrations[1].Name)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+-- Базовая схема для PostgreSQL. Колонки совпадают со схемой SQLite;
+-- дополнительно хранится tsvector для полнотекстового поиска.
+
+CREATE TABLE IF NOT EXISTS entries (
+	id uuid PRIMARY KEY,
+	user_id uuid,
+	title varchar(255) NOT NULL,
+	content text NOT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	entry_date timestamptz,
+	time_zone varchar(64) DEFAULT 'UTC',
+	mood smallint,
+	mood_emoji varchar(32),
+	mood_label varchar(64),
+	energy smallint,
+	version integer NOT NULL DEFAULT 1,
+	-- Заголовок весит больше текста (веса A и B). Конфигурация simple
+	-- не зависит от языка записи, как и токенизатор unicode61 в SQLite.
+	search tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', title), 'A') ||
+		setweight(to_tsvector('simple', content), 'B')
+	) STORED
+);
+CREATE INDEX IF NOT EXISTS idx_entries_user_id ON entries (user_id);
+CREATE INDEX IF NOT EXISTS idx_entries_user_created ON entries (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_entries_user_date ON entries (user_id, entry_date);
+-- Корзина и ее очистка выбирают только удаленные записи
+CREATE INDEX IF NOT EXISTS idx_entries_deleted_at ON entries (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_entries_search ON entries USING gin (search);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id uuid PRIMARY KEY,
+	user_id uuid NOT NULL,
+	name varchar(64) NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags (user_id, name);
+
+CREATE TABLE IF NOT EXISTS entry_tags (
+	entry_id uuid,
+	tag_id uuid,
+	PRIMARY KEY (entry_id, tag_id),
+	CONSTRAINT fk_entry_tags_entry FOREIGN KEY (entry_id) REFERENCES entries (id) ON DELETE CASCADE,
+	CONSTRAINT fk_entry_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+-- PostgreSQL не индексирует внешние ключи; индекс нужен для выборок по тегу
+CREATE INDEX IF NOT EXISTS idx_entry_tags_tag_id ON entry_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS entry_revisions (
+	id uuid PRIMARY KEY,
+	entry_id uuid NOT NULL,
+	number integer NOT NULL,
+	title varchar(255) NOT NULL,
+	content text NOT NULL,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_revisions_entry_number ON entry_revisions (entry_id, number);
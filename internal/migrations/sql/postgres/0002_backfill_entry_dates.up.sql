@@ -0,0 +1,4 @@
+-- Версия выровнена с SQLite. Базы PostgreSQL создаются уже со всеми
+-- колонками, но записи, перенесенные из старой базы, могут быть без дат.
+UPDATE entries SET entry_date = created_at WHERE entry_date IS NULL;
+UPDATE entries SET updated_at = created_at WHERE updated_at IS NULL;
@@ -0,0 +1,6 @@
+DROP TABLE entry_keys;
+
+ALTER TABLE entries DROP COLUMN key_id;
+ALTER TABLE entries DROP COLUMN algorithm;
+ALTER TABLE entries DROP COLUMN nonce;
+ALTER TABLE entries DROP COLUMN ciphertext;
@@ -0,0 +1,7 @@
+-- Индекс полнотекстового поиска создается при запуске (repos.SetupSearchIndex)
+-- и без таблицы entries не имеет смысла
+DROP TABLE IF EXISTS `entries_fts`;
+DROP TABLE IF EXISTS `entry_revisions`;
+DROP TABLE IF EXISTS `entry_tags`;
+DROP TABLE IF EXISTS `tags`;
+DROP TABLE IF EXISTS `entries`;
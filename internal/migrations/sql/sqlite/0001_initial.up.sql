@@ -0,0 +1,52 @@
+-- Базовая схема. Совпадает со схемой, которую создавал gorm AutoMigrate,
+-- поэтому на существующей базе миграция ничего не меняет.
+
+CREATE TABLE IF NOT EXISTS `entries` (
+	`id` uuid,
+	`user_id` uuid,
+	`title` varchar(255) NOT NULL,
+	`content` text NOT NULL,
+	`created_at` datetime,
+	`updated_at` datetime,
+	`deleted_at` datetime,
+	`entry_date` datetime,
+	`time_zone` varchar(64) DEFAULT "UTC",
+	`mood` smallint,
+	`mood_emoji` varchar(32),
+	`mood_label` varchar(64),
+	`energy` smallint,
+	`version` integer NOT NULL DEFAULT 1,
+	PRIMARY KEY (`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_entries_user_id` ON `entries`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_entries_user_created` ON `entries`(`user_id`, `created_at`);
+CREATE INDEX IF NOT EXISTS `idx_entries_user_date` ON `entries`(`user_id`, `entry_date`);
+CREATE INDEX IF NOT EXISTS `idx_entries_deleted_at` ON `entries`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+	`id` uuid,
+	`user_id` uuid NOT NULL,
+	`name` varchar(64) NOT NULL,
+	`created_at` datetime,
+	PRIMARY KEY (`id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_tags_user_name` ON `tags`(`user_id`, `name`);
+
+CREATE TABLE IF NOT EXISTS `entry_tags` (
+	`entry_id` uuid,
+	`tag_id` uuid,
+	PRIMARY KEY (`entry_id`, `tag_id`),
+	CONSTRAINT `fk_entry_tags_entry` FOREIGN KEY (`entry_id`) REFERENCES `entries`(`id`) ON DELETE CASCADE,
+	CONSTRAINT `fk_entry_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `entry_revisions` (
+	`id` uuid,
+	`entry_id` uuid NOT NULL,
+	`number` integer NOT NULL,
+	`title` varchar(255) NOT NULL,
+	`content` text NOT NULL,
+	`created_at` datetime,
+	PRIMARY KEY (`id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_revisions_entry_number` ON `entry_revisions`(`entry_id`, `number`);
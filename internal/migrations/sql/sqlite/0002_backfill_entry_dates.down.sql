@@ -0,0 +1 @@
+-- Заполненные даты неотличимы от указанных пользователем, откатывать нечего
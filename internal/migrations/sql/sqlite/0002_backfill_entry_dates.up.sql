@@ -0,0 +1,4 @@
+-- Записи, созданные до появления даты записи и времени изменения,
+-- относятся к дате создания
+UPDATE `entries` SET `entry_date` = `created_at` WHERE `entry_date` IS NULL;
+UPDATE `entries` SET `updated_at` = `created_at` WHERE `updated_at` IS NULL;
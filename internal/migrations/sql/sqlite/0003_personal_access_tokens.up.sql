@@ -0,0 +1,15 @@
+-- Персональные токены доступа; хранится только SHA-256 секрета
+
+CREATE TABLE `personal_access_tokens` (
+	`id` uuid,
+	`user_id` uuid NOT NULL,
+	`name` varchar(100) NOT NULL,
+	`secret_hash` char(64) NOT NULL,
+	`scopes` varchar(255) NOT NULL,
+	`expires_at` datetime,
+	`last_used_at` datetime,
+	`created_at` datetime,
+	PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_personal_access_tokens_user_id` ON `personal_access_tokens`(`user_id`);
+CREATE UNIQUE INDEX `idx_personal_access_tokens_secret_hash` ON `personal_access_tokens`(`secret_hash`);
@@ -0,0 +1,20 @@
+-- Записи, зашифрованные на клиенте, и обернутые ключи шифрования
+
+ALTER TABLE `entries` ADD COLUMN `ciphertext` blob;
+ALTER TABLE `entries` ADD COLUMN `nonce` blob;
+ALTER TABLE `entries` ADD COLUMN `algorithm` varchar(32) NOT NULL DEFAULT '';
+ALTER TABLE `entries` ADD COLUMN `key_id` varchar(64) NOT NULL DEFAULT '';
+
+CREATE TABLE `entry_keys` (
+	`user_id` uuid,
+	`key_id` varchar(64),
+	`algorithm` varchar(32) NOT NULL,
+	`nonce` blob NOT NULL,
+	`wrapped_key` blob NOT NULL,
+	`kdf` varchar(32) NOT NULL,
+	`salt` blob NOT NULL,
+	`iterations` integer NOT NULL,
+	`created_at` datetime,
+	`updated_at` datetime,
+	PRIMARY KEY (`user_id`, `key_id`)
+);
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Алгоритм шифрования записей и обертки ключей (имена из JWA, RFC 7518)
+const EncryptionA256GCM = "A256GCM"
+
+// Вывод ключа обертки из пароля пользователя
+const KDFPBKDF2SHA256 = "PBKDF2-SHA256"
+
+// Размер nonce алгоритма в байтах; ok == false для неизвестного алгоритма
+func EncryptionNonceSize(algorithm string) (size int, ok bool) {
+	if algorithm == EncryptionA256GCM {
+		return 12, true
+	}
+	return 0, false
+}
+
+// Ключ шифрования записей, обернутый (зашифрованный) ключом, который клиент
+// выводит из пароля пользователя. Сервер хранит только обернутый ключ и
+// параметры вывода, поэтому не может расшифровать ни ключ, ни записи.
+type EntryKey struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// Выбирается клиентом; записи ссылаются на ключ по нему
+	KeyID string `gorm:"type:varchar(64);primaryKey"`
+	// Алгоритм обертки, ее nonce и обернутый ключ
+	Algorithm  string `gorm:"type:varchar(32);not null"`
+	Nonce      []byte `gorm:"not null"`
+	WrappedKey []byte `gorm:"not null"`
+	// Вывод ключа обертки: функция, соль и число итераций
+	KDF        string    `gorm:"type:varchar(32);not null"`
+	Salt       []byte    `gorm:"not null"`
+	Iterations int       `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	// Меняется, когда клиент заново оборачивает ключ (например, при смене пароля)
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -4,12 +4,60 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Entry struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;index"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;index:idx_entries_user_created,priority:1;index:idx_entries_user_date,priority:1"`
 	Title     string    `gorm:"type:varchar(255);not null"`
 	Content   string    `gorm:"type:text;not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_entries_user_created,priority:2"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	// Время перемещения в корзину; удаленные записи не видны в обычных запросах
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Tags      []Tag          `gorm:"many2many:entry_tags;constraint:OnDelete:CASCADE"`
+
+	// Дата, к которой относится запись (выбирается пользователем, хранится в UTC),
+	// и часовой пояс пользователя в формате IANA
+	EntryDate time.Time `gorm:"index:idx_entries_user_date,priority:2"`
+	TimeZone  string    `gorm:"type:varchar(64);default:UTC"`
+
+	// Настроение и энергия по шкале MoodMin..MoodMax; nil - не указано
+	Mood      *int   `gorm:"type:smallint"`
+	MoodEmoji string `gorm:"type:varchar(32)"`
+	MoodLabel string `gorm:"type:varchar(64)"`
+	Energy    *int   `gorm:"type:smallint"`
+
+	// Счетчик изменений для оптимистичной блокировки; увеличивается при каждом
+	// обновлении записи, в том числе при переименовании ее тегов
+	Version int `gorm:"not null;default:1"`
+
+	// Запись, зашифрованная на клиенте (E2EE): заголовок и текст есть только
+	// в Ciphertext, Title и Content пусты. Ключ KeyID хранится у сервера
+	// обернутым (EntryKey). У обычных записей Algorithm пуст.
+	Ciphertext []byte
+	Nonce      []byte
+	Algorithm  string `gorm:"type:varchar(32);not null;default:''"`
+	KeyID      string `gorm:"type:varchar(64);not null;default:''"`
+}
+
+func (e *Entry) Encrypted() bool {
+	return e.Algorithm != ""
 }
+
+// Ограничения полей записи по умолчанию. Длина заголовка не может быть
+// больше размера колонки entries.title (varchar(255)).
+const (
+	MaxTitleColumnLength    = 255
+	DefaultMaxContentLength = 100_000
+	DefaultMaxTags          = 32
+)
+
+// Шкала настроения и энергии
+const (
+	MoodMin   = 1
+	MoodMax   = 5
+	EnergyMin = 1
+	EnergyMax = 5
+)
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// --- Параметры выборки списка записей ---
+
+type EntrySortField string
+
+const (
+	SortByEntryDate EntrySortField = "entry_date"
+	SortByCreatedAt EntrySortField = "created_at"
+	SortByUpdatedAt EntrySortField = "updated_at"
+	SortByTitle     EntrySortField = "title"
+)
+
+func (f EntrySortField) Valid() bool {
+	switch f {
+	case SortByEntryDate, SortByCreatedAt, SortByUpdatedAt, SortByTitle:
+		return true
+	}
+	return false
+}
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+func (o SortOrder) Valid() bool {
+	return o == SortAsc || o == SortDesc
+}
+
+// Режим фильтрации по нескольким тегам
+type TagMatch string
+
+const (
+	// Запись содержит хотя бы один из тегов
+	TagMatchAny TagMatch = "any"
+	// Запись содержит все теги
+	TagMatchAll TagMatch = "all"
+)
+
+func (m TagMatch) Valid() bool {
+	return m == TagMatchAny || m == TagMatchAll
+}
+
+type EntryFilter struct {
+	// Диапазон по EntryDate: From включительно, To не включительно
+	From *time.Time
+	To   *time.Time
+	// Подстрока заголовка (без учета регистра)
+	Title string
+	// Нормализованные имена тегов и режим их сочетания
+	Tags     []string
+	TagMatch TagMatch
+
+	SortBy    EntrySortField
+	SortOrder SortOrder
+
+	// Пагинация; Limit не больше MaxPageSize, 0 - DefaultPageSize
+	Cursor string
+	Limit  int
+}
+
+// Размер страницы списков и поиска: по умолчанию и наибольший
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
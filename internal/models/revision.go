@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Снимок заголовка и текста записи после очередного изменения.
+// Номера ревизий идут подряд в пределах записи, начиная с 1.
+type EntryRevision struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	EntryID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_revisions_entry_number,priority:1"`
+	Number    int       `gorm:"not null;uniqueIndex:idx_revisions_entry_number,priority:2"`
+	Title     string    `gorm:"type:varchar(255);not null"`
+	Content   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// --- Сравнение ревизий ---
+
+// Единица сравнения: строки или слова
+type DiffMode string
+
+const (
+	DiffByLine DiffMode = "line"
+	DiffByWord DiffMode = "word"
+)
+
+func (m DiffMode) Valid() bool {
+	return m == DiffByLine || m == DiffByWord
+}
+
+type DiffOpType string
+
+const (
+	DiffEqual  DiffOpType = "equal"
+	DiffInsert DiffOpType = "insert"
+	DiffDelete DiffOpType = "delete"
+)
+
+// Фрагмент различий; склеенные фрагменты equal и delete дают старый текст,
+// equal и insert - новый
+type DiffOp struct {
+	Type DiffOpType
+	Text string
+}
+
+type RevisionDiff struct {
+	From    int
+	To      int
+	Mode    DiffMode
+	Title   []DiffOp
+	Content []DiffOp
+}
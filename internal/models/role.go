@@ -0,0 +1,30 @@
+package models
+
+// Роль пользователя определяет права его сессий. Пользователи хранятся у
+// провайдера аутентификации, поэтому роль назначается конфигурацией сервера.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Права сессии с этой ролью; у неизвестной роли прав нет
+func (r Role) Scopes() []TokenScope {
+	switch r {
+	case RoleUser:
+		return []TokenScope{ScopeEntriesWrite, ScopeTokensManage}
+	case RoleAdmin:
+		return []TokenScope{ScopeEntriesWrite, ScopeTokensManage, ScopeAdminRead}
+	}
+	return nil
+}
+
+func (r Role) HasScope(scope TokenScope) bool {
+	for _, granted := range r.Scopes() {
+		if granted.Implies(scope) {
+			return true
+		}
+	}
+	return false
+}
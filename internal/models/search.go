@@ -0,0 +1,12 @@
+package models
+
+// Результат полнотекстового поиска по записям
+type SearchResult struct {
+	Entry *Entry
+	// Релевантность: чем больше, тем лучше
+	Rank float64
+	// Фрагменты с подсвеченными совпадениями (<mark>...</mark>). Текст
+	// записи в них экранирован для HTML, разметка - только теги mark.
+	TitleSnippet   string
+	ContentSnippet string
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Отметка настроения/энергии одной записи
+type MoodPoint struct {
+	EntryDate time.Time
+	Mood      *int
+	Energy    *int
+}
+
+// Период агрегации статистики
+type StatsPeriod string
+
+const (
+	PeriodDay   StatsPeriod = "day"
+	PeriodWeek  StatsPeriod = "week"
+	PeriodMonth StatsPeriod = "month"
+)
+
+func (p StatsPeriod) Valid() bool {
+	switch p {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	}
+	return false
+}
+
+// Сводка значений шкалы за период
+type ScaleAggregate struct {
+	Count int
+	Avg   float64
+	Min   int
+	Max   int
+}
+
+// Агрегаты за один день, неделю (с понедельника) или месяц
+type MoodBucket struct {
+	Start   time.Time
+	Entries int
+	Mood    *ScaleAggregate
+	Energy  *ScaleAggregate
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Тег принадлежит пользователю; имя уникально в пределах пользователя
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tags_user_name,priority:1"`
+	Name      string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_tags_user_name,priority:2"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// Тег с количеством записей, в которых он используется
+type TagCount struct {
+	Name  string
+	Count int64
+}
+
+const MaxTagNameLength = 64
+
+// Приводит имя тега к каноническому виду: без лишних пробелов, в нижнем регистре
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
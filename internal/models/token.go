@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Право на группу операций. Сессия получает права своей роли, персональный
+// токен - только выданные ему, и не больше, чем у роли владельца. Право на
+// запись включает чтение: изменение записи требует ее текущей версии.
+type TokenScope string
+
+const (
+	ScopeEntriesRead  TokenScope = "entries:read"
+	ScopeEntriesWrite TokenScope = "entries:write"
+	// Выпуск и отзыв токенов; токену не выдается, чтобы он не мог
+	// выпустить другой токен или расширить свои права
+	ScopeTokensManage TokenScope = "tokens:manage"
+	// Список пользователей и использование хранилища
+	ScopeAdminRead TokenScope = "admin:read"
+)
+
+// Можно ли выдать право персональному токену
+func (s TokenScope) Valid() bool {
+	return s == ScopeEntriesRead || s == ScopeEntriesWrite
+}
+
+// Включает ли право другое право
+func (s TokenScope) Implies(scope TokenScope) bool {
+	return s == scope || (s == ScopeEntriesWrite && scope == ScopeEntriesRead)
+}
+
+// Персональный токен доступа для скриптов и интеграций. Сам секрет не
+// хранится: он показывается пользователю один раз при создании, а для
+// проверки хранится его SHA-256.
+type PersonalAccessToken struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"type:varchar(100);not null"`
+	SecretHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	// Права через пробел, как scope в OAuth 2.0
+	Scopes string `gorm:"type:varchar(255);not null"`
+	// nil - токен бессрочный
+	ExpiresAt *time.Time
+	// Обновляется не чаще раза в минуту, чтобы не писать в хранилище
+	// на каждый запрос
+	LastUsedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func JoinScopes(scopes []TokenScope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, " ")
+}
+
+func (t *PersonalAccessToken) ScopeList() []TokenScope {
+	fields := strings.Fields(t.Scopes)
+	scopes := make([]TokenScope, len(fields))
+	for i, field := range fields {
+		scopes[i] = TokenScope(field)
+	}
+	return scopes
+}
+
+func (t *PersonalAccessToken) HasScope(scope TokenScope) bool {
+	for _, granted := range t.ScopeList() {
+		if granted.Implies(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Использование хранилища одним пользователем. Учетные записи хранятся у
+// провайдера аутентификации, поэтому пользователь известен серверу, только
+// если у него есть записи или токены.
+type UserUsage struct {
+	UserID uuid.UUID
+	// Записи вне корзины
+	Entries int64
+	Trashed int64
+	Tokens  int64
+	// Последнее изменение записи, включая корзину; nil - записей нет
+	LastEntryAt *time.Time
+}
+
+// Использование хранилища всеми пользователями
+type UsageTotals struct {
+	Users   int64
+	Entries int64
+	Trashed int64
+	Tokens  int64
+}
@@ -0,0 +1,93 @@
+package repos_test
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/migrations"
+	"diary/internal/repos"
+	"diary/internal/repos/repotest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Все реализации EntryRepository, TokenRepository, UsageRepository и
+// EntryKeyRepository проходят общие наборы repotest
+
+// Хранилище, на котором запускаются наборы: каждый вызов open дает пустое
+type conformanceBackend struct {
+	name string
+	open func(t *testing.T) repos.Repository
+}
+
+// Базы из dbtest, файлы и память
+func conformanceBackends() []conformanceBackend {
+	var backends []conformanceBackend
+	for _, backend := range dbtest.Backends() {
+		backends = append(backends, conformanceBackend{
+			name: backend.Name,
+			open: func(t *testing.T) repos.Repository {
+				return repos.NewRepository(migratedDB(t, backend))
+			},
+		})
+	}
+	return append(backends,
+		conformanceBackend{
+			name: "files",
+			open: func(t *testing.T) repos.Repository {
+				repo, err := repos.NewFileRepository(t.TempDir())
+				require.NoError(t, err)
+				return repo
+			},
+		},
+		conformanceBackend{
+			name: "memory",
+			open: func(t *testing.T) repos.Repository {
+				return repos.NewMemoryRepository()
+			},
+		},
+	)
+}
+
+// Запускает run в подтесте для каждого хранилища
+func runConformance(t *testing.T, run func(t *testing.T, open func(t *testing.T) repos.Repository)) {
+	for _, backend := range conformanceBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			run(t, backend.open)
+		})
+	}
+}
+
+func TestEntryRepositoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, open func(t *testing.T) repos.Repository) {
+		repotest.RunEntryRepository(t, func(t *testing.T) repos.EntryRepository { return open(t) })
+	})
+}
+
+func TestTokenRepositoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, open func(t *testing.T) repos.Repository) {
+		repotest.RunTokenRepository(t, func(t *testing.T) repos.TokenRepository { return open(t) })
+	})
+}
+
+func TestUsageRepositoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, open func(t *testing.T) repos.Repository) {
+		repotest.RunUsageRepository(t, func(t *testing.T) repotest.UsageStore { return open(t) })
+	})
+}
+
+func TestEntryKeyRepositoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T, open func(t *testing.T) repos.Repository) {
+		repotest.RunEntryKeyRepository(t, func(t *testing.T) repos.EntryKeyRepository { return open(t) })
+	})
+}
+
+func migratedDB(t *testing.T, backend dbtest.Backend) *gorm.DB {
+	db := backend.Open(t)
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errs.Field("cursor", "invalid cursor")
+
+// --- Курсор для keyset-пагинации ---
+
+// Курсор указывает на последнюю запись предыдущей страницы и запоминает
+// сортировку, с которой он был выдан. Для клиента он непрозрачен: это base64 от JSON.
+type entryCursor struct {
+	SortBy    models.EntrySortField `json:"s"`
+	SortOrder models.SortOrder      `json:"o"`
+	Value     string                `json:"v"`
+	ID        uuid.UUID             `json:"id"`
+}
+
+func newEntryCursor(filter models.EntryFilter, entry *models.Entry) entryCursor {
+	c := entryCursor{SortBy: filter.SortBy, SortOrder: filter.SortOrder, ID: entry.ID}
+	switch filter.SortBy {
+	case models.SortByTitle:
+		c.Value = entry.Title
+	case models.SortByCreatedAt:
+		c.Value = entry.CreatedAt.Format(time.RFC3339Nano)
+	case models.SortByUpdatedAt:
+		c.Value = entry.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		c.Value = entry.EntryDate.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// Значение сортируемой колонки в виде, пригодном для сравнения в запросе
+func (c entryCursor) sortValue() (interface{}, error) {
+	switch c.SortBy {
+	case models.SortByTitle:
+		return c.Value, nil
+	default:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	}
+}
+
+func encodeCursor(c entryCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Декодирует курсор и проверяет, что он выдан для той же сортировки
+func decodeCursor(s string, filter models.EntryFilter) (*entryCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c entryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != filter.SortBy || c.SortOrder != filter.SortOrder {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
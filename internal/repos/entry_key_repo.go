@@ -0,0 +1,105 @@
+package repos
+
+import (
+	"bytes"
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrEntryKeyNotFound = errs.NotFound("entry key not found")
+
+// --- Entry Key Repository Interface ---
+
+// Обернутые ключи шифрования записей. Удаления нет: без ключа зашифрованные
+// им записи уже не прочитать.
+type EntryKeyRepository interface {
+	// Создает ключ или заменяет обертку существующего; время создания
+	// существующего ключа сохраняется
+	PutEntryKey(ctx context.Context, key *models.EntryKey) error
+	ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error)
+	ReadEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error)
+}
+
+// --- Entry Key Repository Implementation ---
+
+type entryKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewEntryKeyRepository(db *gorm.DB) EntryKeyRepository {
+	return &entryKeyRepository{db: db}
+}
+
+// --- Entry Keys ---
+
+func (r *entryKeyRepository) PutEntryKey(ctx context.Context, key *models.EntryKey) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"algorithm", "nonce", "wrapped_key", "kdf", "salt", "iterations", "updated_at"}),
+	}).Create(key).Error
+}
+
+// Ключи пользователя в порядке создания
+func (r *entryKeyRepository) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	var keys []*models.EntryKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at, key_id").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *entryKeyRepository) ReadEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	var key models.EntryKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND key_id = ?", userID, keyID).First(&key).Error
+	if err != nil {
+		return nil, notFound(err, ErrEntryKeyNotFound)
+	}
+	return &key, nil
+}
+
+// --- Вспомогательные функции ---
+
+// Копия ключа; хранилища без базы не отдают наружу свои значения
+func cloneEntryKey(key *models.EntryKey) *models.EntryKey {
+	clone := *key
+	clone.Nonce = bytes.Clone(key.Nonce)
+	clone.WrappedKey = bytes.Clone(key.WrappedKey)
+	clone.Salt = bytes.Clone(key.Salt)
+	clone.CreatedAt = key.CreatedAt.UTC()
+	clone.UpdatedAt = key.UpdatedAt.UTC()
+	return &clone
+}
+
+// Порядок ListEntryKeys для хранилищ без базы
+func sortEntryKeys(keys []*models.EntryKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.KeyID < b.KeyID
+	})
+}
+
+// Заполняет время создания и изменения, как autoCreateTime и autoUpdateTime
+// в базе; у существующего ключа время создания не меняется
+func prepareEntryKey(key, existing *models.EntryKey, now time.Time) *models.EntryKey {
+	if existing != nil {
+		key.CreatedAt = existing.CreatedAt
+	} else if key.CreatedAt.IsZero() {
+		key.CreatedAt = now
+	}
+	key.UpdatedAt = now
+	return cloneEntryKey(key)
+}
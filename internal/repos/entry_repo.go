@@ -1,19 +1,36 @@
 package repos
 
 import (
+	"context"
+	"diary/internal/errs"
 	"diary/internal/models"
+	"fmt"
+	"strings"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrEntryNotFound = errs.NotFound("entry not found")
+	// Запись изменилась с момента чтения: версия не совпадает
+	ErrVersionConflict = errs.Conflict("entry has been modified")
+	// Запись с таким ID уже есть
+	errEntryExists = errs.Conflict("entry already exists")
+)
+
 // --- Entry Repository Interface ---
 
+// Все запросы ограничены владельцем записи: чужая запись неотличима
+// от несуществующей и дает ErrEntryNotFound
+
 type EntryRepository interface {
-	Create(entry *models.Entry) error
-	Read(id string) (*models.Entry, error)
-	Update(entry *models.Entry) error
-	Delete(id string) error
-	List() ([]*models.Entry, error)
+	Create(ctx context.Context, entry *models.Entry) error
+	Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error)
+	Update(ctx context.Context, entry *models.Entry) error
+	Delete(ctx context.Context, userID uuid.UUID, id string) error
+	List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error)
 }
 
 // --- Entry Repository Implementation ---
@@ -28,30 +45,226 @@ func NewEntryRepository(db *gorm.DB) EntryRepository {
 
 // --- CRUD Entry ---
 
-func (r *entryRepository) Create(entry *models.Entry) error {
-	return r.db.Create(entry).Error
+// Теги записи передаются по имени; существующие теги пользователя
+// переиспользуются, недостающие создаются
+func (r *entryRepository) Create(ctx context.Context, entry *models.Entry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tags, err := resolveTags(tx, entry.UserID, entry.Tags)
+		if err != nil {
+			return err
+		}
+		entry.Tags = tags
+		entry.Version = 1
+		if err := tx.Create(entry).Error; err != nil {
+			return conflict(err, errEntryExists)
+		}
+		_, err = addRevision(tx, nil, entry.ID, entry.Title, entry.Content)
+		return err
+	})
 }
 
-func (r *entryRepository) Read(id string) (*models.Entry, error) {
+func (r *entryRepository) Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil, ErrEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
 	var entry models.Entry
-	if err := r.db.First(&entry, "id = ?", id).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Preload("Tags", orderTagsByName).First(&entry, "id = ? AND user_id = ?", entryID, userID).Error; err != nil {
+		return nil, notFound(err, ErrEntryNotFound)
 	}
 	return &entry, nil
 }
 
-func (r *entryRepository) Update(entry *models.Entry) error {
-	return r.db.Save(entry).Error
+// Набор тегов записи заменяется целиком на entry.Tags.
+// Изменение заголовка или текста сохраняется новой ревизией.
+// entry.Version должна совпадать с сохраненной версией, иначе возвращается
+// ErrVersionConflict; после обновления версия увеличивается.
+// Запись другого пользователя (entry.UserID) не изменяется.
+func (r *entryRepository) Update(ctx context.Context, entry *models.Entry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkEntryOwner(tx, entry); err != nil {
+			return err
+		}
+		if err := recordRevision(tx, entry); err != nil {
+			return err
+		}
+		tags, err := resolveTags(tx, entry.UserID, entry.Tags)
+		if err != nil {
+			return err
+		}
+
+		expected := entry.Version
+		entry.Version = expected + 1
+		result := tx.Model(entry).
+			Where("user_id = ? AND version = ?", entry.UserID, expected).
+			Select("*").Omit("Tags").
+			Updates(entry)
+		if result.Error != nil {
+			entry.Version = expected
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			entry.Version = expected
+			var count int64
+			if err := tx.Unscoped().Model(&models.Entry{}).Where("id = ?", entry.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVersionConflict
+			}
+			// Как и Save, создаем запись, если ее еще нет
+			entry.Version = 1
+			if err := tx.Omit("Tags").Create(entry).Error; err != nil {
+				return err
+			}
+		}
+		entry.Tags = tags
+		return tx.Model(entry).Omit("Tags.*").Association("Tags").Replace(tags)
+	})
 }
 
-func (r *entryRepository) Delete(id string) error {
-	return r.db.Delete(&models.Entry{}, "id = ?", id).Error
+// Перемещает запись в корзину; теги сохраняются до окончательного удаления
+func (r *entryRepository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil
+	}
+	return r.db.WithContext(ctx).Delete(&models.Entry{}, "id = ? AND user_id = ?", entryID, userID).Error
 }
 
-func (r *entryRepository) List() ([]*models.Entry, error) {
+func (r *entryRepository) List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
 	var entries []*models.Entry
-	if err := r.db.Order("created_at DESC").Find(&entries).Error; err != nil {
+	err := r.db.WithContext(ctx).
+		Preload("Tags", orderTagsByName).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	if err != nil {
 		return nil, err
 	}
 	return entries, nil
 }
+
+// Постраничный список записей одного пользователя с фильтрами и сортировкой.
+// Возвращает курсор следующей страницы или пустую строку, если страница последняя.
+func (r *entryRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	filter = normalizeFilter(filter)
+
+	after, err := decodeCursor(filter.Cursor, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+
+	// Фильтры; время хранится в UTC, поэтому границы приводим к UTC
+	if filter.From != nil {
+		query = query.Where("entry_date >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("entry_date < ?", filter.To.UTC())
+	}
+	if filter.Title != "" {
+		query = query.Where("LOWER(title) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(filter.Title))+"%")
+	}
+	if len(filter.Tags) > 0 {
+		query = query.Where("id IN (?)", taggedEntryIDs(r.db.WithContext(ctx), userID, filter.Tags, filter.TagMatch))
+	}
+
+	// Сортировка и keyset-пагинация; имя колонки берется только из белого списка
+	column := string(filter.SortBy)
+	direction, cmp := "DESC", "<"
+	if filter.SortOrder == models.SortAsc {
+		direction, cmp = "ASC", ">"
+	}
+	if after != nil {
+		value, err := after.sortValue()
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where(
+			fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", column, cmp),
+			value, value, after.ID,
+		)
+	}
+	query = query.Order(column + " " + direction).Order("id " + direction)
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	var entries []*models.Entry
+	if err := query.Preload("Tags", orderTagsByName).Limit(filter.Limit + 1).Find(&entries).Error; err != nil {
+		return nil, "", err
+	}
+
+	if len(entries) <= filter.Limit {
+		return entries, "", nil
+	}
+
+	entries = entries[:filter.Limit]
+	return entries, encodeCursor(newEntryCursor(filter, entries[filter.Limit-1])), nil
+}
+
+// Подзапрос id записей пользователя с указанными тегами
+func taggedEntryIDs(db *gorm.DB, userID uuid.UUID, tags []string, match models.TagMatch) *gorm.DB {
+	sub := db.Table("entry_tags").
+		Select("entry_tags.entry_id").
+		Joins("JOIN tags ON tags.id = entry_tags.tag_id").
+		Where("tags.user_id = ? AND tags.name IN ?", userID, tags)
+	if match == models.TagMatchAll {
+		// Повторы в фильтре не должны требовать больше тегов, чем в нем разных имен
+		distinct := make(map[string]bool, len(tags))
+		for _, name := range tags {
+			distinct[name] = true
+		}
+		sub = sub.Group("entry_tags.entry_id").Having("COUNT(DISTINCT tags.id) = ?", len(distinct))
+	}
+	return sub
+}
+
+func orderTagsByName(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name")
+}
+
+// Подставляет значения по умолчанию для сортировки и размера страницы
+func normalizeFilter(filter models.EntryFilter) models.EntryFilter {
+	if !filter.SortBy.Valid() {
+		filter.SortBy = models.SortByEntryDate
+	}
+	if !filter.SortOrder.Valid() {
+		filter.SortOrder = models.SortDesc
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultPageSize
+	}
+	if !filter.TagMatch.Valid() {
+		filter.TagMatch = models.TagMatchAny
+	}
+	return filter
+}
+
+// ID из запроса. Строка, не являющаяся UUID, не совпадает ни с одной
+// записью; до базы она не передается, потому что PostgreSQL отвергает ее ошибкой.
+func parseID(id string) (uuid.UUID, bool) {
+	parsed, err := uuid.Parse(id)
+	return parsed, err == nil
+}
+
+// Экранирует спецсимволы LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// Запись с тем же ID, принадлежащая другому пользователю (в том числе
+// удаленная), не должна ни изменяться, ни перезаписываться
+func checkEntryOwner(tx *gorm.DB, entry *models.Entry) error {
+	var owners []uuid.UUID
+	err := tx.Unscoped().Model(&models.Entry{}).
+		Where("id = ?", entry.ID).
+		Pluck("user_id", &owners).Error
+	if err != nil {
+		return err
+	}
+	if len(owners) > 0 && owners[0] != entry.UserID {
+		return ErrEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
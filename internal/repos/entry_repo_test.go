@@ -1,6 +1,9 @@
 package repos
 
 import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/errs"
 	"diary/internal/models"
 	"testing"
 	"time"
@@ -14,18 +17,14 @@ import (
 
 type EntryRepositoryTestSuite struct {
 	suite.Suite
-	db   *gorm.DB
-	repo EntryRepository
+	backend dbtest.Backend
+	db      *gorm.DB
+	repo    EntryRepository
 }
 
 func (suite *EntryRepositoryTestSuite) SetupSuite() {
-	// Создаем in-memory SQLite базу данных для тестов
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	suite.Require().NoError(err)
-
-	// Автомиграция
-	err = db.AutoMigrate(&models.Entry{})
-	suite.Require().NoError(err)
+	// Одна база на весь набор; таблица очищается после каждого теста
+	db := openTestDB(suite.T(), suite.backend)
 
 	suite.db = db
 	suite.repo = NewEntryRepository(db)
@@ -47,7 +46,7 @@ func (suite *EntryRepositoryTestSuite) TestCreate() {
 	}
 
 	// Act
-	err := suite.repo.Create(entry)
+	err := suite.repo.Create(context.Background(), entry)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -74,7 +73,7 @@ func (suite *EntryRepositoryTestSuite) TestCreateWithEmptyTitle() {
 	// Act & Assert
 	// Поскольку в модели поле title помечено как not null,
 	// создание записи с пустым title может завершиться ошибкой в зависимости от настроек БД
-	err := suite.repo.Create(entry)
+	err := suite.repo.Create(context.Background(), entry)
 	// В SQLite пустая строка допустима, но в реальной БД может быть ограничение
 	assert.NoError(suite.T(), err)
 }
@@ -91,7 +90,7 @@ func (suite *EntryRepositoryTestSuite) TestRead() {
 	suite.Require().NoError(err)
 
 	// Act
-	foundEntry, err := suite.repo.Read(entry.ID.String())
+	foundEntry, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -107,12 +106,31 @@ func (suite *EntryRepositoryTestSuite) TestReadNotFound() {
 	nonExistentID := uuid.New().String()
 
 	// Act
-	foundEntry, err := suite.repo.Read(nonExistentID)
+	foundEntry, err := suite.repo.Read(context.Background(), uuid.New(), nonExistentID)
 
 	// Assert
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), foundEntry)
-	assert.Equal(suite.T(), gorm.ErrRecordNotFound, err)
+	assert.ErrorIs(suite.T(), err, ErrEntryNotFound)
+	assert.ErrorIs(suite.T(), err, errs.ErrNotFound)
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+}
+
+func (suite *EntryRepositoryTestSuite) TestCanceledContext() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title", Content: "Content"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	createErr := suite.repo.Create(ctx, entry)
+	_, _, listErr := suite.repo.ListByUser(ctx, entry.UserID, models.EntryFilter{})
+
+	// Assert - отмененный контекст прерывает запрос, запись не создается
+	assert.ErrorIs(suite.T(), createErr, context.Canceled)
+	assert.ErrorIs(suite.T(), listErr, context.Canceled)
+	_, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	assert.ErrorIs(suite.T(), err, ErrEntryNotFound)
 }
 
 func (suite *EntryRepositoryTestSuite) TestReadInvalidID() {
@@ -120,7 +138,7 @@ func (suite *EntryRepositoryTestSuite) TestReadInvalidID() {
 	invalidID := "invalid-uuid"
 
 	// Act
-	foundEntry, err := suite.repo.Read(invalidID)
+	foundEntry, err := suite.repo.Read(context.Background(), uuid.New(), invalidID)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -143,7 +161,7 @@ func (suite *EntryRepositoryTestSuite) TestUpdate() {
 	entry.Content = "Updated Content"
 
 	// Act
-	err = suite.repo.Update(entry)
+	err = suite.repo.Update(context.Background(), entry)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -166,7 +184,7 @@ func (suite *EntryRepositoryTestSuite) TestUpdateNonExistent() {
 	}
 
 	// Act - пытаемся обновить несуществующую запись
-	err := suite.repo.Update(entry)
+	err := suite.repo.Update(context.Background(), entry)
 
 	// Assert
 	// GORM создаст новую запись если ID не найден при Save()
@@ -190,7 +208,7 @@ func (suite *EntryRepositoryTestSuite) TestDelete() {
 	suite.Require().NoError(err)
 
 	// Act
-	err = suite.repo.Delete(entry.ID.String())
+	err = suite.repo.Delete(context.Background(), entry.UserID, entry.ID.String())
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -207,7 +225,7 @@ func (suite *EntryRepositoryTestSuite) TestDeleteNonExistent() {
 	nonExistentID := uuid.New().String()
 
 	// Act
-	err := suite.repo.Delete(nonExistentID)
+	err := suite.repo.Delete(context.Background(), uuid.New(), nonExistentID)
 
 	// Assert
 	// GORM не возвращает ошибку при удалении несуществующей записи
@@ -219,7 +237,7 @@ func (suite *EntryRepositoryTestSuite) TestDeleteInvalidID() {
 	invalidID := "invalid-uuid"
 
 	// Act
-	err := suite.repo.Delete(invalidID)
+	err := suite.repo.Delete(context.Background(), uuid.New(), invalidID)
 
 	// Assert
 	// GORM обработает недопустимый ID без ошибки
@@ -227,29 +245,37 @@ func (suite *EntryRepositoryTestSuite) TestDeleteInvalidID() {
 }
 
 func (suite *EntryRepositoryTestSuite) TestList() {
-	// Arrange - создаем несколько тестовых записей
+	// Arrange - создаем несколько тестовых записей и запись другого пользователя
+	userID := uuid.New()
 	entries := []*models.Entry{
 		{
 			ID:        uuid.New(),
-			UserID:    uuid.New(),
+			UserID:    userID,
 			Title:     "First Entry",
 			Content:   "First Content",
 			CreatedAt: time.Now().Add(-2 * time.Hour),
 		},
 		{
 			ID:        uuid.New(),
-			UserID:    uuid.New(),
+			UserID:    userID,
 			Title:     "Second Entry",
 			Content:   "Second Content",
 			CreatedAt: time.Now().Add(-1 * time.Hour),
 		},
 		{
 			ID:        uuid.New(),
-			UserID:    uuid.New(),
+			UserID:    userID,
 			Title:     "Third Entry",
 			Content:   "Third Content",
 			CreatedAt: time.Now(),
 		},
+		{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Title:     "Foreign Entry",
+			Content:   "Foreign Content",
+			CreatedAt: time.Now(),
+		},
 	}
 
 	for _, entry := range entries {
@@ -258,7 +284,7 @@ func (suite *EntryRepositoryTestSuite) TestList() {
 	}
 
 	// Act
-	result, err := suite.repo.List()
+	result, err := suite.repo.List(context.Background(), userID)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -272,7 +298,7 @@ func (suite *EntryRepositoryTestSuite) TestList() {
 
 func (suite *EntryRepositoryTestSuite) TestListEmpty() {
 	// Act
-	result, err := suite.repo.List()
+	result, err := suite.repo.List(context.Background(), uuid.New())
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -299,12 +325,12 @@ func (suite *EntryRepositoryTestSuite) TestCreateMultipleEntries() {
 
 	// Act
 	for _, entry := range entries {
-		err := suite.repo.Create(entry)
+		err := suite.repo.Create(context.Background(), entry)
 		assert.NoError(suite.T(), err)
 	}
 
 	// Assert
-	result, err := suite.repo.List()
+	result, err := suite.repo.List(context.Background(), userID)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), result, 2)
 
@@ -313,8 +339,302 @@ func (suite *EntryRepositoryTestSuite) TestCreateMultipleEntries() {
 	assert.Equal(suite.T(), userID, result[1].UserID)
 }
 
+func (suite *EntryRepositoryTestSuite) TestListByUserOnlyOwnEntries() {
+	// Arrange
+	userID := uuid.New()
+	otherUserID := uuid.New()
+	entries := []*models.Entry{
+		{ID: uuid.New(), UserID: userID, Title: "Mine", Content: "Content"},
+		{ID: uuid.New(), UserID: otherUserID, Title: "Foreign", Content: "Content"},
+	}
+	for _, entry := range entries {
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+
+	// Act
+	result, next, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{Limit: 10})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), next)
+	assert.Len(suite.T(), result, 1)
+	assert.Equal(suite.T(), "Mine", result[0].Title)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserPagination() {
+	// Arrange - пять записей с разным временем и две с одинаковым
+	userID := uuid.New()
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		entry := &models.Entry{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Title:     "Entry",
+			Content:   "Content",
+			EntryDate: base.Add(time.Duration(i) * time.Minute),
+		}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+	for i := 0; i < 2; i++ {
+		entry := &models.Entry{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Title:     "Same time",
+			Content:   "Content",
+			EntryDate: base,
+		}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+
+	// Act - проходим все страницы по 3 записи
+	var all []*models.Entry
+	cursor := ""
+	pages := 0
+	for {
+		page, next, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{Cursor: cursor, Limit: 3})
+		suite.Require().NoError(err)
+		all = append(all, page...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	// Assert - без пропусков и повторов, от новых к старым по дате записи
+	assert.Equal(suite.T(), 3, pages)
+	assert.Len(suite.T(), all, 7)
+	seen := make(map[uuid.UUID]bool)
+	for i, entry := range all {
+		assert.False(suite.T(), seen[entry.ID], "duplicate entry on page boundary")
+		seen[entry.ID] = true
+		if i > 0 {
+			assert.False(suite.T(), entry.EntryDate.After(all[i-1].EntryDate))
+		}
+	}
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserSortByCreatedAt() {
+	// Arrange - запись, написанная позже, может относиться к более ранней дате
+	userID := uuid.New()
+	now := time.Now().UTC()
+	backdated := &models.Entry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     "Yesterday",
+		Content:   "Content",
+		CreatedAt: now,
+		EntryDate: now.AddDate(0, 0, -1),
+	}
+	regular := &models.Entry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     "Today",
+		Content:   "Content",
+		CreatedAt: now.Add(-time.Hour),
+		EntryDate: now.Add(-time.Hour),
+	}
+	suite.Require().NoError(suite.db.Create(backdated).Error)
+	suite.Require().NoError(suite.db.Create(regular).Error)
+
+	// Act
+	byEntryDate, _, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{})
+	suite.Require().NoError(err)
+	byCreatedAt, _, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{SortBy: models.SortByCreatedAt})
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Equal(suite.T(), "Today", byEntryDate[0].Title)
+	assert.Equal(suite.T(), "Yesterday", byCreatedAt[0].Title)
+}
+
+func (suite *EntryRepositoryTestSuite) TestUpdateSetsUpdatedAt() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title", Content: "Content"}
+	suite.Require().NoError(suite.db.Create(entry).Error)
+	createdUpdatedAt := entry.UpdatedAt
+
+	// Act
+	time.Sleep(10 * time.Millisecond)
+	entry.Title = "Changed"
+	err := suite.repo.Update(context.Background(), entry)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	found, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.True(suite.T(), found.UpdatedAt.After(createdUpdatedAt))
+	assert.Equal(suite.T(), entry.CreatedAt.Unix(), found.CreatedAt.Unix())
+}
+
+func (suite *EntryRepositoryTestSuite) TestUpdateIncrementsVersion() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title", Content: "Content"}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	assert.Equal(suite.T(), 1, entry.Version)
+
+	// Act
+	entry.Title = "Changed"
+	err := suite.repo.Update(context.Background(), entry)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, entry.Version)
+	found, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, found.Version)
+}
+
+func (suite *EntryRepositoryTestSuite) TestUpdateVersionConflict() {
+	// Arrange - два клиента прочитали одну и ту же версию
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title", Content: "Content"}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	first, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	second, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+
+	// Act
+	first.Content = "From first device"
+	suite.Require().NoError(suite.repo.Update(context.Background(), first))
+	second.Content = "From second device"
+	err = suite.repo.Update(context.Background(), second)
+
+	// Assert - второе изменение не перезаписывает первое
+	assert.ErrorIs(suite.T(), err, ErrVersionConflict)
+	assert.Equal(suite.T(), 1, second.Version)
+	found, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "From first device", found.Content)
+}
+
+func (suite *EntryRepositoryTestSuite) TestForeignEntryHidden() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title", Content: "Content"}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	stranger := uuid.New()
+
+	// Act
+	_, readErr := suite.repo.Read(context.Background(), stranger, entry.ID.String())
+	deleteErr := suite.repo.Delete(context.Background(), stranger, entry.ID.String())
+	forged := &models.Entry{ID: entry.ID, UserID: stranger, Title: "Forged", Content: "Forged", Version: 1}
+	updateErr := suite.repo.Update(context.Background(), forged)
+
+	// Assert - чужая запись не читается, не удаляется и не перезаписывается
+	assert.ErrorIs(suite.T(), readErr, ErrEntryNotFound)
+	assert.NoError(suite.T(), deleteErr)
+	assert.ErrorIs(suite.T(), updateErr, ErrEntryNotFound)
+	found, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Title", found.Title)
+	assert.Equal(suite.T(), 1, found.Version)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserInvalidCursor() {
+	// Act
+	result, next, err := suite.repo.ListByUser(context.Background(), uuid.New(), models.EntryFilter{Cursor: "not-a-cursor", Limit: 10})
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrInvalidCursor)
+	assert.Nil(suite.T(), result)
+	assert.Empty(suite.T(), next)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserDateRange() {
+	// Arrange
+	userID := uuid.New()
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Before", "Inside", "After"} {
+		entry := &models.Entry{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Title:     title,
+			Content:   "Content",
+			EntryDate: day.AddDate(0, 0, i-1).Add(12 * time.Hour),
+		}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+	from := day
+	to := day.AddDate(0, 0, 1)
+
+	// Act
+	result, _, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{From: &from, To: &to})
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result, 1)
+	assert.Equal(suite.T(), "Inside", result[0].Title)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserTitleFilter() {
+	// Arrange
+	userID := uuid.New()
+	for _, title := range []string{"Morning Walk", "evening walk", "100% done", "Lunch"} {
+		entry := &models.Entry{ID: uuid.New(), UserID: userID, Title: title, Content: "Content"}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+
+	// Act
+	walks, _, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{Title: "WALK"})
+	suite.Require().NoError(err)
+	percent, _, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{Title: "%"})
+	suite.Require().NoError(err)
+
+	// Assert - поиск без учета регистра, спецсимволы LIKE экранируются
+	assert.Len(suite.T(), walks, 2)
+	assert.Len(suite.T(), percent, 1)
+	assert.Equal(suite.T(), "100% done", percent[0].Title)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserSortByTitle() {
+	// Arrange
+	userID := uuid.New()
+	for _, title := range []string{"Charlie", "Alpha", "Delta", "Bravo", "Alpha"} {
+		entry := &models.Entry{ID: uuid.New(), UserID: userID, Title: title, Content: "Content"}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+	filter := models.EntryFilter{SortBy: models.SortByTitle, SortOrder: models.SortAsc, Limit: 2}
+
+	// Act - проходим все страницы
+	var titles []string
+	for {
+		page, next, err := suite.repo.ListByUser(context.Background(), userID, filter)
+		suite.Require().NoError(err)
+		for _, entry := range page {
+			titles = append(titles, entry.Title)
+		}
+		if next == "" {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	// Assert
+	assert.Equal(suite.T(), []string{"Alpha", "Alpha", "Bravo", "Charlie", "Delta"}, titles)
+}
+
+func (suite *EntryRepositoryTestSuite) TestListByUserCursorFromOtherSort() {
+	// Arrange
+	userID := uuid.New()
+	for i := 0; i < 3; i++ {
+		entry := &models.Entry{ID: uuid.New(), UserID: userID, Title: "Entry", Content: "Content"}
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+	_, next, err := suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{Limit: 1})
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(next)
+
+	// Act - курсор, выданный для created_at, нельзя использовать с сортировкой по title
+	_, _, err = suite.repo.ListByUser(context.Background(), userID, models.EntryFilter{SortBy: models.SortByTitle, Cursor: next, Limit: 1})
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrInvalidCursor)
+}
+
 func TestEntryRepositoryTestSuite(t *testing.T) {
-	suite.Run(t, new(EntryRepositoryTestSuite))
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &EntryRepositoryTestSuite{backend: backend})
+	})
 }
 
 // Дополнительные единичные тесты для специфических случаев
@@ -348,6 +668,6 @@ func TestEntryRepositoryWithNilDB(t *testing.T) {
 	}
 
 	assert.Panics(t, func() {
-		repo.Create(entry)
+		repo.Create(context.Background(), entry)
 	})
 }
@@ -0,0 +1,287 @@
+package repos
+
+import (
+	"diary/internal/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Выборки без SQL ---
+
+// Хранилища без базы данных (файлы, память) отбирают и сортируют записи
+// по их краткому описанию так же, как запросы entryRepository.
+
+// Краткое описание записи: все, что нужно для фильтров, сортировки и
+// статистики. В файловом хранилище это строка индекса, Path - файл записи
+// относительно каталога пользователя.
+type entrySummary struct {
+	Path      string     `json:"path"`
+	Title     string     `json:"title"`
+	EntryDate time.Time  `json:"entry_date"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Tags      []string   `json:"tags"`
+	Mood      *int       `json:"mood,omitempty"`
+	Energy    *int       `json:"energy,omitempty"`
+	Encrypted bool       `json:"encrypted,omitempty"`
+}
+
+func newEntrySummary(entry *models.Entry, relPath string) *entrySummary {
+	item := &entrySummary{
+		Path:      relPath,
+		Title:     entry.Title,
+		EntryDate: entry.EntryDate.UTC(),
+		CreatedAt: entry.CreatedAt.UTC(),
+		UpdatedAt: entry.UpdatedAt.UTC(),
+		Tags:      tagNames(entry.Tags),
+		Mood:      entry.Mood,
+		Energy:    entry.Energy,
+		Encrypted: entry.Encrypted(),
+	}
+	if entry.DeletedAt.Valid {
+		deletedAt := entry.DeletedAt.Time.UTC()
+		item.DeletedAt = &deletedAt
+	}
+	return item
+}
+
+// ID записей, отобранных keep, в порядке less
+func selectIDs(items map[uuid.UUID]*entrySummary, keep func(item *entrySummary) bool, less func(a, b *entrySummary) bool) []uuid.UUID {
+	var ids []uuid.UUID
+	for id, item := range items {
+		if keep(item) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := items[ids[i]], items[ids[j]]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
+
+func isActive(item *entrySummary) bool {
+	return item.DeletedAt == nil
+}
+
+func isTrashed(item *entrySummary) bool {
+	return item.DeletedAt != nil
+}
+
+// Поиск не видит зашифрованные записи: их текста у сервера нет
+func isSearchable(item *entrySummary) bool {
+	return isActive(item) && !item.Encrypted
+}
+
+// Фильтры ListByUser: диапазон дат, подстрока заголовка, теги
+func matchesFilter(item *entrySummary, filter models.EntryFilter) bool {
+	if filter.From != nil && item.EntryDate.Before(filter.From.UTC()) {
+		return false
+	}
+	if filter.To != nil && !item.EntryDate.Before(filter.To.UTC()) {
+		return false
+	}
+	if filter.Title != "" && !strings.Contains(strings.ToLower(item.Title), strings.ToLower(filter.Title)) {
+		return false
+	}
+	if len(filter.Tags) > 0 {
+		matched := 0
+		for _, name := range filter.Tags {
+			if hasTag(item, name) {
+				matched++
+			}
+		}
+		if matched == 0 || (filter.TagMatch == models.TagMatchAll && matched < len(filter.Tags)) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTag(item *entrySummary, name string) bool {
+	for _, tag := range item.Tags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortValue(item *entrySummary, field models.EntrySortField) interface{} {
+	switch field {
+	case models.SortByTitle:
+		return item.Title
+	case models.SortByCreatedAt:
+		return item.CreatedAt
+	case models.SortByUpdatedAt:
+		return item.UpdatedAt
+	default:
+		return item.EntryDate
+	}
+}
+
+// Сравнивает значение сортируемого поля записи с value того же типа
+func compareSortValue(item *entrySummary, field models.EntrySortField, value interface{}) int {
+	switch v := sortValue(item, field).(type) {
+	case string:
+		return strings.Compare(v, value.(string))
+	case time.Time:
+		return v.Compare(value.(time.Time))
+	}
+	return 0
+}
+
+// Записи страницы ListByUser по фильтру, в порядке ORDER BY <колонка>, id
+// SQL-хранилища. Возвращает до filter.Limit+1 ID: лишний означает, что
+// есть следующая страница. filter должен быть нормализован.
+func pageIDs(items map[uuid.UUID]*entrySummary, filter models.EntryFilter, after *entryCursor) ([]uuid.UUID, error) {
+	var afterValue interface{}
+	if after != nil {
+		var err error
+		if afterValue, err = after.sortValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	desc := filter.SortOrder == models.SortDesc
+	var ids []uuid.UUID
+	for id, item := range items {
+		if isActive(item) && matchesFilter(item, filter) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		c := compareSortValue(items[ids[i]], filter.SortBy, sortValue(items[ids[j]], filter.SortBy))
+		if c == 0 {
+			c = strings.Compare(ids[i].String(), ids[j].String())
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	// Keyset-пагинация: пропускаем записи до курсора включительно
+	if after != nil {
+		start := len(ids)
+		for i, id := range ids {
+			c := compareSortValue(items[id], filter.SortBy, afterValue)
+			if c == 0 {
+				c = strings.Compare(id.String(), after.ID.String())
+			}
+			if (desc && c < 0) || (!desc && c > 0) {
+				start = i
+				break
+			}
+		}
+		ids = ids[start:]
+	}
+	if len(ids) > filter.Limit+1 {
+		ids = ids[:filter.Limit+1]
+	}
+	return ids, nil
+}
+
+// Обрезает записи, полученные по pageIDs, до страницы и выдает курсор следующей
+func pageResult(entries []*models.Entry, filter models.EntryFilter) ([]*models.Entry, string) {
+	if len(entries) <= filter.Limit {
+		return entries, ""
+	}
+	entries = entries[:filter.Limit]
+	return entries, encodeCursor(newEntryCursor(filter, entries[filter.Limit-1]))
+}
+
+// --- Теги и статистика ---
+
+// У тегов вне базы нет собственных ID: ID выводится из пользователя и имени,
+// поэтому один и тот же тег всегда получает один и тот же ID
+func namedTag(userID uuid.UUID, name string) models.Tag {
+	return models.Tag{ID: uuid.NewSHA1(userID, []byte(name)), UserID: userID, Name: name}
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Теги записи: имена без повторов, по алфавиту, с ID из имени
+func namedTags(userID uuid.UUID, tags []models.Tag) []models.Tag {
+	names := tagNames(tags)
+	result := make([]models.Tag, 0, len(names))
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		result = append(result, namedTag(userID, name))
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Тег существует, пока он есть хотя бы у одной записи, включая корзину
+func tagExists(items map[uuid.UUID]*entrySummary, name string) bool {
+	for _, item := range items {
+		if hasTag(item, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Количество активных записей с каждым тегом, по убыванию
+func countTags(items map[uuid.UUID]*entrySummary) []*models.TagCount {
+	byName := map[string]int64{}
+	for _, item := range items {
+		if !isActive(item) {
+			continue
+		}
+		for _, name := range item.Tags {
+			byName[name]++
+		}
+	}
+	var counts []*models.TagCount
+	for name, count := range byName {
+		counts = append(counts, &models.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// Настроение и энергия активных записей за [from, to), по дате записи
+func moodPoints(items map[uuid.UUID]*entrySummary, from, to time.Time) []*models.MoodPoint {
+	keep := func(item *entrySummary) bool {
+		return isActive(item) &&
+			(item.Mood != nil || item.Energy != nil) &&
+			!item.EntryDate.Before(from.UTC()) && item.EntryDate.Before(to.UTC())
+	}
+	ids := selectIDs(items, keep, func(a, b *entrySummary) bool {
+		return a.EntryDate.Before(b.EntryDate)
+	})
+	var points []*models.MoodPoint
+	for _, id := range ids {
+		item := items[id]
+		points = append(points, &models.MoodPoint{EntryDate: item.EntryDate, Mood: item.Mood, Energy: item.Energy})
+	}
+	return points
+}
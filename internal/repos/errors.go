@@ -0,0 +1,26 @@
+package repos
+
+import (
+	"diary/internal/errs"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Заменяет gorm.ErrRecordNotFound типизированной ошибкой; исходная ошибка
+// остается в цепочке. Остальные ошибки возвращаются без изменений.
+func notFound(err error, target *errs.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return target.Wrap(err)
+	}
+	return err
+}
+
+// Заменяет нарушение уникального ключа (gorm.ErrDuplicatedKey) типизированной
+// ошибкой. Остальные ошибки возвращаются без изменений.
+func conflict(err error, target *errs.Error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return target.Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package repos
+
+// Без flock каталог защищен только блокировками внутри процесса
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package repos
+
+import (
+	"os"
+	"syscall"
+)
+
+// Блокировка flock на файле: разделяемая для чтения, исключительная для
+// изменений. Защищает каталог от одновременной работы нескольких процессов.
+func lockFile(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,806 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- File Repository Implementation ---
+
+// Хранит записи Markdown-файлами с YAML front matter вместо базы данных
+// (раскладка каталога описана в file_store.go). Реализует Repository
+// целиком, поэтому может заменить SQL-хранилище при запуске. Поиск
+// работает как упрощенный режим SQLite без FTS5: термы ищутся подстрокой.
+type fileRepository struct {
+	store *fileStore
+	now   func() time.Time
+}
+
+// NewFileRepository создает хранилище в каталоге root (каталог создается,
+// если его нет)
+func NewFileRepository(root string) (Repository, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+	return &fileRepository{
+		store: &fileStore{root: root},
+		now:   func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// --- Блокировки и индекс ---
+
+// Выполняет fn с индексом пользователя под разделяемой блокировкой
+func (r *fileRepository) view(ctx context.Context, userID uuid.UUID, fn func(index *fileIndex) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := r.store.lock(userID, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = r.withIndex(userID, fn)
+	return err
+}
+
+// Выполняет fn под исключительной блокировкой и сохраняет измененный индекс.
+// Если fn завершился ошибкой, часть файлов могла уже измениться: индекс
+// удаляется и при следующем обращении перестраивается по файлам.
+func (r *fileRepository) update(ctx context.Context, userID uuid.UUID, fn func(index *fileIndex) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := r.store.lock(userID, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index, err := r.withIndex(userID, fn)
+	if err != nil {
+		if !errors.Is(err, errs.ErrNotFound) && !errors.Is(err, errs.ErrConflict) {
+			os.Remove(filepath.Join(r.store.userDir(userID), fileIndexName))
+		}
+		return err
+	}
+	return r.store.saveIndex(userID, index)
+}
+
+// Выполняет fn с индексом пользователя. Если индекс ссылается на пропавший
+// файл (записи изменили в обход хранилища или сбой случился между записью
+// файла и индекса), индекс перестраивается по файлам и fn выполняется еще раз.
+func (r *fileRepository) withIndex(userID uuid.UUID, fn func(index *fileIndex) error) (*fileIndex, error) {
+	index, err := r.store.loadIndex(userID)
+	if err != nil {
+		return nil, err
+	}
+	err = fn(index)
+	if errors.Is(err, fs.ErrNotExist) {
+		if index, err = r.store.rebuildIndex(userID); err != nil {
+			return nil, err
+		}
+		err = fn(index)
+	}
+	return index, err
+}
+
+// Читает записи по индексу в заданном порядке
+func (r *fileRepository) readEntries(userID uuid.UUID, index *fileIndex, ids []uuid.UUID) ([]*models.Entry, error) {
+	entries := make([]*models.Entry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := r.store.readEntry(userID, index.Entries[id].Path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Пишет файл записи и обновляет строку индекса
+func (r *fileRepository) saveEntry(index *fileIndex, entry *models.Entry) error {
+	oldPath := ""
+	if item, ok := index.Entries[entry.ID]; ok {
+		oldPath = item.Path
+	}
+	relPath, err := r.store.writeEntry(entry, oldPath)
+	if err != nil {
+		return err
+	}
+	index.Entries[entry.ID] = newEntrySummary(entry, relPath)
+	return nil
+}
+
+// --- CRUD Entry ---
+
+func (r *fileRepository) Create(ctx context.Context, entry *models.Entry) error {
+	return r.update(ctx, entry.UserID, func(index *fileIndex) error {
+		if _, ok := index.Entries[entry.ID]; ok {
+			return errEntryExists
+		}
+		foreign, err := r.store.ownedByOther(entry.UserID, entry.ID)
+		if err != nil {
+			return err
+		}
+		if foreign {
+			return errEntryExists
+		}
+		return r.createEntry(index, entry)
+	})
+}
+
+func (r *fileRepository) createEntry(index *fileIndex, entry *models.Entry) error {
+	now := r.now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = now
+	}
+	entry.Version = 1
+	entry.Tags = namedTags(entry.UserID, entry.Tags)
+	if err := r.saveEntry(index, entry); err != nil {
+		return err
+	}
+	_, err := r.addRevision(entry.UserID, nil, entry.ID, entry.Title, entry.Content)
+	return err
+}
+
+func (r *fileRepository) Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+	var entry *models.Entry
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		item, ok := index.Entries[entryID]
+		if !ok || !isActive(item) {
+			return ErrEntryNotFound
+		}
+		var err error
+		entry, err = r.store.readEntry(userID, item.Path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Семантика совпадает с SQL-хранилищем: набор тегов заменяется целиком,
+// изменение заголовка или текста сохраняется ревизией, версия проверяется
+// и увеличивается, отсутствующая запись создается
+func (r *fileRepository) Update(ctx context.Context, entry *models.Entry) error {
+	return r.update(ctx, entry.UserID, func(index *fileIndex) error {
+		item, ok := index.Entries[entry.ID]
+		if !ok {
+			// Запись с этим идентификатором у другого пользователя не перезаписывается
+			foreign, err := r.store.ownedByOther(entry.UserID, entry.ID)
+			if err != nil {
+				return err
+			}
+			if foreign {
+				return ErrEntryNotFound
+			}
+			return r.createEntry(index, entry)
+		}
+		if !isActive(item) {
+			return ErrVersionConflict
+		}
+		stored, err := r.store.readEntry(entry.UserID, item.Path)
+		if err != nil {
+			return err
+		}
+		if stored.Version != entry.Version {
+			return ErrVersionConflict
+		}
+		if err := r.recordRevision(stored, entry); err != nil {
+			return err
+		}
+
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = stored.CreatedAt
+		}
+		entry.UpdatedAt = r.now()
+		entry.DeletedAt = stored.DeletedAt
+		entry.Version = stored.Version + 1
+		entry.Tags = namedTags(entry.UserID, entry.Tags)
+		if err := r.saveEntry(index, entry); err != nil {
+			entry.Version = stored.Version
+			return err
+		}
+		return nil
+	})
+}
+
+// Перемещает запись в корзину
+func (r *fileRepository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil
+	}
+	return r.update(ctx, userID, func(index *fileIndex) error {
+		item, ok := index.Entries[entryID]
+		if !ok || !isActive(item) {
+			return nil
+		}
+		entry, err := r.store.readEntry(userID, item.Path)
+		if err != nil {
+			return err
+		}
+		entry.DeletedAt.Time = r.now()
+		entry.DeletedAt.Valid = true
+		return r.saveEntry(index, entry)
+	})
+}
+
+func (r *fileRepository) List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	var entries []*models.Entry
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		ids := selectIDs(index.Entries, isActive, func(a, b *entrySummary) bool {
+			return a.CreatedAt.After(b.CreatedAt)
+		})
+		var err error
+		entries, err = r.readEntries(userID, index, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *fileRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	filter = normalizeFilter(filter)
+	after, err := decodeCursor(filter.Cursor, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var entries []*models.Entry
+	err = r.view(ctx, userID, func(index *fileIndex) error {
+		ids, err := pageIDs(index.Entries, filter, after)
+		if err != nil {
+			return err
+		}
+		entries, err = r.readEntries(userID, index, ids)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next := pageResult(entries, filter)
+	return entries, next, nil
+}
+
+// --- Search ---
+
+func (r *fileRepository) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	parsed := parseSearchQuery(query)
+	if len(parsed) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	var matched []*models.Entry
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		ids := selectIDs(index.Entries, isSearchable, func(a, b *entrySummary) bool {
+			return a.EntryDate.After(b.EntryDate)
+		})
+		for _, id := range ids {
+			entry, err := r.store.readEntry(userID, index.Entries[id].Path)
+			if err != nil {
+				return err
+			}
+			if parsed.matches(entry.Title, entry.Content) {
+				matched = append(matched, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankResults(matched, parsed, limit), nil
+}
+
+// --- Tags ---
+
+func (r *fileRepository) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	var counts []*models.TagCount
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		counts = countTags(index.Entries)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Переименовывает тег во всех записях, в том числе в корзине. Если тег
+// с новым именем уже есть у записи, теги сливаются.
+func (r *fileRepository) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	return r.update(ctx, userID, func(index *fileIndex) error {
+		if !tagExists(index.Entries, oldName) {
+			return ErrTagNotFound
+		}
+		if oldName == newName {
+			return nil
+		}
+		return r.replaceTags(userID, index, []string{oldName}, newName)
+	})
+}
+
+func (r *fileRepository) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	return r.update(ctx, userID, func(index *fileIndex) error {
+		var merged []string
+		for _, name := range sources {
+			if name == target {
+				continue
+			}
+			if !tagExists(index.Entries, name) {
+				return ErrTagNotFound
+			}
+			merged = append(merged, name)
+		}
+		return r.replaceTags(userID, index, merged, target)
+	})
+}
+
+// Заменяет теги sources на target во всех записях и увеличивает их версию:
+// представление записи меняется вместе с именем тега
+func (r *fileRepository) replaceTags(userID uuid.UUID, index *fileIndex, sources []string, target string) error {
+	for _, item := range index.Entries {
+		affected := false
+		for _, name := range sources {
+			affected = affected || hasTag(item, name)
+		}
+		if !affected {
+			continue
+		}
+
+		entry, err := r.store.readEntry(userID, item.Path)
+		if err != nil {
+			return err
+		}
+		tags := make([]models.Tag, 0, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			for _, name := range sources {
+				if tag.Name == name {
+					tag.Name = target
+				}
+			}
+			tags = append(tags, tag)
+		}
+		entry.Tags = namedTags(userID, tags)
+		entry.Version++
+		if err := r.saveEntry(index, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- Stats ---
+
+func (r *fileRepository) ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error) {
+	var points []*models.MoodPoint
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		points = moodPoints(index.Entries, from, to)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// --- Trash ---
+
+func (r *fileRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	var entries []*models.Entry
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		ids := selectIDs(index.Entries, isTrashed, func(a, b *entrySummary) bool {
+			return a.DeletedAt.After(*b.DeletedAt)
+		})
+		var err error
+		entries, err = r.readEntries(userID, index, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *fileRepository) ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil, ErrTrashedEntryNotFound
+	}
+	var entry *models.Entry
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		item, ok := index.Entries[entryID]
+		if !ok || !isTrashed(item) {
+			return ErrTrashedEntryNotFound
+		}
+		var err error
+		entry, err = r.store.readEntry(userID, item.Path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *fileRepository) Restore(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return ErrTrashedEntryNotFound
+	}
+	return r.update(ctx, userID, func(index *fileIndex) error {
+		item, ok := index.Entries[entryID]
+		if !ok || !isTrashed(item) {
+			return ErrTrashedEntryNotFound
+		}
+		entry, err := r.store.readEntry(userID, item.Path)
+		if err != nil {
+			return err
+		}
+		entry.DeletedAt.Valid = false
+		entry.DeletedAt.Time = time.Time{}
+		return r.saveEntry(index, entry)
+	})
+}
+
+// Окончательно удаляет файл записи из корзины вместе с ревизиями
+func (r *fileRepository) Purge(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return ErrTrashedEntryNotFound
+	}
+	return r.update(ctx, userID, func(index *fileIndex) error {
+		item, ok := index.Entries[entryID]
+		if !ok || !isTrashed(item) {
+			return ErrTrashedEntryNotFound
+		}
+		return r.purgeEntry(userID, index, entryID)
+	})
+}
+
+// Системная операция: обходит каталоги всех пользователей
+func (r *fileRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	users, err := r.store.users()
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, userID := range users {
+		err := r.update(ctx, userID, func(index *fileIndex) error {
+			for id, item := range index.Entries {
+				if isTrashed(item) && item.DeletedAt.Before(cutoff.UTC()) {
+					if err := r.purgeEntry(userID, index, id); err != nil {
+						return err
+					}
+					purged++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+func (r *fileRepository) purgeEntry(userID uuid.UUID, index *fileIndex, entryID uuid.UUID) error {
+	if err := r.store.removeEntryFile(userID, index.Entries[entryID].Path); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(r.store.revisionDir(userID, entryID)); err != nil {
+		return err
+	}
+	delete(index.Entries, entryID)
+	return nil
+}
+
+// --- Revisions ---
+
+// Ревизии записи, новые первыми. История удаленной записи пуста.
+func (r *fileRepository) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	id, ok := parseID(entryID)
+	if !ok {
+		return nil, nil
+	}
+	var revisions []*models.EntryRevision
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		if item, ok := index.Entries[id]; !ok || !isActive(item) {
+			return nil
+		}
+		var err error
+		revisions, err = r.store.readRevisions(userID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions, nil
+}
+
+func (r *fileRepository) ReadRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	id, ok := parseID(entryID)
+	if !ok {
+		return nil, ErrRevisionNotFound
+	}
+	var revision *models.EntryRevision
+	err := r.view(ctx, userID, func(index *fileIndex) error {
+		if item, ok := index.Entries[id]; !ok || !isActive(item) {
+			return ErrRevisionNotFound
+		}
+		data, err := os.ReadFile(r.store.revisionPath(userID, id, number))
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		revision, err = decodeRevisionFile(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// Добавляет ревизию, если заголовок или текст отличаются от последней.
+// У записей без истории сначала сохраняется прежнее состояние.
+func (r *fileRepository) recordRevision(stored, entry *models.Entry) error {
+	revisions, err := r.store.readRevisions(entry.UserID, entry.ID)
+	if err != nil {
+		return err
+	}
+	var latest *models.EntryRevision
+	if len(revisions) > 0 {
+		latest = revisions[len(revisions)-1]
+	} else if latest, err = r.addRevision(entry.UserID, nil, stored.ID, stored.Title, stored.Content); err != nil {
+		return err
+	}
+	if latest.Title == entry.Title && latest.Content == entry.Content {
+		return nil
+	}
+	_, err = r.addRevision(entry.UserID, latest, entry.ID, entry.Title, entry.Content)
+	return err
+}
+
+// Добавляет ревизию со следующим номером после prev
+func (r *fileRepository) addRevision(userID uuid.UUID, prev *models.EntryRevision, entryID uuid.UUID, title, content string) (*models.EntryRevision, error) {
+	revision := &models.EntryRevision{
+		ID:        uuid.New(),
+		EntryID:   entryID,
+		Number:    1,
+		Title:     title,
+		Content:   content,
+		CreatedAt: r.now(),
+	}
+	if prev != nil {
+		revision.Number = prev.Number + 1
+	}
+	if err := r.store.writeRevision(userID, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// --- Tokens ---
+
+// Выполняет fn с токенами всех пользователей под разделяемой блокировкой
+func (r *fileRepository) viewTokens(ctx context.Context, fn func(tokens map[uuid.UUID]*models.PersonalAccessToken) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := r.store.lockTokens(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tokens, err := r.store.loadTokens()
+	if err != nil {
+		return err
+	}
+	return fn(tokens)
+}
+
+// Выполняет fn под исключительной блокировкой и сохраняет токены
+func (r *fileRepository) updateTokens(ctx context.Context, fn func(tokens map[uuid.UUID]*models.PersonalAccessToken) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := r.store.lockTokens(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tokens, err := r.store.loadTokens()
+	if err != nil {
+		return err
+	}
+	if err := fn(tokens); err != nil {
+		return err
+	}
+	return r.store.saveTokens(tokens)
+}
+
+func (r *fileRepository) CreateToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.updateTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		if tokenConflicts(tokens, token) {
+			return errTokenExists
+		}
+		tokens[token.ID] = prepareToken(token, r.now())
+		return nil
+	})
+}
+
+func (r *fileRepository) ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	var list []*models.PersonalAccessToken
+	err := r.viewTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		for _, token := range tokens {
+			if token.UserID == userID {
+				list = append(list, token)
+			}
+		}
+		return nil
+	})
+	sortTokens(list)
+	return list, err
+}
+
+func (r *fileRepository) ReadTokenByHash(ctx context.Context, secretHash string) (*models.PersonalAccessToken, error) {
+	var found *models.PersonalAccessToken
+	err := r.viewTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		for _, token := range tokens {
+			if token.SecretHash == secretHash {
+				found = token
+				return nil
+			}
+		}
+		return ErrTokenNotFound
+	})
+	return found, err
+}
+
+func (r *fileRepository) DeleteToken(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.updateTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		tokenID, ok := parseID(id)
+		if !ok {
+			return ErrTokenNotFound
+		}
+		token, ok := tokens[tokenID]
+		if !ok || token.UserID != userID {
+			return ErrTokenNotFound
+		}
+		delete(tokens, tokenID)
+		return nil
+	})
+}
+
+func (r *fileRepository) TouchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.updateTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		if token, ok := tokens[id]; ok {
+			usedAt := usedAt.UTC()
+			token.LastUsedAt = &usedAt
+		}
+		return nil
+	})
+}
+
+// --- Entry Keys ---
+
+// Выполняет fn с ключами пользователя под блокировкой его каталога;
+// при exclusive ключи после fn сохраняются
+func (r *fileRepository) withEntryKeys(ctx context.Context, userID uuid.UUID, exclusive bool, fn func(keys map[string]*models.EntryKey) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	unlock, err := r.store.lock(userID, exclusive)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	keys, err := r.store.loadEntryKeys(userID)
+	if err != nil {
+		return err
+	}
+	if err := fn(keys); err != nil {
+		return err
+	}
+	if !exclusive {
+		return nil
+	}
+	return r.store.saveEntryKeys(userID, keys)
+}
+
+func (r *fileRepository) PutEntryKey(ctx context.Context, key *models.EntryKey) error {
+	return r.withEntryKeys(ctx, key.UserID, true, func(keys map[string]*models.EntryKey) error {
+		keys[key.KeyID] = prepareEntryKey(key, keys[key.KeyID], r.now())
+		return nil
+	})
+}
+
+func (r *fileRepository) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	var list []*models.EntryKey
+	err := r.withEntryKeys(ctx, userID, false, func(keys map[string]*models.EntryKey) error {
+		for _, key := range keys {
+			list = append(list, key)
+		}
+		return nil
+	})
+	sortEntryKeys(list)
+	return list, err
+}
+
+func (r *fileRepository) ReadEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	var found *models.EntryKey
+	err := r.withEntryKeys(ctx, userID, false, func(keys map[string]*models.EntryKey) error {
+		key, ok := keys[keyID]
+		if !ok {
+			return ErrEntryKeyNotFound
+		}
+		found = key
+		return nil
+	})
+	return found, err
+}
+
+// --- Usage ---
+
+// Системная операция: обходит каталоги всех пользователей и файл токенов
+func (r *fileRepository) ListUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	users, err := r.store.users()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(usageByUser)
+	for _, userID := range users {
+		err := r.view(ctx, userID, func(index *fileIndex) error {
+			for _, item := range index.Entries {
+				countEntry(usage, userID, item)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = r.viewTokens(ctx, func(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+		for _, token := range tokens {
+			usage.user(token.UserID).Tokens++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage.list(), nil
+}
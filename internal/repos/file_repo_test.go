@@ -0,0 +1,448 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FileRepositoryTestSuite struct {
+	suite.Suite
+	root   string
+	repo   Repository
+	userID uuid.UUID
+}
+
+func (suite *FileRepositoryTestSuite) SetupTest() {
+	suite.root = suite.T().TempDir()
+	repo, err := NewFileRepository(suite.root)
+	suite.Require().NoError(err)
+	suite.repo = repo
+	suite.userID = uuid.New()
+}
+
+func (suite *FileRepositoryTestSuite) createEntry(title string, entryDate time.Time, tags ...string) *models.Entry {
+	entry := &models.Entry{
+		ID:        uuid.New(),
+		UserID:    suite.userID,
+		Title:     title,
+		Content:   "Content of " + title,
+		EntryDate: entryDate,
+		TimeZone:  "UTC",
+	}
+	for _, name := range tags {
+		entry.Tags = append(entry.Tags, models.Tag{Name: name})
+	}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	return entry
+}
+
+func (suite *FileRepositoryTestSuite) entryPath(entry *models.Entry) string {
+	return filepath.Join(suite.root, entry.UserID.String(), entryRelPath(entry))
+}
+
+func (suite *FileRepositoryTestSuite) TestCreateWritesMarkdownWithFrontMatter() {
+	// Arrange
+	day := time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	mood := 4
+
+	// Act
+	entry := &models.Entry{
+		ID: uuid.New(), UserID: suite.userID, Title: "Вечер", Content: "# Заметки\n\n---\nТекст с разделителем\n",
+		EntryDate: day, TimeZone: "Europe/Moscow", Mood: &mood,
+		Tags: []models.Tag{{Name: "travel"}, {Name: "family"}},
+	}
+	err := suite.repo.Create(context.Background(), entry)
+
+	// Assert - файл лежит в каталоге дня по часовому поясу записи
+	suite.Require().NoError(err)
+	path := filepath.Join(suite.root, suite.userID.String(), "2024", "05", "02", entry.ID.String()+".md")
+	data, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	text := string(data)
+	assert.True(suite.T(), strings.HasPrefix(text, "---\n"))
+	assert.Contains(suite.T(), text, "title: Вечер\n")
+	assert.Contains(suite.T(), text, "tags: [family, travel]\n")
+	assert.Contains(suite.T(), text, "mood: 4\n")
+	assert.True(suite.T(), strings.HasSuffix(text, "---\n# Заметки\n\n---\nТекст с разделителем\n"))
+
+	// Текст и поля читаются без изменений
+	found, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), entry.Content, found.Content)
+	assert.True(suite.T(), day.Equal(found.EntryDate))
+	assert.Equal(suite.T(), 4, *found.Mood)
+	assert.Equal(suite.T(), []string{"family", "travel"}, tagNames(found.Tags))
+	assert.Equal(suite.T(), 1, found.Version)
+}
+
+func (suite *FileRepositoryTestSuite) TestCreateDuplicate() {
+	// Arrange
+	entry := suite.createEntry("Once", time.Now())
+
+	// Act
+	err := suite.repo.Create(context.Background(), entry)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, errEntryExists)
+}
+
+func (suite *FileRepositoryTestSuite) TestReadForeignAndInvalid() {
+	// Arrange
+	entry := suite.createEntry("Mine", time.Now())
+
+	// Act
+	_, foreign := suite.repo.Read(context.Background(), uuid.New(), entry.ID.String())
+	_, invalid := suite.repo.Read(context.Background(), suite.userID, "not-a-uuid")
+
+	// Assert
+	assert.ErrorIs(suite.T(), foreign, ErrEntryNotFound)
+	assert.ErrorIs(suite.T(), invalid, ErrEntryNotFound)
+}
+
+func (suite *FileRepositoryTestSuite) TestUpdateMovesFileAndRecordsRevision() {
+	// Arrange
+	entry := suite.createEntry("Draft", time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC), "work")
+	oldPath := suite.entryPath(entry)
+
+	// Act
+	entry.Title = "Final"
+	entry.EntryDate = time.Date(2024, 2, 3, 12, 0, 0, 0, time.UTC)
+	entry.Tags = []models.Tag{{Name: "home"}}
+	err := suite.repo.Update(context.Background(), entry)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, entry.Version)
+	assert.NoFileExists(suite.T(), oldPath)
+	assert.NoDirExists(suite.T(), filepath.Dir(oldPath))
+	assert.FileExists(suite.T(), suite.entryPath(entry))
+
+	found, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Final", found.Title)
+	assert.Equal(suite.T(), []string{"home"}, tagNames(found.Tags))
+
+	revisions, err := suite.repo.ListRevisions(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	suite.Require().Len(revisions, 2)
+	assert.Equal(suite.T(), "Final", revisions[0].Title)
+	assert.Equal(suite.T(), "Draft", revisions[1].Title)
+
+	revision, err := suite.repo.ReadRevision(context.Background(), suite.userID, entry.ID.String(), 1)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Draft", revision.Title)
+	_, err = suite.repo.ReadRevision(context.Background(), suite.userID, entry.ID.String(), 3)
+	assert.ErrorIs(suite.T(), err, ErrRevisionNotFound)
+}
+
+func (suite *FileRepositoryTestSuite) TestUpdateVersionConflict() {
+	// Arrange
+	entry := suite.createEntry("Draft", time.Now())
+	stale := *entry
+	entry.Title = "First edit"
+	suite.Require().NoError(suite.repo.Update(context.Background(), entry))
+
+	// Act
+	stale.Title = "Lost edit"
+	err := suite.repo.Update(context.Background(), &stale)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrVersionConflict)
+	assert.Equal(suite.T(), 1, stale.Version)
+}
+
+func (suite *FileRepositoryTestSuite) TestListByUserFiltersAndPagination() {
+	// Arrange
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		suite.createEntry("Day "+string(rune('A'+i)), day.AddDate(0, 0, i), "daily")
+	}
+	suite.createEntry("Trip", day.AddDate(0, 0, 10), "travel", "daily")
+	suite.createEntry("Other tag", day.AddDate(0, 0, 11), "work")
+
+	// Act - страницы по две записи с тегом daily, новые первыми
+	var titles []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		entries, next, err := suite.repo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+			Tags: []string{"daily"}, Limit: 2, Cursor: cursor,
+		})
+		suite.Require().NoError(err)
+		for _, entry := range entries {
+			titles = append(titles, entry.Title)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	// Assert
+	assert.Equal(suite.T(), []string{"Trip", "Day E", "Day D", "Day C", "Day B", "Day A"}, titles)
+
+	all, _, err := suite.repo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+		Tags: []string{"daily", "travel"}, TagMatch: models.TagMatchAll,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(all, 1)
+	assert.Equal(suite.T(), "Trip", all[0].Title)
+
+	from, to := day.AddDate(0, 0, 1), day.AddDate(0, 0, 3)
+	ranged, _, err := suite.repo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+		From: &from, To: &to, SortBy: models.SortByTitle, SortOrder: models.SortAsc,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(ranged, 2)
+	assert.Equal(suite.T(), "Day B", ranged[0].Title)
+
+	titled, _, err := suite.repo.ListByUser(context.Background(), suite.userID, models.EntryFilter{Title: "TRIP"})
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), titled, 1)
+}
+
+func (suite *FileRepositoryTestSuite) TestTrashLifecycle() {
+	// Arrange
+	kept := suite.createEntry("Kept", time.Now())
+	trashed := suite.createEntry("Trashed", time.Now())
+
+	// Act - удаление перемещает запись в корзину
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, trashed.ID.String()))
+
+	// Assert
+	list, err := suite.repo.List(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	suite.Require().Len(list, 1)
+	assert.Equal(suite.T(), kept.ID, list[0].ID)
+
+	trash, err := suite.repo.ListTrash(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	suite.Require().Len(trash, 1)
+	assert.True(suite.T(), trash[0].DeletedAt.Valid)
+	_, err = suite.repo.Read(context.Background(), suite.userID, trashed.ID.String())
+	assert.ErrorIs(suite.T(), err, ErrEntryNotFound)
+
+	// Восстановление
+	suite.Require().NoError(suite.repo.Restore(context.Background(), suite.userID, trashed.ID.String()))
+	_, err = suite.repo.Read(context.Background(), suite.userID, trashed.ID.String())
+	suite.Require().NoError(err)
+
+	// Окончательное удаление убирает файл и историю
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, trashed.ID.String()))
+	suite.Require().NoError(suite.repo.Purge(context.Background(), suite.userID, trashed.ID.String()))
+	assert.NoFileExists(suite.T(), suite.entryPath(trashed))
+	assert.NoDirExists(suite.T(), filepath.Join(suite.root, suite.userID.String(), fileRevisionsDir, trashed.ID.String()))
+	assert.ErrorIs(suite.T(), suite.repo.Purge(context.Background(), suite.userID, trashed.ID.String()), ErrTrashedEntryNotFound)
+}
+
+func (suite *FileRepositoryTestSuite) TestPurgeDeletedBefore() {
+	// Arrange - записи двух пользователей в корзине
+	mine := suite.createEntry("Mine", time.Now())
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, mine.ID.String()))
+	other := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Other", Content: "-", EntryDate: time.Now()}
+	suite.Require().NoError(suite.repo.Create(context.Background(), other))
+	suite.Require().NoError(suite.repo.Delete(context.Background(), other.UserID, other.ID.String()))
+
+	// Act
+	notYet, err := suite.repo.PurgeDeletedBefore(context.Background(), time.Now().Add(-time.Hour))
+	suite.Require().NoError(err)
+	purged, err := suite.repo.PurgeDeletedBefore(context.Background(), time.Now().Add(time.Hour))
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Zero(suite.T(), notYet)
+	assert.Equal(suite.T(), int64(2), purged)
+}
+
+func (suite *FileRepositoryTestSuite) TestTags() {
+	// Arrange
+	suite.createEntry("One", time.Now(), "work", "ideas")
+	two := suite.createEntry("Two", time.Now(), "work")
+	suite.createEntry("Three", time.Now(), "todo")
+
+	// Act
+	suite.Require().NoError(suite.repo.RenameTag(context.Background(), suite.userID, "ideas", "work"))
+	suite.Require().NoError(suite.repo.MergeTags(context.Background(), suite.userID, []string{"todo"}, "work"))
+	counts, err := suite.repo.ListTags(context.Background(), suite.userID)
+
+	// Assert - теги слились без повторов, версии записей увеличились
+	suite.Require().NoError(err)
+	suite.Require().Len(counts, 1)
+	assert.Equal(suite.T(), models.TagCount{Name: "work", Count: 3}, *counts[0])
+	assert.ErrorIs(suite.T(), suite.repo.RenameTag(context.Background(), suite.userID, "missing", "x"), ErrTagNotFound)
+
+	found, err := suite.repo.Read(context.Background(), suite.userID, two.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 1, found.Version)
+	one, _, err := suite.repo.ListByUser(context.Background(), suite.userID, models.EntryFilter{Title: "One"})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, one[0].Version)
+	assert.Equal(suite.T(), []string{"work"}, tagNames(one[0].Tags))
+}
+
+func (suite *FileRepositoryTestSuite) TestSearchAndStats() {
+	// Arrange
+	day := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	mood := 3
+	suite.createEntry("Mountains", day)
+	entry := &models.Entry{ID: uuid.New(), UserID: suite.userID, Title: "Notes", Content: "Walk in the mountains", EntryDate: day.Add(time.Hour), Mood: &mood}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, "mountain*", 10)
+	suite.Require().NoError(err)
+	points, err := suite.repo.ListMoodPoints(context.Background(), suite.userID, day, day.AddDate(0, 0, 1))
+	suite.Require().NoError(err)
+
+	// Assert
+	suite.Require().Len(results, 2)
+	assert.Equal(suite.T(), "Mountains", results[0].Entry.Title)
+	assert.Contains(suite.T(), results[1].ContentSnippet, "<mark>mountain</mark>s")
+	suite.Require().Len(points, 1)
+	assert.Equal(suite.T(), 3, *points[0].Mood)
+}
+
+func (suite *FileRepositoryTestSuite) TestEncryptedEntryFile() {
+	// Arrange
+	day := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	suite.createEntry("Mountains", day)
+	entry := &models.Entry{
+		ID: uuid.New(), UserID: suite.userID, EntryDate: day, TimeZone: "UTC",
+		Algorithm: models.EncryptionA256GCM, KeyID: "main",
+		Nonce: []byte("0123456789ab"), Ciphertext: []byte("\x00---\nmountains"),
+	}
+
+	// Act
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	suite.Require().NoError(suite.repo.PutEntryKey(context.Background(), &models.EntryKey{
+		UserID: suite.userID, KeyID: "main", Algorithm: models.EncryptionA256GCM, WrappedKey: []byte("wrapped"),
+	}))
+	results, err := suite.repo.Search(context.Background(), suite.userID, "mountain*", 10)
+	suite.Require().NoError(err)
+
+	// Assert - шифротекст лежит в файле как base64, поиск его не видит
+	data, err := os.ReadFile(suite.entryPath(entry))
+	suite.Require().NoError(err)
+	text := string(data)
+	assert.Contains(suite.T(), text, "encryption:\n")
+	assert.Contains(suite.T(), text, "key_id: main\n")
+	assert.True(suite.T(), strings.HasSuffix(text, "---\nAC0tLQptb3VudGFpbnM=\n"))
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "Mountains", results[0].Entry.Title)
+	assert.FileExists(suite.T(), filepath.Join(suite.root, suite.userID.String(), fileKeysName))
+
+	// Индекс, перестроенный по файлам, не спотыкается о файл ключей
+	suite.Require().NoError(os.Remove(filepath.Join(suite.root, suite.userID.String(), fileIndexName)))
+	found, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), entry.Ciphertext, found.Ciphertext)
+	assert.Equal(suite.T(), entry.Nonce, found.Nonce)
+	assert.Empty(suite.T(), found.Content)
+}
+
+func (suite *FileRepositoryTestSuite) TestIndexRebuiltFromFiles() {
+	// Arrange - индекс потерян или поврежден
+	entry := suite.createEntry("Survivor", time.Now(), "tag")
+	indexPath := filepath.Join(suite.root, suite.userID.String(), fileIndexName)
+	suite.Require().NoError(os.WriteFile(indexPath, []byte("{broken"), 0o600))
+
+	// Act
+	list, err := suite.repo.List(context.Background(), suite.userID)
+
+	// Assert
+	suite.Require().NoError(err)
+	suite.Require().Len(list, 1)
+	assert.Equal(suite.T(), entry.ID, list[0].ID)
+
+	// Файл, удаленный в обход хранилища, исчезает из выборок
+	suite.Require().NoError(os.Remove(suite.entryPath(entry)))
+	list, err = suite.repo.List(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), list)
+}
+
+func (suite *FileRepositoryTestSuite) TestIndexRebuiltWhenFileMoved() {
+	// Arrange - файл перенесен в другой день в обход хранилища: индекс
+	// ссылается на старый путь
+	entry := suite.createEntry("Moved", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	oldPath := suite.entryPath(entry)
+	newPath := filepath.Join(suite.root, suite.userID.String(), "2024", "05", "02", filepath.Base(oldPath))
+	suite.Require().NoError(os.MkdirAll(filepath.Dir(newPath), 0o700))
+	suite.Require().NoError(os.Rename(oldPath, newPath))
+
+	// Act
+	read, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	read.Title = "Updated"
+	err = suite.repo.Update(context.Background(), read)
+
+	// Assert - при неудачном чтении индекс перестроен по файлам
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), entry.ID, read.ID)
+	assert.FileExists(suite.T(), suite.entryPath(read))
+}
+
+func (suite *FileRepositoryTestSuite) TestConcurrentUpdatesKeepFilesConsistent() {
+	// Arrange
+	entry := suite.createEntry("Counter", time.Now())
+
+	// Act - конкурирующие изменения одной версии: проходит ровно одно
+	var wg sync.WaitGroup
+	results := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := *entry
+			update.Content = strings.Repeat("x", i+1)
+			results <- suite.repo.Update(context.Background(), &update)
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	// Assert
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(suite.T(), err, ErrVersionConflict)
+		}
+	}
+	assert.Equal(suite.T(), 1, succeeded)
+
+	found, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), 2, found.Version)
+
+	// Временных файлов атомарной записи не остается
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(suite.entryPath(entry)), ".tmp-*"))
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), leftovers)
+}
+
+func (suite *FileRepositoryTestSuite) TestCanceledContext() {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	_, err := suite.repo.List(ctx, suite.userID)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, context.Canceled)
+}
+
+func TestFileRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(FileRepositoryTestSuite))
+}
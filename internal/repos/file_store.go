@@ -0,0 +1,642 @@
+package repos
+
+import (
+	"bytes"
+	"diary/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// --- Хранение записей в файлах ---
+
+// Раскладка каталога:
+//
+//	<root>/<user-id>/2024/05/01/<entry-id>.md   запись (дата - в часовом поясе записи)
+//	<root>/<user-id>/.revisions/<entry-id>/0001.md   ревизии записи
+//	<root>/<user-id>/index.json   индекс для выборок без чтения всех файлов
+//	<root>/<user-id>/keys.json   обернутые ключи шифрования записей
+//	<root>/<user-id>/.lock   файл блокировки каталога пользователя
+//	<root>/tokens.json   токены доступа всех пользователей
+//	<root>/.tokens.lock   файл блокировки токенов
+//
+// Источник истины - файлы записей; индекс перестраивается по ним, если он
+// потерян, поврежден или ссылается на отсутствующий файл.
+
+const (
+	fileIndexName    = "index.json"
+	fileKeysName     = "keys.json"
+	fileLockName     = ".lock"
+	fileTokensName   = "tokens.json"
+	fileTokensLock   = ".tokens.lock"
+	fileRevisionsDir = ".revisions"
+	fileEntryExt     = ".md"
+	fileTempPattern  = ".tmp-*"
+	frontMatterDelim = "---\n"
+)
+
+var errMalformedEntryFile = errors.New("malformed entry file: front matter not found")
+
+// Заголовок записи в YAML front matter; текст записи идет после него
+type entryFrontMatter struct {
+	ID        uuid.UUID  `yaml:"id"`
+	UserID    uuid.UUID  `yaml:"user_id"`
+	Title     string     `yaml:"title"`
+	EntryDate time.Time  `yaml:"entry_date"`
+	TimeZone  string     `yaml:"time_zone"`
+	CreatedAt time.Time  `yaml:"created_at"`
+	UpdatedAt time.Time  `yaml:"updated_at"`
+	DeletedAt *time.Time `yaml:"deleted_at,omitempty"`
+	Tags      []string   `yaml:"tags,flow"`
+	Mood      *int       `yaml:"mood,omitempty"`
+	MoodEmoji string     `yaml:"mood_emoji,omitempty"`
+	MoodLabel string     `yaml:"mood_label,omitempty"`
+	Energy    *int       `yaml:"energy,omitempty"`
+	Version   int        `yaml:"version"`
+	// Есть у зашифрованной записи; текст файла - шифротекст в base64
+	Encryption *encryptionFrontMatter `yaml:"encryption,omitempty"`
+}
+
+type encryptionFrontMatter struct {
+	Algorithm string `yaml:"algorithm"`
+	KeyID     string `yaml:"key_id"`
+	Nonce     string `yaml:"nonce"`
+}
+
+type revisionFrontMatter struct {
+	ID        uuid.UUID `yaml:"id"`
+	EntryID   uuid.UUID `yaml:"entry_id"`
+	Number    int       `yaml:"number"`
+	Title     string    `yaml:"title"`
+	CreatedAt time.Time `yaml:"created_at"`
+}
+
+type fileIndex struct {
+	Entries map[uuid.UUID]*entrySummary `json:"entries"`
+}
+
+// --- Кодирование файлов ---
+
+func encodeEntryFile(entry *models.Entry) ([]byte, error) {
+	meta := entryFrontMatter{
+		ID:        entry.ID,
+		UserID:    entry.UserID,
+		Title:     entry.Title,
+		EntryDate: entry.EntryDate.UTC(),
+		TimeZone:  entry.TimeZone,
+		CreatedAt: entry.CreatedAt.UTC(),
+		UpdatedAt: entry.UpdatedAt.UTC(),
+		Tags:      tagNames(entry.Tags),
+		Mood:      entry.Mood,
+		MoodEmoji: entry.MoodEmoji,
+		MoodLabel: entry.MoodLabel,
+		Energy:    entry.Energy,
+		Version:   entry.Version,
+	}
+	if entry.DeletedAt.Valid {
+		deletedAt := entry.DeletedAt.Time.UTC()
+		meta.DeletedAt = &deletedAt
+	}
+	if entry.Encrypted() {
+		meta.Encryption = &encryptionFrontMatter{
+			Algorithm: entry.Algorithm,
+			KeyID:     entry.KeyID,
+			Nonce:     base64.StdEncoding.EncodeToString(entry.Nonce),
+		}
+		return encodeFrontMatter(meta, base64.StdEncoding.EncodeToString(entry.Ciphertext)+"\n")
+	}
+	return encodeFrontMatter(meta, entry.Content)
+}
+
+func decodeEntryFile(data []byte) (*models.Entry, error) {
+	var meta entryFrontMatter
+	content, err := decodeFrontMatter(data, &meta)
+	if err != nil {
+		return nil, err
+	}
+	entry := &models.Entry{
+		ID:        meta.ID,
+		UserID:    meta.UserID,
+		Title:     meta.Title,
+		Content:   content,
+		EntryDate: meta.EntryDate.UTC(),
+		TimeZone:  meta.TimeZone,
+		CreatedAt: meta.CreatedAt.UTC(),
+		UpdatedAt: meta.UpdatedAt.UTC(),
+		Mood:      meta.Mood,
+		MoodEmoji: meta.MoodEmoji,
+		MoodLabel: meta.MoodLabel,
+		Energy:    meta.Energy,
+		Version:   meta.Version,
+	}
+	if meta.DeletedAt != nil {
+		entry.DeletedAt.Time = meta.DeletedAt.UTC()
+		entry.DeletedAt.Valid = true
+	}
+	for _, name := range meta.Tags {
+		entry.Tags = append(entry.Tags, namedTag(meta.UserID, name))
+	}
+	if meta.Encryption != nil {
+		if err := decodeEncryption(entry, meta.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// Переносит шифротекст из текста файла в запись
+func decodeEncryption(entry *models.Entry, meta *encryptionFrontMatter) error {
+	nonce, err := base64.StdEncoding.DecodeString(meta.Nonce)
+	if err != nil {
+		return fmt.Errorf("malformed entry file: nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(entry.Content))
+	if err != nil {
+		return fmt.Errorf("malformed entry file: ciphertext: %w", err)
+	}
+	entry.Algorithm = meta.Algorithm
+	entry.KeyID = meta.KeyID
+	entry.Nonce = nonce
+	entry.Ciphertext = ciphertext
+	entry.Content = ""
+	return nil
+}
+
+func encodeRevisionFile(revision *models.EntryRevision) ([]byte, error) {
+	return encodeFrontMatter(revisionFrontMatter{
+		ID:        revision.ID,
+		EntryID:   revision.EntryID,
+		Number:    revision.Number,
+		Title:     revision.Title,
+		CreatedAt: revision.CreatedAt.UTC(),
+	}, revision.Content)
+}
+
+func decodeRevisionFile(data []byte) (*models.EntryRevision, error) {
+	var meta revisionFrontMatter
+	content, err := decodeFrontMatter(data, &meta)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EntryRevision{
+		ID:        meta.ID,
+		EntryID:   meta.EntryID,
+		Number:    meta.Number,
+		Title:     meta.Title,
+		Content:   content,
+		CreatedAt: meta.CreatedAt.UTC(),
+	}, nil
+}
+
+func encodeFrontMatter(meta any, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelim)
+	buf.Write(header)
+	buf.WriteString(frontMatterDelim)
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// Разбирает front matter в meta и возвращает текст после него без изменений
+func decodeFrontMatter(data []byte, meta any) (string, error) {
+	text := string(data)
+	if !strings.HasPrefix(text, frontMatterDelim) {
+		return "", errMalformedEntryFile
+	}
+	rest := text[len(frontMatterDelim):]
+	end := strings.Index(rest, "\n"+frontMatterDelim)
+	if end < 0 {
+		return "", errMalformedEntryFile
+	}
+	if err := yaml.Unmarshal([]byte(rest[:end+1]), meta); err != nil {
+		return "", fmt.Errorf("malformed entry file: %w", err)
+	}
+	return rest[end+1+len(frontMatterDelim):], nil
+}
+
+// --- Пути ---
+
+type fileStore struct {
+	root string
+
+	// Блокировки каталогов пользователей внутри процесса; между процессами
+	// каталог защищает блокировка файла .lock
+	mu    sync.Mutex
+	locks map[uuid.UUID]*sync.RWMutex
+
+	// Токены лежат в одном файле: поиск по секрету идет до того, как
+	// известен владелец
+	tokensMu sync.RWMutex
+}
+
+func (s *fileStore) userDir(userID uuid.UUID) string {
+	return filepath.Join(s.root, userID.String())
+}
+
+// Путь записи относительно каталога пользователя: год/месяц/день в часовом
+// поясе записи, чтобы раскладка совпадала с тем, что видит пользователь
+func entryRelPath(entry *models.Entry) string {
+	date := entry.EntryDate
+	if loc, err := time.LoadLocation(entry.TimeZone); err == nil && entry.TimeZone != "" {
+		date = date.In(loc)
+	} else {
+		date = date.UTC()
+	}
+	return filepath.Join(date.Format("2006"), date.Format("01"), date.Format("02"), entry.ID.String()+fileEntryExt)
+}
+
+func (s *fileStore) revisionDir(userID, entryID uuid.UUID) string {
+	return filepath.Join(s.userDir(userID), fileRevisionsDir, entryID.String())
+}
+
+func (s *fileStore) revisionPath(userID, entryID uuid.UUID, number int) string {
+	return filepath.Join(s.revisionDir(userID, entryID), fmt.Sprintf("%04d%s", number, fileEntryExt))
+}
+
+// --- Блокировки ---
+
+// Блокирует каталог пользователя: exclusive - для изменений, иначе
+// разделяемая блокировка для чтения. Возвращает функцию снятия блокировки.
+func (s *fileStore) lock(userID uuid.UUID, exclusive bool) (func(), error) {
+	s.mu.Lock()
+	if s.locks == nil {
+		s.locks = map[uuid.UUID]*sync.RWMutex{}
+	}
+	mu, ok := s.locks[userID]
+	if !ok {
+		mu = &sync.RWMutex{}
+		s.locks[userID] = mu
+	}
+	s.mu.Unlock()
+
+	if exclusive {
+		mu.Lock()
+	} else {
+		mu.RLock()
+	}
+	release := func() {
+		if exclusive {
+			mu.Unlock()
+		} else {
+			mu.RUnlock()
+		}
+	}
+
+	dir := s.userDir(userID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		release()
+		return nil, err
+	}
+	unlockFile, err := lockFile(filepath.Join(dir, fileLockName), exclusive)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return func() {
+		unlockFile()
+		release()
+	}, nil
+}
+
+// Блокирует файл токенов так же, как lock блокирует каталог пользователя
+func (s *fileStore) lockTokens(exclusive bool) (func(), error) {
+	if exclusive {
+		s.tokensMu.Lock()
+	} else {
+		s.tokensMu.RLock()
+	}
+	release := func() {
+		if exclusive {
+			s.tokensMu.Unlock()
+		} else {
+			s.tokensMu.RUnlock()
+		}
+	}
+
+	unlockFile, err := lockFile(filepath.Join(s.root, fileTokensLock), exclusive)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return func() {
+		unlockFile()
+		release()
+	}, nil
+}
+
+// --- Чтение и запись ---
+
+// Атомарная запись: временный файл в том же каталоге, fsync, затем rename.
+// Читатель видит либо старое, либо новое содержимое целиком.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, fileTempPattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Сохраняет rename на диске; не везде каталог можно открыть для fsync
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	d.Sync()
+	return nil
+}
+
+func (s *fileStore) readEntry(userID uuid.UUID, relPath string) (*models.Entry, error) {
+	data, err := os.ReadFile(filepath.Join(s.userDir(userID), relPath))
+	if err != nil {
+		return nil, err
+	}
+	entry, err := decodeEntryFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", relPath, err)
+	}
+	return entry, nil
+}
+
+// Записывает файл записи и удаляет прежний, если дата записи изменилась
+func (s *fileStore) writeEntry(entry *models.Entry, oldPath string) (string, error) {
+	data, err := encodeEntryFile(entry)
+	if err != nil {
+		return "", err
+	}
+	relPath := entryRelPath(entry)
+	if err := writeFileAtomic(filepath.Join(s.userDir(entry.UserID), relPath), data); err != nil {
+		return "", err
+	}
+	if oldPath != "" && oldPath != relPath {
+		if err := s.removeEntryFile(entry.UserID, oldPath); err != nil {
+			return "", err
+		}
+	}
+	return relPath, nil
+}
+
+// Удаляет файл записи и опустевшие каталоги дня, месяца и года
+func (s *fileStore) removeEntryFile(userID uuid.UUID, relPath string) error {
+	base := s.userDir(userID)
+	if err := os.Remove(filepath.Join(base, relPath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+		if os.Remove(filepath.Join(base, dir)) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *fileStore) readRevisions(userID, entryID uuid.UUID) ([]*models.EntryRevision, error) {
+	dir := s.revisionDir(userID, entryID)
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]*models.EntryRevision, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != fileEntryExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		revision, err := decodeRevisionFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Number < revisions[j].Number })
+	return revisions, nil
+}
+
+func (s *fileStore) writeRevision(userID uuid.UUID, revision *models.EntryRevision) error {
+	data, err := encodeRevisionFile(revision)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.revisionPath(userID, revision.EntryID, revision.Number), data)
+}
+
+// --- Токены ---
+
+// Токены в отличие от индекса не восстановить по другим файлам, поэтому
+// поврежденный файл токенов - ошибка, а не повод начать с пустого списка
+func (s *fileStore) loadTokens() (map[uuid.UUID]*models.PersonalAccessToken, error) {
+	tokens := make(map[uuid.UUID]*models.PersonalAccessToken)
+	data, err := os.ReadFile(filepath.Join(s.root, fileTokensName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*models.PersonalAccessToken
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", fileTokensName, err)
+	}
+	for _, token := range list {
+		tokens[token.ID] = token
+	}
+	return tokens, nil
+}
+
+func (s *fileStore) saveTokens(tokens map[uuid.UUID]*models.PersonalAccessToken) error {
+	list := make([]*models.PersonalAccessToken, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, token)
+	}
+	sortTokens(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.root, fileTokensName), data)
+}
+
+// --- Ключи шифрования ---
+
+// Как и токены, ключи не восстановить по другим файлам: поврежденный файл
+// ключей - ошибка
+func (s *fileStore) loadEntryKeys(userID uuid.UUID) (map[string]*models.EntryKey, error) {
+	keys := make(map[string]*models.EntryKey)
+	data, err := os.ReadFile(filepath.Join(s.userDir(userID), fileKeysName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*models.EntryKey
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", fileKeysName, err)
+	}
+	for _, key := range list {
+		keys[key.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (s *fileStore) saveEntryKeys(userID uuid.UUID, keys map[string]*models.EntryKey) error {
+	list := make([]*models.EntryKey, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key)
+	}
+	sortEntryKeys(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.userDir(userID), fileKeysName), data)
+}
+
+// --- Индекс ---
+
+// Читает индекс пользователя; отсутствующий или поврежденный индекс
+// перестраивается по файлам записей. С файлами индекс здесь не сверяется,
+// это дорого для каждой выборки: устаревший индекс обнаруживается, когда
+// файл по его пути не читается (см. fileRepository.withIndex).
+func (s *fileStore) loadIndex(userID uuid.UUID) (*fileIndex, error) {
+	data, err := os.ReadFile(filepath.Join(s.userDir(userID), fileIndexName))
+	if err == nil {
+		var index fileIndex
+		if json.Unmarshal(data, &index) == nil && index.Entries != nil {
+			return &index, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return s.rebuildIndex(userID)
+}
+
+func (s *fileStore) rebuildIndex(userID uuid.UUID) (*fileIndex, error) {
+	index := &fileIndex{Entries: map[uuid.UUID]*entrySummary{}}
+	base := s.userDir(userID)
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == base {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == fileRevisionsDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != fileEntryExt {
+			return nil
+		}
+		relPath, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		entry, err := s.readEntry(userID, relPath)
+		if err != nil {
+			return err
+		}
+		index.Entries[entry.ID] = newEntrySummary(entry, relPath)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rebuild index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *fileStore) saveIndex(userID uuid.UUID, index *fileIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.userDir(userID), fileIndexName), data)
+}
+
+// Пользователи, у которых есть каталог
+func (s *fileStore) users() ([]uuid.UUID, error) {
+	dirs, err := os.ReadDir(s.root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var users []uuid.UUID
+	for _, dir := range dirs {
+		if id, err := uuid.Parse(dir.Name()); err == nil && dir.IsDir() {
+			users = append(users, id)
+		}
+	}
+	return users, nil
+}
+
+// Есть ли запись entryID у другого пользователя. Идентификаторы уникальны
+// среди всех пользователей, как первичный ключ в базе; запись узнается по
+// каталогу ревизий, который создается вместе с ней.
+func (s *fileStore) ownedByOther(userID, entryID uuid.UUID) (bool, error) {
+	users, err := s.users()
+	if err != nil {
+		return false, err
+	}
+	for _, other := range users {
+		if other == userID {
+			continue
+		}
+		_, err := os.Stat(s.revisionDir(other, entryID))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,811 @@
+package repos
+
+import (
+	"bytes"
+	"context"
+	"diary/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Memory Repository Interface ---
+
+// Хранилище в памяти для тестов и демо-режима. Реализует Repository
+// целиком с той же семантикой, что и SQL-хранилище; поиск работает как
+// упрощенный режим SQLite без FTS5.
+type MemoryRepository interface {
+	Repository
+	// SaveSnapshot записывает содержимое в файл снимка, с которым хранилище
+	// было открыто; без файла ничего не делает
+	SaveSnapshot() error
+}
+
+// --- Memory Repository Implementation ---
+
+type memoryRecord struct {
+	entry     *models.Entry
+	summary   *entrySummary
+	revisions []*models.EntryRevision
+}
+
+// Ключ шифрования определяется пользователем и своим идентификатором
+type memoryKeyID struct {
+	userID uuid.UUID
+	keyID  string
+}
+
+type memoryRepository struct {
+	mu sync.RWMutex
+	// Записи по пользователям: выборки перебирают только записи владельца
+	records map[uuid.UUID]map[uuid.UUID]*memoryRecord
+	// Владелец каждой записи. ID уникальны среди всех пользователей, как
+	// первичный ключ в базе.
+	owners   map[uuid.UUID]uuid.UUID
+	tokens   map[uuid.UUID]*models.PersonalAccessToken
+	keys     map[memoryKeyID]*models.EntryKey
+	snapshot string
+	now      func() time.Time
+}
+
+// NewMemoryRepository создает пустое хранилище без снимка
+func NewMemoryRepository() MemoryRepository {
+	return &memoryRepository{
+		records: make(map[uuid.UUID]map[uuid.UUID]*memoryRecord),
+		owners:  make(map[uuid.UUID]uuid.UUID),
+		tokens:  make(map[uuid.UUID]*models.PersonalAccessToken),
+		keys:    make(map[memoryKeyID]*models.EntryKey),
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// OpenMemoryRepository загружает снимок из path, если файл есть.
+// SaveSnapshot сохраняет содержимое в тот же файл.
+func OpenMemoryRepository(path string) (MemoryRepository, error) {
+	r := NewMemoryRepository().(*memoryRepository)
+	r.snapshot = path
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// --- Блокировки и копии ---
+
+// Выполняет fn под блокировкой на чтение
+func (r *memoryRepository) view(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return fn()
+}
+
+// Выполняет fn под исключительной блокировкой
+func (r *memoryRepository) update(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fn()
+}
+
+// Наружу отдаются и внутрь принимаются только копии: изменения
+// вызывающего кода не должны менять хранилище в обход блокировки
+func cloneEntry(entry *models.Entry) *models.Entry {
+	clone := *entry
+	clone.Tags = append([]models.Tag(nil), entry.Tags...)
+	if entry.Mood != nil {
+		mood := *entry.Mood
+		clone.Mood = &mood
+	}
+	if entry.Energy != nil {
+		energy := *entry.Energy
+		clone.Energy = &energy
+	}
+	clone.Ciphertext = bytes.Clone(entry.Ciphertext)
+	clone.Nonce = bytes.Clone(entry.Nonce)
+	return &clone
+}
+
+func cloneRevision(revision *models.EntryRevision) *models.EntryRevision {
+	clone := *revision
+	return &clone
+}
+
+// Краткие описания записей пользователя
+func (r *memoryRepository) summaries(userID uuid.UUID) map[uuid.UUID]*entrySummary {
+	records := r.records[userID]
+	items := make(map[uuid.UUID]*entrySummary, len(records))
+	for id, record := range records {
+		items[id] = record.summary
+	}
+	return items
+}
+
+// Копии записей пользователя в порядке ids
+func (r *memoryRepository) entries(userID uuid.UUID, ids []uuid.UUID) []*models.Entry {
+	entries := make([]*models.Entry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, cloneEntry(r.records[userID][id].entry))
+	}
+	return entries
+}
+
+// Запись пользователя, если она есть и ее состояние подходит
+func (r *memoryRepository) record(userID uuid.UUID, id string, keep func(item *entrySummary) bool) (*memoryRecord, bool) {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil, false
+	}
+	record, ok := r.records[userID][entryID]
+	if !ok || !keep(record.summary) {
+		return nil, false
+	}
+	return record, true
+}
+
+// Сохраняет копию записи; время хранится в UTC, как в базе
+func (r *memoryRepository) store(record *memoryRecord, entry *models.Entry) {
+	stored := cloneEntry(entry)
+	stored.EntryDate = stored.EntryDate.UTC()
+	stored.CreatedAt = stored.CreatedAt.UTC()
+	stored.UpdatedAt = stored.UpdatedAt.UTC()
+	if stored.DeletedAt.Valid {
+		stored.DeletedAt.Time = stored.DeletedAt.Time.UTC()
+	}
+	record.entry = stored
+	record.summary = newEntrySummary(stored, "")
+}
+
+// Добавляет запись в хранилище
+func (r *memoryRepository) put(record *memoryRecord) {
+	userID, id := record.entry.UserID, record.entry.ID
+	if r.records[userID] == nil {
+		r.records[userID] = make(map[uuid.UUID]*memoryRecord)
+	}
+	r.records[userID][id] = record
+	r.owners[id] = userID
+}
+
+// Удаляет запись из хранилища
+func (r *memoryRepository) remove(record *memoryRecord) {
+	userID, id := record.entry.UserID, record.entry.ID
+	delete(r.records[userID], id)
+	if len(r.records[userID]) == 0 {
+		delete(r.records, userID)
+	}
+	delete(r.owners, id)
+}
+
+// --- CRUD Entry ---
+
+func (r *memoryRepository) Create(ctx context.Context, entry *models.Entry) error {
+	return r.update(ctx, func() error {
+		if _, ok := r.owners[entry.ID]; ok {
+			return errEntryExists
+		}
+		r.createEntry(entry)
+		return nil
+	})
+}
+
+func (r *memoryRepository) createEntry(entry *models.Entry) {
+	now := r.now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = now
+	}
+	entry.Version = 1
+	entry.Tags = namedTags(entry.UserID, entry.Tags)
+
+	record := &memoryRecord{}
+	r.store(record, entry)
+	r.addRevision(record)
+	r.put(record)
+}
+
+func (r *memoryRepository) Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	var entry *models.Entry
+	err := r.view(ctx, func() error {
+		record, ok := r.record(userID, id, isActive)
+		if !ok {
+			return ErrEntryNotFound
+		}
+		entry = cloneEntry(record.entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Семантика как у entryRepository.Update: проверка версии, ревизия при
+// изменении заголовка или текста, создание отсутствующей записи
+func (r *memoryRepository) Update(ctx context.Context, entry *models.Entry) error {
+	return r.update(ctx, func() error {
+		owner, ok := r.owners[entry.ID]
+		if !ok {
+			r.createEntry(entry)
+			return nil
+		}
+		if owner != entry.UserID {
+			return ErrEntryNotFound
+		}
+		record := r.records[owner][entry.ID]
+		stored := record.entry
+		if !isActive(record.summary) || stored.Version != entry.Version {
+			return ErrVersionConflict
+		}
+
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = stored.CreatedAt
+		}
+		entry.UpdatedAt = r.now()
+		entry.DeletedAt = stored.DeletedAt
+		entry.Version = stored.Version + 1
+		entry.Tags = namedTags(entry.UserID, entry.Tags)
+		r.store(record, entry)
+		r.recordRevision(record)
+		return nil
+	})
+}
+
+// Перемещает запись в корзину
+func (r *memoryRepository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.update(ctx, func() error {
+		record, ok := r.record(userID, id, isActive)
+		if !ok {
+			return nil
+		}
+		entry := cloneEntry(record.entry)
+		entry.DeletedAt.Time = r.now()
+		entry.DeletedAt.Valid = true
+		r.store(record, entry)
+		return nil
+	})
+}
+
+func (r *memoryRepository) List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	var entries []*models.Entry
+	err := r.view(ctx, func() error {
+		ids := selectIDs(r.summaries(userID), isActive, func(a, b *entrySummary) bool {
+			return a.CreatedAt.After(b.CreatedAt)
+		})
+		entries = r.entries(userID, ids)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *memoryRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	filter = normalizeFilter(filter)
+	after, err := decodeCursor(filter.Cursor, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var entries []*models.Entry
+	err = r.view(ctx, func() error {
+		ids, err := pageIDs(r.summaries(userID), filter, after)
+		if err != nil {
+			return err
+		}
+		entries = r.entries(userID, ids)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	entries, next := pageResult(entries, filter)
+	return entries, next, nil
+}
+
+// --- Search ---
+
+func (r *memoryRepository) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	parsed := parseSearchQuery(query)
+	if len(parsed) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	var matched []*models.Entry
+	err := r.view(ctx, func() error {
+		ids := selectIDs(r.summaries(userID), isSearchable, func(a, b *entrySummary) bool {
+			return a.EntryDate.After(b.EntryDate)
+		})
+		for _, id := range ids {
+			entry := r.records[userID][id].entry
+			if parsed.matches(entry.Title, entry.Content) {
+				matched = append(matched, cloneEntry(entry))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankResults(matched, parsed, limit), nil
+}
+
+// --- Tags ---
+
+func (r *memoryRepository) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	var counts []*models.TagCount
+	err := r.view(ctx, func() error {
+		counts = countTags(r.summaries(userID))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Переименовывает тег во всех записях, в том числе в корзине. Если тег
+// с новым именем уже есть у записи, теги сливаются.
+func (r *memoryRepository) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	return r.update(ctx, func() error {
+		if !tagExists(r.summaries(userID), oldName) {
+			return ErrTagNotFound
+		}
+		if oldName == newName {
+			return nil
+		}
+		r.replaceTags(userID, []string{oldName}, newName)
+		return nil
+	})
+}
+
+func (r *memoryRepository) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	return r.update(ctx, func() error {
+		items := r.summaries(userID)
+		var merged []string
+		for _, name := range sources {
+			if name == target {
+				continue
+			}
+			if !tagExists(items, name) {
+				return ErrTagNotFound
+			}
+			merged = append(merged, name)
+		}
+		r.replaceTags(userID, merged, target)
+		return nil
+	})
+}
+
+// Заменяет теги sources на target во всех записях и увеличивает их версию
+func (r *memoryRepository) replaceTags(userID uuid.UUID, sources []string, target string) {
+	for id, item := range r.summaries(userID) {
+		affected := false
+		for _, name := range sources {
+			affected = affected || hasTag(item, name)
+		}
+		if !affected {
+			continue
+		}
+
+		record := r.records[userID][id]
+		entry := cloneEntry(record.entry)
+		for i, tag := range entry.Tags {
+			for _, name := range sources {
+				if tag.Name == name {
+					entry.Tags[i].Name = target
+				}
+			}
+		}
+		entry.Tags = namedTags(userID, entry.Tags)
+		entry.Version++
+		r.store(record, entry)
+	}
+}
+
+// --- Stats ---
+
+func (r *memoryRepository) ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error) {
+	var points []*models.MoodPoint
+	err := r.view(ctx, func() error {
+		points = moodPoints(r.summaries(userID), from, to)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// --- Trash ---
+
+func (r *memoryRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	var entries []*models.Entry
+	err := r.view(ctx, func() error {
+		ids := selectIDs(r.summaries(userID), isTrashed, func(a, b *entrySummary) bool {
+			return a.DeletedAt.After(*b.DeletedAt)
+		})
+		entries = r.entries(userID, ids)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *memoryRepository) ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	var entry *models.Entry
+	err := r.view(ctx, func() error {
+		record, ok := r.record(userID, id, isTrashed)
+		if !ok {
+			return ErrTrashedEntryNotFound
+		}
+		entry = cloneEntry(record.entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *memoryRepository) Restore(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.update(ctx, func() error {
+		record, ok := r.record(userID, id, isTrashed)
+		if !ok {
+			return ErrTrashedEntryNotFound
+		}
+		entry := cloneEntry(record.entry)
+		entry.DeletedAt.Valid = false
+		entry.DeletedAt.Time = time.Time{}
+		r.store(record, entry)
+		return nil
+	})
+}
+
+// Окончательно удаляет запись из корзины вместе с ревизиями
+func (r *memoryRepository) Purge(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.update(ctx, func() error {
+		record, ok := r.record(userID, id, isTrashed)
+		if !ok {
+			return ErrTrashedEntryNotFound
+		}
+		r.remove(record)
+		return nil
+	})
+}
+
+// Системная операция: затрагивает записи всех пользователей
+func (r *memoryRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.update(ctx, func() error {
+		for _, records := range r.records {
+			for _, record := range records {
+				if isTrashed(record.summary) && record.summary.DeletedAt.Before(cutoff.UTC()) {
+					r.remove(record)
+					purged++
+				}
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// --- Revisions ---
+
+// Ревизии записи, новые первыми. История удаленной записи пуста.
+func (r *memoryRepository) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	var revisions []*models.EntryRevision
+	err := r.view(ctx, func() error {
+		record, ok := r.record(userID, entryID, isActive)
+		if !ok {
+			return nil
+		}
+		for i := len(record.revisions) - 1; i >= 0; i-- {
+			revisions = append(revisions, cloneRevision(record.revisions[i]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *memoryRepository) ReadRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	var revision *models.EntryRevision
+	err := r.view(ctx, func() error {
+		record, ok := r.record(userID, entryID, isActive)
+		if !ok || number < 1 || number > len(record.revisions) {
+			return ErrRevisionNotFound
+		}
+		revision = cloneRevision(record.revisions[number-1])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// Добавляет ревизию, если заголовок или текст записи отличаются от последней
+func (r *memoryRepository) recordRevision(record *memoryRecord) {
+	latest := record.revisions[len(record.revisions)-1]
+	if latest.Title == record.entry.Title && latest.Content == record.entry.Content {
+		return
+	}
+	r.addRevision(record)
+}
+
+// Добавляет ревизию с текущим заголовком и текстом записи
+func (r *memoryRepository) addRevision(record *memoryRecord) {
+	record.revisions = append(record.revisions, &models.EntryRevision{
+		ID:        uuid.New(),
+		EntryID:   record.entry.ID,
+		Number:    len(record.revisions) + 1,
+		Title:     record.entry.Title,
+		Content:   record.entry.Content,
+		CreatedAt: r.now(),
+	})
+}
+
+// --- Tokens ---
+
+func (r *memoryRepository) CreateToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.update(ctx, func() error {
+		if tokenConflicts(r.tokens, token) {
+			return errTokenExists
+		}
+		r.tokens[token.ID] = prepareToken(token, r.now())
+		return nil
+	})
+}
+
+func (r *memoryRepository) ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	var tokens []*models.PersonalAccessToken
+	err := r.view(ctx, func() error {
+		for _, token := range r.tokens {
+			if token.UserID == userID {
+				tokens = append(tokens, cloneToken(token))
+			}
+		}
+		return nil
+	})
+	sortTokens(tokens)
+	return tokens, err
+}
+
+func (r *memoryRepository) ReadTokenByHash(ctx context.Context, secretHash string) (*models.PersonalAccessToken, error) {
+	var found *models.PersonalAccessToken
+	err := r.view(ctx, func() error {
+		for _, token := range r.tokens {
+			if token.SecretHash == secretHash {
+				found = cloneToken(token)
+				return nil
+			}
+		}
+		return ErrTokenNotFound
+	})
+	return found, err
+}
+
+func (r *memoryRepository) DeleteToken(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.update(ctx, func() error {
+		tokenID, ok := parseID(id)
+		if !ok {
+			return ErrTokenNotFound
+		}
+		token, ok := r.tokens[tokenID]
+		if !ok || token.UserID != userID {
+			return ErrTokenNotFound
+		}
+		delete(r.tokens, tokenID)
+		return nil
+	})
+}
+
+func (r *memoryRepository) TouchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.update(ctx, func() error {
+		if token, ok := r.tokens[id]; ok {
+			usedAt := usedAt.UTC()
+			token.LastUsedAt = &usedAt
+		}
+		return nil
+	})
+}
+
+// --- Entry Keys ---
+
+func (r *memoryRepository) PutEntryKey(ctx context.Context, key *models.EntryKey) error {
+	return r.update(ctx, func() error {
+		id := memoryKeyID{userID: key.UserID, keyID: key.KeyID}
+		r.keys[id] = prepareEntryKey(key, r.keys[id], r.now())
+		return nil
+	})
+}
+
+func (r *memoryRepository) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	var keys []*models.EntryKey
+	err := r.view(ctx, func() error {
+		for _, key := range r.keys {
+			if key.UserID == userID {
+				keys = append(keys, cloneEntryKey(key))
+			}
+		}
+		return nil
+	})
+	sortEntryKeys(keys)
+	return keys, err
+}
+
+func (r *memoryRepository) ReadEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	var found *models.EntryKey
+	err := r.view(ctx, func() error {
+		key, ok := r.keys[memoryKeyID{userID: userID, keyID: keyID}]
+		if !ok {
+			return ErrEntryKeyNotFound
+		}
+		found = cloneEntryKey(key)
+		return nil
+	})
+	return found, err
+}
+
+// --- Usage ---
+
+func (r *memoryRepository) ListUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	usage := make(usageByUser)
+	err := r.view(ctx, func() error {
+		for userID, records := range r.records {
+			for _, record := range records {
+				countEntry(usage, userID, record.summary)
+			}
+		}
+		for _, token := range r.tokens {
+			usage.user(token.UserID).Tokens++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage.list(), nil
+}
+
+// --- Снимок на диске ---
+
+// Содержимое хранилища в JSON: записи, включая корзину, их ревизии,
+// токены доступа и обернутые ключи шифрования
+type memorySnapshot struct {
+	Entries   []*models.Entry               `json:"entries"`
+	Revisions []*models.EntryRevision       `json:"revisions"`
+	Tokens    []*models.PersonalAccessToken `json:"tokens"`
+	EntryKeys []*models.EntryKey            `json:"entry_keys"`
+}
+
+func (r *memoryRepository) SaveSnapshot() error {
+	if r.snapshot == "" {
+		return nil
+	}
+
+	r.mu.RLock()
+	snapshot := memorySnapshot{
+		Entries:   []*models.Entry{},
+		Revisions: []*models.EntryRevision{},
+		Tokens:    []*models.PersonalAccessToken{},
+		EntryKeys: []*models.EntryKey{},
+	}
+	for _, records := range r.records {
+		for _, record := range records {
+			snapshot.Entries = append(snapshot.Entries, record.entry)
+			snapshot.Revisions = append(snapshot.Revisions, record.revisions...)
+		}
+	}
+	for _, token := range r.tokens {
+		snapshot.Tokens = append(snapshot.Tokens, token)
+	}
+	sortTokens(snapshot.Tokens)
+	for _, key := range r.keys {
+		snapshot.EntryKeys = append(snapshot.EntryKeys, key)
+	}
+	sort.Slice(snapshot.EntryKeys, func(i, j int) bool {
+		a, b := snapshot.EntryKeys[i], snapshot.EntryKeys[j]
+		if a.UserID != b.UserID {
+			return a.UserID.String() < b.UserID.String()
+		}
+		return a.KeyID < b.KeyID
+	})
+	// Стабильный порядок, чтобы снимки удобно было сравнивать
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		a, b := snapshot.Entries[i], snapshot.Entries[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	sort.Slice(snapshot.Revisions, func(i, j int) bool {
+		a, b := snapshot.Revisions[i], snapshot.Revisions[j]
+		if a.EntryID != b.EntryID {
+			return a.EntryID.String() < b.EntryID.String()
+		}
+		return a.Number < b.Number
+	})
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	r.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.snapshot), 0o700); err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	if err := writeFileAtomic(r.snapshot, data); err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (r *memoryRepository) loadSnapshot() error {
+	data, err := os.ReadFile(r.snapshot)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load snapshot: %w", err)
+	}
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("parse snapshot %s: %w", r.snapshot, err)
+	}
+
+	for _, token := range snapshot.Tokens {
+		r.tokens[token.ID] = cloneToken(token)
+	}
+	for _, key := range snapshot.EntryKeys {
+		r.keys[memoryKeyID{userID: key.UserID, keyID: key.KeyID}] = cloneEntryKey(key)
+	}
+	for _, entry := range snapshot.Entries {
+		record := &memoryRecord{}
+		r.store(record, entry)
+		r.put(record)
+	}
+	sort.Slice(snapshot.Revisions, func(i, j int) bool {
+		return snapshot.Revisions[i].Number < snapshot.Revisions[j].Number
+	})
+	for _, revision := range snapshot.Revisions {
+		if owner, ok := r.owners[revision.EntryID]; ok {
+			record := r.records[owner][revision.EntryID]
+			record.revisions = append(record.revisions, revision)
+		}
+	}
+	// Ревизии нумеруются подряд; у записи без истории она начинается заново
+	for _, records := range r.records {
+		for _, record := range records {
+			for i, revision := range record.revisions {
+				if revision.Number != i+1 {
+					return fmt.Errorf("parse snapshot %s: entry %s: revision %d out of sequence", r.snapshot, record.entry.ID, revision.Number)
+				}
+			}
+			if len(record.revisions) == 0 {
+				r.addRevision(record)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryRepositoryTestSuite struct {
+	suite.Suite
+	repo   MemoryRepository
+	userID uuid.UUID
+}
+
+func (suite *MemoryRepositoryTestSuite) SetupTest() {
+	suite.repo = NewMemoryRepository()
+	suite.userID = uuid.New()
+}
+
+func (suite *MemoryRepositoryTestSuite) createEntry(title string, tags ...string) *models.Entry {
+	entry := &models.Entry{ID: uuid.New(), UserID: suite.userID, Title: title, Content: "Content of " + title, EntryDate: time.Now()}
+	for _, name := range tags {
+		entry.Tags = append(entry.Tags, models.Tag{Name: name})
+	}
+	suite.Require().NoError(suite.repo.Create(context.Background(), entry))
+	return entry
+}
+
+func (suite *MemoryRepositoryTestSuite) TestReturnsCopies() {
+	// Arrange
+	mood := 3
+	entry := suite.createEntry("Original", "tag")
+	entry.Mood = &mood
+
+	// Act - изменения переданной и прочитанной записи не попадают в хранилище
+	entry.Title = "Changed by caller"
+	found, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	found.Title = "Changed after read"
+	found.Tags[0].Name = "changed"
+
+	// Assert
+	again, err := suite.repo.Read(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Original", again.Title)
+	assert.Equal(suite.T(), []string{"tag"}, tagNames(again.Tags))
+	assert.Nil(suite.T(), again.Mood)
+}
+
+func (suite *MemoryRepositoryTestSuite) TestTrashAndRevisions() {
+	// Arrange
+	entry := suite.createEntry("Draft")
+	entry.Title = "Final"
+	suite.Require().NoError(suite.repo.Update(context.Background(), entry))
+
+	// Act
+	revisions, err := suite.repo.ListRevisions(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, entry.ID.String()))
+	trash, err := suite.repo.ListTrash(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	hidden, err := suite.repo.ListRevisions(context.Background(), suite.userID, entry.ID.String())
+	suite.Require().NoError(err)
+
+	// Assert
+	suite.Require().Len(revisions, 2)
+	assert.Equal(suite.T(), "Final", revisions[0].Title)
+	assert.Equal(suite.T(), 1, revisions[1].Number)
+	suite.Require().Len(trash, 1)
+	assert.True(suite.T(), trash[0].DeletedAt.Valid)
+	assert.Empty(suite.T(), hidden)
+
+	// Восстановление и окончательное удаление
+	suite.Require().NoError(suite.repo.Restore(context.Background(), suite.userID, entry.ID.String()))
+	assert.ErrorIs(suite.T(), suite.repo.Purge(context.Background(), suite.userID, entry.ID.String()), ErrTrashedEntryNotFound)
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, entry.ID.String()))
+	purged, err := suite.repo.PurgeDeletedBefore(context.Background(), time.Now().Add(time.Hour))
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), purged)
+	_, err = suite.repo.ReadTrashed(context.Background(), suite.userID, entry.ID.String())
+	assert.ErrorIs(suite.T(), err, ErrTrashedEntryNotFound)
+}
+
+func (suite *MemoryRepositoryTestSuite) TestTagsAndSearch() {
+	// Arrange
+	suite.createEntry("Mountains", "ideas")
+	suite.createEntry("Notes", "work")
+
+	// Act
+	suite.Require().NoError(suite.repo.MergeTags(context.Background(), suite.userID, []string{"ideas"}, "work"))
+	counts, err := suite.repo.ListTags(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	results, err := suite.repo.Search(context.Background(), suite.userID, "mountain*", 10)
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Equal(suite.T(), []*models.TagCount{{Name: "work", Count: 2}}, counts)
+	suite.Require().Len(results, 1)
+	assert.Contains(suite.T(), results[0].TitleSnippet, "<mark>Mountain</mark>s")
+	assert.ErrorIs(suite.T(), suite.repo.RenameTag(context.Background(), suite.userID, "ideas", "x"), ErrTagNotFound)
+}
+
+func (suite *MemoryRepositoryTestSuite) TestRecordsKeyedByUser() {
+	// Arrange
+	entry := suite.createEntry("Mine")
+	other := uuid.New()
+	foreign := &models.Entry{ID: entry.ID, UserID: other, Title: "Theirs", Content: "Text", EntryDate: time.Now()}
+
+	// Act - ID занят записью другого пользователя; после окончательного
+	// удаления он свободен
+	duplicateErr := suite.repo.Create(context.Background(), foreign)
+	suite.Require().NoError(suite.repo.Delete(context.Background(), suite.userID, entry.ID.String()))
+	suite.Require().NoError(suite.repo.Purge(context.Background(), suite.userID, entry.ID.String()))
+	reuseErr := suite.repo.Create(context.Background(), foreign)
+
+	// Assert
+	assert.ErrorIs(suite.T(), duplicateErr, errEntryExists)
+	suite.Require().NoError(reuseErr)
+	mine, err := suite.repo.List(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	theirs, err := suite.repo.List(context.Background(), other)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), mine)
+	assert.Len(suite.T(), theirs, 1)
+}
+
+func (suite *MemoryRepositoryTestSuite) TestSaveSnapshotWithoutFile() {
+	// Act & Assert - хранилище без снимка ничего не пишет
+	assert.NoError(suite.T(), suite.repo.SaveSnapshot())
+}
+
+func TestMemoryRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryRepositoryTestSuite))
+}
+
+// --- Снимок ---
+
+func TestMemorySnapshotRoundTrip(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "demo", "snapshot.json")
+	repo, err := OpenMemoryRepository(path)
+	assert.NoError(t, err)
+	userID := uuid.New()
+	mood := 4
+	kept := &models.Entry{ID: uuid.New(), UserID: userID, Title: "Draft", Content: "Text", EntryDate: time.Now(), Mood: &mood, Tags: []models.Tag{{Name: "tag"}}}
+	trashed := &models.Entry{ID: uuid.New(), UserID: userID, Title: "Trashed", Content: "Text", EntryDate: time.Now()}
+	assert.NoError(t, repo.Create(ctx, kept))
+	assert.NoError(t, repo.Create(ctx, trashed))
+	kept.Title = "Final"
+	assert.NoError(t, repo.Update(ctx, kept))
+	assert.NoError(t, repo.Delete(ctx, userID, trashed.ID.String()))
+	token := &models.PersonalAccessToken{ID: uuid.New(), UserID: userID, Name: "cron", SecretHash: "hash", Scopes: "entries:read"}
+	assert.NoError(t, repo.CreateToken(ctx, token))
+	key := &models.EntryKey{UserID: userID, KeyID: "main", Algorithm: models.EncryptionA256GCM, WrappedKey: []byte("wrapped")}
+	assert.NoError(t, repo.PutEntryKey(ctx, key))
+
+	// Act
+	assert.NoError(t, repo.SaveSnapshot())
+	reopened, err := OpenMemoryRepository(path)
+
+	// Assert - записи, корзина, ревизии, токены и ключи переживают перезапуск
+	assert.NoError(t, err)
+	found, err := reopened.Read(ctx, userID, kept.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Final", found.Title)
+	assert.Equal(t, 2, found.Version)
+	assert.Equal(t, 4, *found.Mood)
+	assert.Equal(t, []string{"tag"}, tagNames(found.Tags))
+	assert.True(t, kept.EntryDate.Equal(found.EntryDate))
+
+	trash, err := reopened.ListTrash(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	revisions, err := reopened.ListRevisions(ctx, userID, kept.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+
+	stored, err := reopened.ReadTokenByHash(ctx, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, stored.ID)
+
+	storedKey, err := reopened.ReadEntryKey(ctx, userID, "main")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("wrapped"), storedKey.WrappedKey)
+
+	// Нумерация ревизий продолжается после загрузки
+	found.Content = "Edited after restart"
+	assert.NoError(t, reopened.Update(ctx, found))
+	latest, err := reopened.ReadRevision(ctx, userID, kept.ID.String(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "Edited after restart", latest.Content)
+}
+
+func TestMemorySnapshotMissingFile(t *testing.T) {
+	// Act
+	repo, err := OpenMemoryRepository(filepath.Join(t.TempDir(), "absent.json"))
+
+	// Assert - первый запуск начинается с пустого хранилища
+	assert.NoError(t, err)
+	entries, err := repo.List(context.Background(), uuid.New())
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMemorySnapshotCorrupt(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	// Act
+	repo, err := OpenMemoryRepository(path)
+
+	// Assert - поврежденный снимок не заменяется молча пустым хранилищем
+	assert.Error(t, err)
+	assert.Nil(t, repo)
+}
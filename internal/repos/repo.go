@@ -1,48 +1,179 @@
 package repos
 
 import (
+	"context"
 	"diary/internal/models"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // Главный интерфейс объединяет все подрепозитории
 type Repository interface {
 	EntryRepository
+	SearchRepository
+	TagRepository
+	StatsRepository
+	TrashRepository
+	RevisionRepository
+	TokenRepository
+	UsageRepository
+	EntryKeyRepository
 }
 
 // --- Комбинирующий репозиторий ---
 
 type repository struct {
-	entryRepo EntryRepository
+	entryRepo    EntryRepository
+	searchRepo   SearchRepository
+	tagRepo      TagRepository
+	statsRepo    StatsRepository
+	trashRepo    TrashRepository
+	revisionRepo RevisionRepository
+	tokenRepo    TokenRepository
+	usageRepo    UsageRepository
+	keyRepo      EntryKeyRepository
 }
 
 // Прокси-методы EntryRepository
 
-func (r *repository) Create(entry *models.Entry) error {
-	return r.entryRepo.Create(entry)
+func (r *repository) Create(ctx context.Context, entry *models.Entry) error {
+	return r.entryRepo.Create(ctx, entry)
 }
 
-func (r *repository) Read(id string) (*models.Entry, error) {
-	return r.entryRepo.Read(id)
+func (r *repository) Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	return r.entryRepo.Read(ctx, userID, id)
 }
 
-func (r *repository) Update(entry *models.Entry) error {
-	return r.entryRepo.Update(entry)
+func (r *repository) Update(ctx context.Context, entry *models.Entry) error {
+	return r.entryRepo.Update(ctx, entry)
 }
 
-func (r *repository) Delete(id string) error {
-	return r.entryRepo.Delete(id)
+func (r *repository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.entryRepo.Delete(ctx, userID, id)
 }
 
-func (r *repository) List() ([]*models.Entry, error) {
-	return r.entryRepo.List()
+func (r *repository) List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	return r.entryRepo.List(ctx, userID)
+}
+
+func (r *repository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	return r.entryRepo.ListByUser(ctx, userID, filter)
+}
+
+// Прокси-методы SearchRepository
+
+func (r *repository) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	return r.searchRepo.Search(ctx, userID, query, limit)
+}
+
+// Прокси-методы TagRepository
+
+func (r *repository) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	return r.tagRepo.ListTags(ctx, userID)
+}
+
+func (r *repository) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	return r.tagRepo.RenameTag(ctx, userID, oldName, newName)
+}
+
+func (r *repository) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	return r.tagRepo.MergeTags(ctx, userID, sources, target)
+}
+
+// Прокси-методы StatsRepository
+
+func (r *repository) ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error) {
+	return r.statsRepo.ListMoodPoints(ctx, userID, from, to)
+}
+
+// Прокси-методы TrashRepository
+
+func (r *repository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	return r.trashRepo.ListTrash(ctx, userID)
+}
+
+func (r *repository) ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	return r.trashRepo.ReadTrashed(ctx, userID, id)
+}
+
+func (r *repository) Restore(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.trashRepo.Restore(ctx, userID, id)
+}
+
+func (r *repository) Purge(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.trashRepo.Purge(ctx, userID, id)
+}
+
+func (r *repository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.trashRepo.PurgeDeletedBefore(ctx, cutoff)
+}
+
+// Прокси-методы RevisionRepository
+
+func (r *repository) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	return r.revisionRepo.ListRevisions(ctx, userID, entryID)
+}
+
+func (r *repository) ReadRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	return r.revisionRepo.ReadRevision(ctx, userID, entryID, number)
+}
+
+// Прокси-методы TokenRepository
+
+func (r *repository) CreateToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.tokenRepo.CreateToken(ctx, token)
+}
+
+func (r *repository) ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	return r.tokenRepo.ListTokens(ctx, userID)
+}
+
+func (r *repository) ReadTokenByHash(ctx context.Context, secretHash string) (*models.PersonalAccessToken, error) {
+	return r.tokenRepo.ReadTokenByHash(ctx, secretHash)
+}
+
+func (r *repository) DeleteToken(ctx context.Context, userID uuid.UUID, id string) error {
+	return r.tokenRepo.DeleteToken(ctx, userID, id)
+}
+
+func (r *repository) TouchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.tokenRepo.TouchToken(ctx, id, usedAt)
+}
+
+// Прокси-методы UsageRepository
+
+func (r *repository) ListUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	return r.usageRepo.ListUsage(ctx)
+}
+
+// Прокси-методы EntryKeyRepository
+
+func (r *repository) PutEntryKey(ctx context.Context, key *models.EntryKey) error {
+	return r.keyRepo.PutEntryKey(ctx, key)
+}
+
+func (r *repository) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	return r.keyRepo.ListEntryKeys(ctx, userID)
+}
+
+func (r *repository) ReadEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	return r.keyRepo.ReadEntryKey(ctx, userID, keyID)
 }
 
 // --- Конструктор комбинирующего репозитория ---
 
 func NewRepository(db *gorm.DB) Repository {
 	return &repository{
-		entryRepo: NewEntryRepository(db),
+		entryRepo:    NewEntryRepository(db),
+		searchRepo:   NewSearchRepository(db),
+		tagRepo:      NewTagRepository(db),
+		statsRepo:    NewStatsRepository(db),
+		trashRepo:    NewTrashRepository(db),
+		revisionRepo: NewRevisionRepository(db),
+		tokenRepo:    NewTokenRepository(db),
+		usageRepo:    NewUsageRepository(db),
+		keyRepo:      NewEntryKeyRepository(db),
 	}
 }
@@ -1,6 +1,8 @@
 package repos
 
 import (
+	"context"
+	"diary/internal/database/dbtest"
 	"diary/internal/models"
 	"errors"
 	"testing"
@@ -19,37 +21,45 @@ type MockEntryRepository struct {
 	mock.Mock
 }
 
-func (m *MockEntryRepository) Create(entry *models.Entry) error {
+func (m *MockEntryRepository) Create(ctx context.Context, entry *models.Entry) error {
 	args := m.Called(entry)
 	return args.Error(0)
 }
 
-func (m *MockEntryRepository) Read(id string) (*models.Entry, error) {
-	args := m.Called(id)
+func (m *MockEntryRepository) Read(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	args := m.Called(userID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Entry), args.Error(1)
 }
 
-func (m *MockEntryRepository) Update(entry *models.Entry) error {
+func (m *MockEntryRepository) Update(ctx context.Context, entry *models.Entry) error {
 	args := m.Called(entry)
 	return args.Error(0)
 }
 
-func (m *MockEntryRepository) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockEntryRepository) Delete(ctx context.Context, userID uuid.UUID, id string) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
 
-func (m *MockEntryRepository) List() ([]*models.Entry, error) {
-	args := m.Called()
+func (m *MockEntryRepository) List(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	args := m.Called(userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.Entry), args.Error(1)
 }
 
+func (m *MockEntryRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	args := m.Called(userID, filter)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Entry), args.String(1), args.Error(2)
+}
+
 // --- Repository Test Suite ---
 
 type RepositoryTestSuite struct {
@@ -83,7 +93,7 @@ func (suite *RepositoryTestSuite) TestCreate() {
 	suite.mockEntryRepo.On("Create", entry).Return(nil)
 
 	// Act
-	err := suite.repo.Create(entry)
+	err := suite.repo.Create(context.Background(), entry)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -102,7 +112,7 @@ func (suite *RepositoryTestSuite) TestCreateError() {
 	suite.mockEntryRepo.On("Create", entry).Return(expectedError)
 
 	// Act
-	err := suite.repo.Create(entry)
+	err := suite.repo.Create(context.Background(), entry)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -119,10 +129,10 @@ func (suite *RepositoryTestSuite) TestRead() {
 		Content: "Test Content",
 	}
 
-	suite.mockEntryRepo.On("Read", entryID).Return(expectedEntry, nil)
+	suite.mockEntryRepo.On("Read", expectedEntry.UserID, entryID).Return(expectedEntry, nil)
 
 	// Act
-	entry, err := suite.repo.Read(entryID)
+	entry, err := suite.repo.Read(context.Background(), expectedEntry.UserID, entryID)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -131,13 +141,14 @@ func (suite *RepositoryTestSuite) TestRead() {
 
 func (suite *RepositoryTestSuite) TestReadError() {
 	// Arrange
+	userID := uuid.New()
 	entryID := uuid.New().String()
 	expectedError := gorm.ErrRecordNotFound
 
-	suite.mockEntryRepo.On("Read", entryID).Return(nil, expectedError)
+	suite.mockEntryRepo.On("Read", userID, entryID).Return(nil, expectedError)
 
 	// Act
-	entry, err := suite.repo.Read(entryID)
+	entry, err := suite.repo.Read(context.Background(), userID, entryID)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -157,7 +168,7 @@ func (suite *RepositoryTestSuite) TestUpdate() {
 	suite.mockEntryRepo.On("Update", entry).Return(nil)
 
 	// Act
-	err := suite.repo.Update(entry)
+	err := suite.repo.Update(context.Background(), entry)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -176,7 +187,7 @@ func (suite *RepositoryTestSuite) TestUpdateError() {
 	suite.mockEntryRepo.On("Update", entry).Return(expectedError)
 
 	// Act
-	err := suite.repo.Update(entry)
+	err := suite.repo.Update(context.Background(), entry)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -185,12 +196,13 @@ func (suite *RepositoryTestSuite) TestUpdateError() {
 
 func (suite *RepositoryTestSuite) TestDelete() {
 	// Arrange
+	userID := uuid.New()
 	entryID := uuid.New().String()
 
-	suite.mockEntryRepo.On("Delete", entryID).Return(nil)
+	suite.mockEntryRepo.On("Delete", userID, entryID).Return(nil)
 
 	// Act
-	err := suite.repo.Delete(entryID)
+	err := suite.repo.Delete(context.Background(), userID, entryID)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -198,13 +210,14 @@ func (suite *RepositoryTestSuite) TestDelete() {
 
 func (suite *RepositoryTestSuite) TestDeleteError() {
 	// Arrange
+	userID := uuid.New()
 	entryID := uuid.New().String()
 	expectedError := errors.New("delete failed")
 
-	suite.mockEntryRepo.On("Delete", entryID).Return(expectedError)
+	suite.mockEntryRepo.On("Delete", userID, entryID).Return(expectedError)
 
 	// Act
-	err := suite.repo.Delete(entryID)
+	err := suite.repo.Delete(context.Background(), userID, entryID)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -213,25 +226,26 @@ func (suite *RepositoryTestSuite) TestDeleteError() {
 
 func (suite *RepositoryTestSuite) TestList() {
 	// Arrange
+	userID := uuid.New()
 	expectedEntries := []*models.Entry{
 		{
 			ID:      uuid.New(),
-			UserID:  uuid.New(),
+			UserID:  userID,
 			Title:   "Entry 1",
 			Content: "Content 1",
 		},
 		{
 			ID:      uuid.New(),
-			UserID:  uuid.New(),
+			UserID:  userID,
 			Title:   "Entry 2",
 			Content: "Content 2",
 		},
 	}
 
-	suite.mockEntryRepo.On("List").Return(expectedEntries, nil)
+	suite.mockEntryRepo.On("List", userID).Return(expectedEntries, nil)
 
 	// Act
-	entries, err := suite.repo.List()
+	entries, err := suite.repo.List(context.Background(), userID)
 
 	// Assert
 	assert.NoError(suite.T(), err)
@@ -241,12 +255,13 @@ func (suite *RepositoryTestSuite) TestList() {
 
 func (suite *RepositoryTestSuite) TestListError() {
 	// Arrange
+	userID := uuid.New()
 	expectedError := errors.New("list failed")
 
-	suite.mockEntryRepo.On("List").Return(nil, expectedError)
+	suite.mockEntryRepo.On("List", userID).Return(nil, expectedError)
 
 	// Act
-	entries, err := suite.repo.List()
+	entries, err := suite.repo.List(context.Background(), userID)
 
 	// Assert
 	assert.Error(suite.T(), err)
@@ -256,18 +271,59 @@ func (suite *RepositoryTestSuite) TestListError() {
 
 func (suite *RepositoryTestSuite) TestListEmpty() {
 	// Arrange
+	userID := uuid.New()
 	expectedEntries := []*models.Entry{}
 
-	suite.mockEntryRepo.On("List").Return(expectedEntries, nil)
+	suite.mockEntryRepo.On("List", userID).Return(expectedEntries, nil)
 
 	// Act
-	entries, err := suite.repo.List()
+	entries, err := suite.repo.List(context.Background(), userID)
 
 	// Assert
 	assert.NoError(suite.T(), err)
 	assert.Empty(suite.T(), entries)
 }
 
+func (suite *RepositoryTestSuite) TestListByUser() {
+	// Arrange
+	userID := uuid.New()
+	expectedEntries := []*models.Entry{
+		{
+			ID:      uuid.New(),
+			UserID:  userID,
+			Title:   "Entry 1",
+			Content: "Content 1",
+		},
+	}
+
+	filter := models.EntryFilter{Cursor: "cursor", Limit: 10}
+	suite.mockEntryRepo.On("ListByUser", userID, filter).Return(expectedEntries, "next", nil)
+
+	// Act
+	entries, next, err := suite.repo.ListByUser(context.Background(), userID, filter)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expectedEntries, entries)
+	assert.Equal(suite.T(), "next", next)
+}
+
+func (suite *RepositoryTestSuite) TestListByUserError() {
+	// Arrange
+	userID := uuid.New()
+
+	filter := models.EntryFilter{Cursor: "bad", Limit: 10}
+	suite.mockEntryRepo.On("ListByUser", userID, filter).Return(nil, "", ErrInvalidCursor)
+
+	// Act
+	entries, next, err := suite.repo.ListByUser(context.Background(), userID, filter)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrInvalidCursor)
+	assert.Nil(suite.T(), entries)
+	assert.Empty(suite.T(), next)
+}
+
 func TestRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(RepositoryTestSuite))
 }
@@ -276,18 +332,14 @@ func TestRepositoryTestSuite(t *testing.T) {
 
 type RepositoryIntegrationTestSuite struct {
 	suite.Suite
-	db   *gorm.DB
-	repo Repository
+	backend dbtest.Backend
+	db      *gorm.DB
+	repo    Repository
 }
 
 func (suite *RepositoryIntegrationTestSuite) SetupSuite() {
-	// Создаем in-memory SQLite базу данных для интеграционных тестов
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	suite.Require().NoError(err)
-
-	// Автомиграция
-	err = db.AutoMigrate(&models.Entry{})
-	suite.Require().NoError(err)
+	// Интеграционные тесты выполняются на каждом доступном бэкенде
+	db := openTestDB(suite.T(), suite.backend)
 
 	suite.db = db
 	suite.repo = NewRepository(db)
@@ -308,11 +360,11 @@ func (suite *RepositoryIntegrationTestSuite) TestCompleteWorkflow() {
 	}
 
 	// Act & Assert - Create
-	err := suite.repo.Create(entry)
+	err := suite.repo.Create(context.Background(), entry)
 	assert.NoError(suite.T(), err)
 
 	// Act & Assert - Read
-	readEntry, err := suite.repo.Read(entry.ID.String())
+	readEntry, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), entry.Title, readEntry.Title)
 	assert.Equal(suite.T(), entry.Content, readEntry.Content)
@@ -320,54 +372,55 @@ func (suite *RepositoryIntegrationTestSuite) TestCompleteWorkflow() {
 	// Act & Assert - Update
 	readEntry.Title = "Updated Integration Test Entry"
 	readEntry.Content = "Updated Integration Test Content"
-	err = suite.repo.Update(readEntry)
+	err = suite.repo.Update(context.Background(), readEntry)
 	assert.NoError(suite.T(), err)
 
 	// Verify update
-	updatedEntry, err := suite.repo.Read(entry.ID.String())
+	updatedEntry, err := suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), "Updated Integration Test Entry", updatedEntry.Title)
 	assert.Equal(suite.T(), "Updated Integration Test Content", updatedEntry.Content)
 
 	// Act & Assert - List
-	entries, err := suite.repo.List()
+	entries, err := suite.repo.List(context.Background(), entry.UserID)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), entries, 1)
 	assert.Equal(suite.T(), updatedEntry.ID, entries[0].ID)
 
 	// Act & Assert - Delete
-	err = suite.repo.Delete(entry.ID.String())
+	err = suite.repo.Delete(context.Background(), entry.UserID, entry.ID.String())
 	assert.NoError(suite.T(), err)
 
 	// Verify deletion
-	_, err = suite.repo.Read(entry.ID.String())
+	_, err = suite.repo.Read(context.Background(), entry.UserID, entry.ID.String())
 	assert.Error(suite.T(), err)
-	assert.Equal(suite.T(), gorm.ErrRecordNotFound, err)
+	assert.ErrorIs(suite.T(), err, ErrEntryNotFound)
 
 	// Verify list is empty
-	entries, err = suite.repo.List()
+	entries, err = suite.repo.List(context.Background(), entry.UserID)
 	assert.NoError(suite.T(), err)
 	assert.Empty(suite.T(), entries)
 }
 
 func (suite *RepositoryIntegrationTestSuite) TestMultipleEntries() {
 	// Arrange
+	userID := uuid.New()
 	entries := []*models.Entry{
 		{
 			ID:      uuid.New(),
-			UserID:  uuid.New(),
+			UserID:  userID,
 			Title:   "Entry 1",
 			Content: "Content 1",
 		},
 		{
 			ID:      uuid.New(),
-			UserID:  uuid.New(),
+			UserID:  userID,
 			Title:   "Entry 2",
 			Content: "Content 2",
 		},
 		{
 			ID:      uuid.New(),
-			UserID:  uuid.New(),
+			UserID:  userID,
 			Title:   "Entry 3",
 			Content: "Content 3",
 		},
@@ -375,35 +428,37 @@ func (suite *RepositoryIntegrationTestSuite) TestMultipleEntries() {
 
 	// Act - Create multiple entries
 	for _, entry := range entries {
-		err := suite.repo.Create(entry)
+		err := suite.repo.Create(context.Background(), entry)
 		assert.NoError(suite.T(), err)
 	}
 
 	// Assert - List all entries
-	allEntries, err := suite.repo.List()
+	allEntries, err := suite.repo.List(context.Background(), userID)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), allEntries, 3)
 
 	// Act & Assert - Read each entry
 	for _, originalEntry := range entries {
-		readEntry, err := suite.repo.Read(originalEntry.ID.String())
+		readEntry, err := suite.repo.Read(context.Background(), originalEntry.UserID, originalEntry.ID.String())
 		assert.NoError(suite.T(), err)
 		assert.Equal(suite.T(), originalEntry.Title, readEntry.Title)
 		assert.Equal(suite.T(), originalEntry.Content, readEntry.Content)
 	}
 
 	// Act & Assert - Delete one entry
-	err = suite.repo.Delete(entries[1].ID.String())
+	err = suite.repo.Delete(context.Background(), entries[1].UserID, entries[1].ID.String())
 	assert.NoError(suite.T(), err)
 
 	// Verify only 2 entries remain
-	remainingEntries, err := suite.repo.List()
+	remainingEntries, err := suite.repo.List(context.Background(), userID)
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), remainingEntries, 2)
 }
 
 func TestRepositoryIntegrationTestSuite(t *testing.T) {
-	suite.Run(t, new(RepositoryIntegrationTestSuite))
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &RepositoryIntegrationTestSuite{backend: backend})
+	})
 }
 
 // --- Constructor Tests ---
@@ -439,7 +494,7 @@ func TestNewRepositoryWithNilDB(t *testing.T) {
 	}
 
 	assert.Panics(t, func() {
-		repo.Create(entry)
+		repo.Create(context.Background(), entry)
 	})
 }
 
@@ -456,22 +511,28 @@ func TestRepositoryImplementsInterface(t *testing.T) {
 	assert.Implements(t, (*EntryRepository)(nil), repo)
 
 	// Проверяем наличие всех методов
-	_, ok := repo.(interface{ Create(*models.Entry) error })
+	_, ok := repo.(interface {
+		Create(context.Context, *models.Entry) error
+	})
 	assert.True(t, ok)
 
 	_, ok = repo.(interface {
-		Read(string) (*models.Entry, error)
+		Read(context.Context, uuid.UUID, string) (*models.Entry, error)
 	})
 	assert.True(t, ok)
 
-	_, ok = repo.(interface{ Update(*models.Entry) error })
+	_, ok = repo.(interface {
+		Update(context.Context, *models.Entry) error
+	})
 	assert.True(t, ok)
 
-	_, ok = repo.(interface{ Delete(string) error })
+	_, ok = repo.(interface {
+		Delete(context.Context, uuid.UUID, string) error
+	})
 	assert.True(t, ok)
 
 	_, ok = repo.(interface {
-		List() ([]*models.Entry, error)
+		List(context.Context, uuid.UUID) ([]*models.Entry, error)
 	})
 	assert.True(t, ok)
 }
@@ -0,0 +1,186 @@
+package repotest
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Создает пустое хранилище ключей шифрования для одного теста
+type NewEntryKeyRepository func(t *testing.T) repos.EntryKeyRepository
+
+// RunEntryKeyRepository запускает набор для реализации, которую создает newRepo
+func RunEntryKeyRepository(t *testing.T, newRepo NewEntryKeyRepository) {
+	suite.Run(t, &EntryKeyRepositorySuite{NewRepository: newRepo})
+}
+
+type EntryKeyRepositorySuite struct {
+	suite.Suite
+	NewRepository NewEntryKeyRepository
+
+	repo   repos.EntryKeyRepository
+	ctx    context.Context
+	userID uuid.UUID
+}
+
+func (s *EntryKeyRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "repotest: NewRepository is not set")
+	s.repo = s.NewRepository(s.T())
+	s.ctx = context.Background()
+	s.userID = uuid.New()
+}
+
+func (s *EntryKeyRepositorySuite) newKey(userID uuid.UUID, keyID string, createdAt time.Time) *models.EntryKey {
+	return &models.EntryKey{
+		UserID:     userID,
+		KeyID:      keyID,
+		Algorithm:  models.EncryptionA256GCM,
+		Nonce:      []byte("nonce-of-key"),
+		WrappedKey: []byte("wrapped " + keyID),
+		KDF:        models.KDFPBKDF2SHA256,
+		Salt:       []byte("salt-of-sixteen!"),
+		Iterations: 600000,
+		CreatedAt:  at(createdAt),
+	}
+}
+
+func (s *EntryKeyRepositorySuite) put(keyID string, createdAt time.Time) *models.EntryKey {
+	key := s.newKey(s.userID, keyID, createdAt)
+	s.Require().NoError(s.repo.PutEntryKey(s.ctx, key))
+	return key
+}
+
+func (s *EntryKeyRepositorySuite) TestPutAndRead() {
+	// Arrange
+	key := s.put("main", time.Now())
+
+	// Act
+	found, err := s.repo.ReadEntryKey(s.ctx, s.userID, "main")
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), s.userID, found.UserID)
+	assert.Equal(s.T(), "main", found.KeyID)
+	assert.Equal(s.T(), key.Algorithm, found.Algorithm)
+	assert.Equal(s.T(), key.Nonce, found.Nonce)
+	assert.Equal(s.T(), key.WrappedKey, found.WrappedKey)
+	assert.Equal(s.T(), key.KDF, found.KDF)
+	assert.Equal(s.T(), key.Salt, found.Salt)
+	assert.Equal(s.T(), key.Iterations, found.Iterations)
+	assert.True(s.T(), key.CreatedAt.Equal(found.CreatedAt))
+	assert.False(s.T(), found.UpdatedAt.IsZero())
+}
+
+func (s *EntryKeyRepositorySuite) TestReadMissingOrForeign() {
+	// Arrange
+	s.put("main", time.Now())
+
+	// Act
+	_, missingErr := s.repo.ReadEntryKey(s.ctx, s.userID, "other")
+	_, foreignErr := s.repo.ReadEntryKey(s.ctx, uuid.New(), "main")
+
+	// Assert - чужой ключ не отличается от несуществующего
+	assert.ErrorIs(s.T(), missingErr, repos.ErrEntryKeyNotFound)
+	assert.ErrorIs(s.T(), missingErr, errs.ErrNotFound)
+	assert.ErrorIs(s.T(), foreignErr, repos.ErrEntryKeyNotFound)
+}
+
+func (s *EntryKeyRepositorySuite) TestPutReplacesWrapping() {
+	// Arrange
+	createdAt := time.Now().Add(-time.Hour)
+	original := s.put("main", createdAt)
+	rewrapped := s.newKey(s.userID, "main", time.Now())
+	rewrapped.Nonce = []byte("other-nonce!")
+	rewrapped.WrappedKey = []byte("rewrapped")
+	rewrapped.Salt = []byte("another-salt-16b")
+	rewrapped.Iterations = 700000
+
+	// Act - например, после смены пароля
+	s.Require().NoError(s.repo.PutEntryKey(s.ctx, rewrapped))
+	found, err := s.repo.ReadEntryKey(s.ctx, s.userID, "main")
+
+	// Assert - обертка новая, время создания прежнее
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []byte("rewrapped"), found.WrappedKey)
+	assert.Equal(s.T(), []byte("other-nonce!"), found.Nonce)
+	assert.Equal(s.T(), []byte("another-salt-16b"), found.Salt)
+	assert.Equal(s.T(), 700000, found.Iterations)
+	assert.True(s.T(), original.CreatedAt.Equal(found.CreatedAt))
+	keys, err := s.repo.ListEntryKeys(s.ctx, s.userID)
+	s.Require().NoError(err)
+	assert.Len(s.T(), keys, 1)
+}
+
+func (s *EntryKeyRepositorySuite) TestListInCreationOrder() {
+	// Arrange
+	base := time.Now().Add(-time.Hour)
+	s.put("second", base.Add(time.Minute))
+	s.put("first", base)
+	s.Require().NoError(s.repo.PutEntryKey(s.ctx, s.newKey(uuid.New(), "foreign", base)))
+
+	// Act
+	keys, err := s.repo.ListEntryKeys(s.ctx, s.userID)
+
+	// Assert - только ключи пользователя
+	s.Require().NoError(err)
+	s.Require().Len(keys, 2)
+	assert.Equal(s.T(), "first", keys[0].KeyID)
+	assert.Equal(s.T(), "second", keys[1].KeyID)
+}
+
+func (s *EntryKeyRepositorySuite) TestSameKeyIDForDifferentUsers() {
+	// Arrange
+	otherID := uuid.New()
+	s.put("main", time.Now())
+	other := s.newKey(otherID, "main", time.Now())
+	other.WrappedKey = []byte("other user")
+
+	// Act
+	s.Require().NoError(s.repo.PutEntryKey(s.ctx, other))
+	mine, err := s.repo.ReadEntryKey(s.ctx, s.userID, "main")
+
+	// Assert - идентификатор ключа уникален только у одного пользователя
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []byte("wrapped main"), mine.WrappedKey)
+}
+
+func (s *EntryKeyRepositorySuite) TestReturnsCopies() {
+	// Arrange
+	key := s.put("main", time.Now())
+
+	// Act - изменения переданного и прочитанного ключа не попадают в хранилище
+	key.WrappedKey[0] = 'X'
+	found, err := s.repo.ReadEntryKey(s.ctx, s.userID, "main")
+	s.Require().NoError(err)
+	found.Salt[0] = 'X'
+
+	// Assert
+	again, err := s.repo.ReadEntryKey(s.ctx, s.userID, "main")
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []byte("wrapped main"), again.WrappedKey)
+	assert.Equal(s.T(), []byte("salt-of-sixteen!"), again.Salt)
+}
+
+func (s *EntryKeyRepositorySuite) TestCanceledContext() {
+	// Arrange
+	key := s.newKey(s.userID, "never", time.Now())
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	// Act
+	putErr := s.repo.PutEntryKey(ctx, key)
+	_, listErr := s.repo.ListEntryKeys(ctx, s.userID)
+
+	// Assert
+	assert.ErrorIs(s.T(), putErr, context.Canceled)
+	assert.ErrorIs(s.T(), listErr, context.Canceled)
+	_, err := s.repo.ReadEntryKey(s.ctx, s.userID, "never")
+	assert.ErrorIs(s.T(), err, repos.ErrEntryKeyNotFound)
+}
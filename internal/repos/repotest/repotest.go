@@ -0,0 +1,639 @@
+// Package repotest - общие наборы тестов, которым должна соответствовать
+// любая реализация repos.EntryRepository, repos.TokenRepository,
+// repos.UsageRepository и repos.EntryKeyRepository: gorm (SQLite,
+// PostgreSQL), файловое хранилище и другие.
+//
+// Набор проверяет только поведение, видимое через интерфейс, поэтому
+// подходит для реализаций без базы данных:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.RunEntryRepository(t, func(t *testing.T) repos.EntryRepository {
+//			return newMyRepository(t)
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Создает пустое хранилище для одного теста; ресурсы освобождаются через t.Cleanup
+type NewEntryRepository func(t *testing.T) repos.EntryRepository
+
+// RunEntryRepository запускает набор для реализации, которую создает newRepo
+func RunEntryRepository(t *testing.T, newRepo NewEntryRepository) {
+	suite.Run(t, &EntryRepositorySuite{NewRepository: newRepo})
+}
+
+type EntryRepositorySuite struct {
+	suite.Suite
+	NewRepository NewEntryRepository
+
+	repo   repos.EntryRepository
+	ctx    context.Context
+	userID uuid.UUID
+}
+
+func (s *EntryRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "repotest: NewRepository is not set")
+	s.repo = s.NewRepository(s.T())
+	s.ctx = context.Background()
+	s.userID = uuid.New()
+}
+
+// Время с точностью до микросекунд: столько хранят все реализации
+func at(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func (s *EntryRepositorySuite) newEntry(userID uuid.UUID, title string, entryDate time.Time, tags ...string) *models.Entry {
+	entry := &models.Entry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     title,
+		Content:   "Content of " + title,
+		EntryDate: at(entryDate),
+		TimeZone:  "UTC",
+	}
+	for _, name := range tags {
+		entry.Tags = append(entry.Tags, models.Tag{Name: name})
+	}
+	return entry
+}
+
+func (s *EntryRepositorySuite) create(title string, entryDate time.Time, tags ...string) *models.Entry {
+	entry := s.newEntry(s.userID, title, entryDate, tags...)
+	s.Require().NoError(s.repo.Create(s.ctx, entry))
+	return entry
+}
+
+func (s *EntryRepositorySuite) read(entry *models.Entry) *models.Entry {
+	found, err := s.repo.Read(s.ctx, entry.UserID, entry.ID.String())
+	s.Require().NoError(err)
+	return found
+}
+
+// Все страницы ListByUser подряд
+func (s *EntryRepositorySuite) listAll(filter models.EntryFilter) []*models.Entry {
+	var all []*models.Entry
+	for page := 0; ; page++ {
+		s.Require().Less(page, 100, "pagination does not terminate")
+		entries, next, err := s.repo.ListByUser(s.ctx, s.userID, filter)
+		s.Require().NoError(err)
+		all = append(all, entries...)
+		if next == "" {
+			return all
+		}
+		filter.Cursor = next
+	}
+}
+
+func titles(entries []*models.Entry) []string {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.Title)
+	}
+	return result
+}
+
+func tagNames(entry *models.Entry) []string {
+	names := make([]string, 0, len(entry.Tags))
+	for _, tag := range entry.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// --- CRUD ---
+
+func (s *EntryRepositorySuite) TestCreateAndRead() {
+	// Arrange
+	mood, energy := 4, 2
+	entry := s.newEntry(s.userID, "Вечер", time.Now().Add(-time.Hour), "work", "family")
+	entry.Content = "# Заметки\n\n---\nтекст\n"
+	entry.TimeZone = "Europe/Moscow"
+	entry.Mood, entry.Energy = &mood, &energy
+	entry.MoodEmoji, entry.MoodLabel = "🙂", "good"
+
+	// Act
+	err := s.repo.Create(s.ctx, entry)
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 1, entry.Version)
+	found := s.read(entry)
+	assert.Equal(s.T(), entry.ID, found.ID)
+	assert.Equal(s.T(), s.userID, found.UserID)
+	assert.Equal(s.T(), "Вечер", found.Title)
+	assert.Equal(s.T(), entry.Content, found.Content)
+	assert.True(s.T(), entry.EntryDate.Equal(found.EntryDate), "entry date %v != %v", found.EntryDate, entry.EntryDate)
+	assert.Equal(s.T(), "Europe/Moscow", found.TimeZone)
+	assert.Equal(s.T(), &mood, found.Mood)
+	assert.Equal(s.T(), &energy, found.Energy)
+	assert.Equal(s.T(), "🙂", found.MoodEmoji)
+	assert.Equal(s.T(), "good", found.MoodLabel)
+	assert.Equal(s.T(), []string{"family", "work"}, tagNames(found), "tags are returned sorted by name")
+	assert.Equal(s.T(), 1, found.Version)
+	assert.False(s.T(), found.CreatedAt.IsZero())
+	assert.False(s.T(), found.UpdatedAt.IsZero())
+	assert.False(s.T(), found.DeletedAt.Valid)
+}
+
+func (s *EntryRepositorySuite) TestEncryptedRoundTrip() {
+	// Arrange - текст зашифрован на клиенте, сервер хранит байты как есть
+	entry := s.newEntry(s.userID, "", time.Now(), "work")
+	entry.Content = ""
+	entry.Algorithm = models.EncryptionA256GCM
+	entry.KeyID = "main"
+	entry.Nonce = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	entry.Ciphertext = []byte{0xff, 0x00, '\n', '-', '-', '-', '\n', 0x80}
+
+	// Act
+	s.Require().NoError(s.repo.Create(s.ctx, entry))
+	found := s.read(entry)
+	found.Ciphertext = append(found.Ciphertext, 0x01)
+	s.Require().NoError(s.repo.Update(s.ctx, found))
+	updated := s.read(entry)
+
+	// Assert
+	assert.True(s.T(), updated.Encrypted())
+	assert.Equal(s.T(), models.EncryptionA256GCM, updated.Algorithm)
+	assert.Equal(s.T(), "main", updated.KeyID)
+	assert.Equal(s.T(), entry.Nonce, updated.Nonce)
+	assert.Equal(s.T(), append(entry.Ciphertext, 0x01), updated.Ciphertext)
+	assert.Empty(s.T(), updated.Title)
+	assert.Empty(s.T(), updated.Content)
+	assert.Equal(s.T(), []string{"work"}, tagNames(updated))
+	assert.Equal(s.T(), 2, updated.Version)
+}
+
+func (s *EntryRepositorySuite) TestCreateKeepsCreatedAt() {
+	// Arrange - импорт записи со временем создания в прошлом
+	entry := s.newEntry(s.userID, "Imported", time.Now())
+	entry.CreatedAt = at(time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC))
+
+	// Act
+	s.Require().NoError(s.repo.Create(s.ctx, entry))
+
+	// Assert
+	assert.True(s.T(), entry.CreatedAt.Equal(s.read(entry).CreatedAt))
+}
+
+func (s *EntryRepositorySuite) TestCreateDuplicateID() {
+	// Arrange
+	entry := s.create("Original", time.Now())
+	duplicate := s.newEntry(s.userID, "Duplicate", time.Now())
+	duplicate.ID = entry.ID
+
+	// Act
+	err := s.repo.Create(s.ctx, duplicate)
+
+	// Assert - во всех хранилищах это конфликт (409), а не ошибка сервера
+	assert.ErrorIs(s.T(), err, errs.ErrConflict)
+	assert.Equal(s.T(), "Original", s.read(entry).Title)
+}
+
+func (s *EntryRepositorySuite) TestReadNotFound() {
+	// Act
+	_, missing := s.repo.Read(s.ctx, s.userID, uuid.New().String())
+	_, invalid := s.repo.Read(s.ctx, s.userID, "not-a-uuid")
+
+	// Assert
+	assert.ErrorIs(s.T(), missing, repos.ErrEntryNotFound)
+	assert.ErrorIs(s.T(), missing, errs.ErrNotFound)
+	assert.ErrorIs(s.T(), invalid, repos.ErrEntryNotFound)
+}
+
+func (s *EntryRepositorySuite) TestUpdate() {
+	// Arrange
+	entry := s.create("Draft", time.Now().AddDate(0, 0, -1), "old")
+	created := s.read(entry)
+
+	// Act
+	time.Sleep(5 * time.Millisecond)
+	entry.Title = "Final"
+	entry.Content = "Rewritten"
+	entry.EntryDate = at(time.Now().AddDate(0, 0, -2))
+	entry.Tags = []models.Tag{{Name: "new"}}
+	err := s.repo.Update(s.ctx, entry)
+
+	// Assert - изменения сохранены, версия увеличена, время создания не меняется
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 2, entry.Version)
+	found := s.read(entry)
+	assert.Equal(s.T(), "Final", found.Title)
+	assert.Equal(s.T(), "Rewritten", found.Content)
+	assert.True(s.T(), entry.EntryDate.Equal(found.EntryDate))
+	assert.Equal(s.T(), []string{"new"}, tagNames(found))
+	assert.Equal(s.T(), 2, found.Version)
+	assert.True(s.T(), created.CreatedAt.Equal(found.CreatedAt))
+	assert.True(s.T(), found.UpdatedAt.After(created.UpdatedAt))
+}
+
+func (s *EntryRepositorySuite) TestUpdateVersionConflict() {
+	// Arrange - два клиента прочитали одну версию
+	entry := s.create("Title", time.Now())
+	first, second := s.read(entry), s.read(entry)
+	first.Content = "From first device"
+	s.Require().NoError(s.repo.Update(s.ctx, first))
+
+	// Act
+	second.Content = "From second device"
+	err := s.repo.Update(s.ctx, second)
+
+	// Assert - второе изменение отклонено и не перезаписывает первое
+	assert.ErrorIs(s.T(), err, repos.ErrVersionConflict)
+	assert.ErrorIs(s.T(), err, errs.ErrConflict)
+	assert.Equal(s.T(), 1, second.Version)
+	found := s.read(entry)
+	assert.Equal(s.T(), "From first device", found.Content)
+	assert.Equal(s.T(), 2, found.Version)
+}
+
+func (s *EntryRepositorySuite) TestUpdateMissingCreates() {
+	// Arrange
+	entry := s.newEntry(s.userID, "Upserted", time.Now())
+
+	// Act
+	err := s.repo.Update(s.ctx, entry)
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), 1, entry.Version)
+	assert.Equal(s.T(), "Upserted", s.read(entry).Title)
+}
+
+func (s *EntryRepositorySuite) TestDelete() {
+	// Arrange
+	kept := s.create("Kept", time.Now())
+	deleted := s.create("Deleted", time.Now())
+
+	// Act
+	err := s.repo.Delete(s.ctx, s.userID, deleted.ID.String())
+
+	// Assert
+	s.Require().NoError(err)
+	_, err = s.repo.Read(s.ctx, s.userID, deleted.ID.String())
+	assert.ErrorIs(s.T(), err, repos.ErrEntryNotFound)
+	list, err := s.repo.List(s.ctx, s.userID)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"Kept"}, titles(list))
+	assert.Equal(s.T(), []string{"Kept"}, titles(s.listAll(models.EntryFilter{})))
+	assert.Equal(s.T(), "Kept", s.read(kept).Title)
+}
+
+func (s *EntryRepositorySuite) TestDeleteIsIdempotent() {
+	// Arrange
+	entry := s.create("Once", time.Now())
+	s.Require().NoError(s.repo.Delete(s.ctx, s.userID, entry.ID.String()))
+
+	// Act & Assert - повторное удаление и удаление несуществующих записей не ошибка
+	assert.NoError(s.T(), s.repo.Delete(s.ctx, s.userID, entry.ID.String()))
+	assert.NoError(s.T(), s.repo.Delete(s.ctx, s.userID, uuid.New().String()))
+	assert.NoError(s.T(), s.repo.Delete(s.ctx, s.userID, "not-a-uuid"))
+}
+
+// --- Порядок и выборки ---
+
+func (s *EntryRepositorySuite) TestListNewestCreatedFirst() {
+	// Arrange
+	now := time.Now()
+	for i, title := range []string{"Old", "Middle", "New"} {
+		entry := s.newEntry(s.userID, title, now)
+		entry.CreatedAt = at(now.Add(time.Duration(i-3) * time.Hour))
+		s.Require().NoError(s.repo.Create(s.ctx, entry))
+	}
+
+	// Act
+	list, err := s.repo.List(s.ctx, s.userID)
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), []string{"New", "Middle", "Old"}, titles(list))
+}
+
+func (s *EntryRepositorySuite) TestListEmpty() {
+	// Act
+	list, err := s.repo.List(s.ctx, s.userID)
+	page, next, pageErr := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{})
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+	assert.NoError(s.T(), pageErr)
+	assert.Empty(s.T(), page)
+	assert.Empty(s.T(), next)
+}
+
+func (s *EntryRepositorySuite) TestListByUserDefaultOrder() {
+	// Arrange - запись, созданная позже, может относиться к более ранней дате
+	day := time.Now().AddDate(0, 0, -10)
+	s.create("Second", day.AddDate(0, 0, 1))
+	s.create("First", day)
+	s.create("Third", day.AddDate(0, 0, 2))
+
+	// Act
+	byEntryDate := s.listAll(models.EntryFilter{})
+	byCreatedAt := s.listAll(models.EntryFilter{SortBy: models.SortByCreatedAt, SortOrder: models.SortAsc})
+
+	// Assert - по умолчанию от новых к старым по дате записи
+	assert.Equal(s.T(), []string{"Third", "Second", "First"}, titles(byEntryDate))
+	assert.Equal(s.T(), []string{"Second", "First", "Third"}, titles(byCreatedAt))
+}
+
+func (s *EntryRepositorySuite) TestListByUserPaginationWithTies() {
+	// Arrange - пять записей с разной датой и три с одинаковой
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		s.create(fmt.Sprintf("Entry %d", i), base.Add(time.Duration(i)*time.Minute))
+	}
+	for i := 0; i < 3; i++ {
+		s.create("Same time", base)
+	}
+
+	for _, order := range []models.SortOrder{models.SortDesc, models.SortAsc} {
+		// Act - страницы по три записи
+		all := s.listAll(models.EntryFilter{SortOrder: order, Limit: 3})
+
+		// Assert - без пропусков и повторов на границах страниц
+		s.Require().Len(all, 8, "order %s", order)
+		seen := make(map[uuid.UUID]bool)
+		for i, entry := range all {
+			assert.False(s.T(), seen[entry.ID], "duplicate entry on page boundary (order %s)", order)
+			seen[entry.ID] = true
+			if i > 0 && order == models.SortDesc {
+				assert.False(s.T(), entry.EntryDate.After(all[i-1].EntryDate))
+			}
+			if i > 0 && order == models.SortAsc {
+				assert.False(s.T(), entry.EntryDate.Before(all[i-1].EntryDate))
+			}
+		}
+	}
+}
+
+func (s *EntryRepositorySuite) TestListByUserSortByTitle() {
+	// Arrange
+	for _, title := range []string{"Charlie", "Alpha", "Delta", "Bravo", "Alpha"} {
+		s.create(title, time.Now())
+	}
+
+	// Act
+	asc := s.listAll(models.EntryFilter{SortBy: models.SortByTitle, SortOrder: models.SortAsc, Limit: 2})
+	desc := s.listAll(models.EntryFilter{SortBy: models.SortByTitle, SortOrder: models.SortDesc, Limit: 2})
+
+	// Assert
+	assert.Equal(s.T(), []string{"Alpha", "Alpha", "Bravo", "Charlie", "Delta"}, titles(asc))
+	assert.Equal(s.T(), []string{"Delta", "Charlie", "Bravo", "Alpha", "Alpha"}, titles(desc))
+}
+
+func (s *EntryRepositorySuite) TestListByUserSortByUpdatedAt() {
+	// Arrange
+	first := s.create("First", time.Now())
+	s.create("Second", time.Now())
+	time.Sleep(5 * time.Millisecond)
+	first.Content = "Touched"
+	s.Require().NoError(s.repo.Update(s.ctx, first))
+
+	// Act
+	list := s.listAll(models.EntryFilter{SortBy: models.SortByUpdatedAt})
+
+	// Assert
+	assert.Equal(s.T(), []string{"First", "Second"}, titles(list))
+}
+
+func (s *EntryRepositorySuite) TestListByUserDateRange() {
+	// Arrange
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	s.create("Before", day.Add(-time.Second))
+	s.create("Start", day)
+	s.create("Inside", day.Add(12*time.Hour))
+	s.create("End", day.AddDate(0, 0, 1))
+	from, to := day, day.AddDate(0, 0, 1)
+
+	// Act
+	list := s.listAll(models.EntryFilter{From: &from, To: &to})
+
+	// Assert - From включительно, To не включительно
+	assert.Equal(s.T(), []string{"Inside", "Start"}, titles(list))
+}
+
+func (s *EntryRepositorySuite) TestListByUserTitleFilter() {
+	// Arrange
+	for _, title := range []string{"Morning Walk", "evening walk", "100% done", "under_score", "Lunch"} {
+		s.create(title, time.Now())
+	}
+
+	// Act
+	walks := s.listAll(models.EntryFilter{Title: "WALK", SortBy: models.SortByTitle, SortOrder: models.SortAsc})
+	percent := s.listAll(models.EntryFilter{Title: "%"})
+	underscore := s.listAll(models.EntryFilter{Title: "_"})
+
+	// Assert - без учета регистра, символы шаблонов LIKE ищутся как есть
+	assert.Equal(s.T(), []string{"Morning Walk", "evening walk"}, titles(walks))
+	assert.Equal(s.T(), []string{"100% done"}, titles(percent))
+	assert.Equal(s.T(), []string{"under_score"}, titles(underscore))
+}
+
+func (s *EntryRepositorySuite) TestListByUserTagFilter() {
+	// Arrange
+	day := time.Now().AddDate(0, 0, -5)
+	s.create("Work", day, "work")
+	s.create("Both", day.AddDate(0, 0, 1), "work", "travel")
+	s.create("Travel", day.AddDate(0, 0, 2), "travel")
+	s.create("None", day.AddDate(0, 0, 3))
+
+	// Act
+	anyTag := s.listAll(models.EntryFilter{Tags: []string{"work", "travel"}, TagMatch: models.TagMatchAny, Limit: 1})
+	allTags := s.listAll(models.EntryFilter{Tags: []string{"work", "travel"}, TagMatch: models.TagMatchAll})
+	repeated := s.listAll(models.EntryFilter{Tags: []string{"work", "work"}, TagMatch: models.TagMatchAll})
+	unknown := s.listAll(models.EntryFilter{Tags: []string{"missing"}})
+
+	// Assert - повторы тега в фильтре не меняют результат
+	assert.Equal(s.T(), []string{"Travel", "Both", "Work"}, titles(anyTag))
+	assert.Equal(s.T(), []string{"Both"}, titles(allTags))
+	assert.Equal(s.T(), []string{"Both", "Work"}, titles(repeated))
+	assert.Empty(s.T(), unknown)
+}
+
+func (s *EntryRepositorySuite) TestListByUserLimit() {
+	// Arrange
+	for i := 0; i < 3; i++ {
+		s.create(fmt.Sprintf("Entry %d", i), time.Now().Add(time.Duration(i)*time.Minute))
+	}
+
+	// Act
+	page, next, err := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{Limit: 2})
+	s.Require().NoError(err)
+	last, end, err := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{Limit: 2, Cursor: next})
+	s.Require().NoError(err)
+
+	// Assert - курсор выдается, только если есть следующая страница
+	assert.Equal(s.T(), []string{"Entry 2", "Entry 1"}, titles(page))
+	assert.NotEmpty(s.T(), next)
+	assert.Equal(s.T(), []string{"Entry 0"}, titles(last))
+	assert.Empty(s.T(), end)
+}
+
+func (s *EntryRepositorySuite) TestListByUserInvalidCursor() {
+	// Arrange
+	for i := 0; i < 2; i++ {
+		s.create("Entry", time.Now())
+	}
+	_, next, err := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{Limit: 1})
+	s.Require().NoError(err)
+	s.Require().NotEmpty(next)
+
+	// Act
+	garbage, _, garbageErr := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{Cursor: "not-a-cursor", Limit: 1})
+	_, _, otherSortErr := s.repo.ListByUser(s.ctx, s.userID, models.EntryFilter{SortBy: models.SortByTitle, Cursor: next, Limit: 1})
+
+	// Assert - курсор действует только с той сортировкой, для которой выдан
+	assert.ErrorIs(s.T(), garbageErr, repos.ErrInvalidCursor)
+	assert.ErrorIs(s.T(), garbageErr, errs.ErrValidation)
+	assert.Nil(s.T(), garbage)
+	assert.ErrorIs(s.T(), otherSortErr, repos.ErrInvalidCursor)
+}
+
+// --- Изоляция пользователей ---
+
+func (s *EntryRepositorySuite) TestUserIsolation() {
+	// Arrange
+	entry := s.create("Mine", time.Now(), "private")
+	stranger := uuid.New()
+	strangers := s.newEntry(stranger, "Theirs", time.Now(), "private")
+	s.Require().NoError(s.repo.Create(s.ctx, strangers))
+
+	// Act
+	_, readErr := s.repo.Read(s.ctx, stranger, entry.ID.String())
+	deleteErr := s.repo.Delete(s.ctx, stranger, entry.ID.String())
+	forged := s.newEntry(stranger, "Forged", time.Now())
+	forged.ID, forged.Version = entry.ID, 1
+	updateErr := s.repo.Update(s.ctx, forged)
+	list, listErr := s.repo.List(s.ctx, s.userID)
+
+	// Assert - чужая запись не читается, не удаляется и не перезаписывается
+	assert.ErrorIs(s.T(), readErr, repos.ErrEntryNotFound)
+	assert.NoError(s.T(), deleteErr)
+	assert.ErrorIs(s.T(), updateErr, repos.ErrEntryNotFound)
+	s.Require().NoError(listErr)
+	assert.Equal(s.T(), []string{"Mine"}, titles(list))
+	assert.Equal(s.T(), []string{"Mine"}, titles(s.listAll(models.EntryFilter{Tags: []string{"private"}})))
+
+	found := s.read(entry)
+	assert.Equal(s.T(), "Mine", found.Title)
+	assert.Equal(s.T(), 1, found.Version)
+	_, err := s.repo.Read(s.ctx, stranger, forged.ID.String())
+	assert.ErrorIs(s.T(), err, repos.ErrEntryNotFound)
+}
+
+func (s *EntryRepositorySuite) TestCreateWithForeignID() {
+	// Arrange
+	entry := s.create("Mine", time.Now())
+	forged := s.newEntry(uuid.New(), "Forged", time.Now())
+	forged.ID = entry.ID
+
+	// Act
+	err := s.repo.Create(s.ctx, forged)
+
+	// Assert - идентификаторы уникальны среди записей всех пользователей
+	assert.Error(s.T(), err)
+	_, readErr := s.repo.Read(s.ctx, forged.UserID, forged.ID.String())
+	assert.ErrorIs(s.T(), readErr, repos.ErrEntryNotFound)
+	assert.Equal(s.T(), "Mine", s.read(entry).Title)
+}
+
+// --- Конкурентный доступ ---
+
+func (s *EntryRepositorySuite) TestConcurrentCreates() {
+	// Arrange
+	const workers = 8
+	var wg sync.WaitGroup
+	errors := make(chan error, workers)
+
+	// Act
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errors <- s.repo.Create(s.ctx, s.newEntry(s.userID, fmt.Sprintf("Entry %d", i), time.Now(), "shared"))
+		}(i)
+	}
+	wg.Wait()
+	close(errors)
+
+	// Assert
+	for err := range errors {
+		assert.NoError(s.T(), err)
+	}
+	list, err := s.repo.List(s.ctx, s.userID)
+	s.Require().NoError(err)
+	assert.Len(s.T(), list, workers)
+}
+
+func (s *EntryRepositorySuite) TestConcurrentUpdatesOfOneVersion() {
+	// Arrange
+	entry := s.create("Counter", time.Now())
+	const workers = 8
+	var wg sync.WaitGroup
+	errors := make(chan error, workers)
+
+	// Act - все обновляют одну и ту же прочитанную версию
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := *entry
+			update.Tags = nil
+			update.Content = fmt.Sprintf("Writer %d", i)
+			errors <- s.repo.Update(s.ctx, &update)
+		}(i)
+	}
+	wg.Wait()
+	close(errors)
+
+	// Assert - проходит ровно одно изменение, остальные получают конфликт версий
+	succeeded := 0
+	for err := range errors {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(s.T(), err, repos.ErrVersionConflict)
+	}
+	assert.Equal(s.T(), 1, succeeded)
+	assert.Equal(s.T(), 2, s.read(entry).Version)
+}
+
+// --- Контекст ---
+
+func (s *EntryRepositorySuite) TestCanceledContext() {
+	// Arrange
+	entry := s.newEntry(s.userID, "Never", time.Now())
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	// Act
+	createErr := s.repo.Create(ctx, entry)
+	_, readErr := s.repo.Read(ctx, s.userID, entry.ID.String())
+	_, listErr := s.repo.List(ctx, s.userID)
+	_, _, pageErr := s.repo.ListByUser(ctx, s.userID, models.EntryFilter{})
+
+	// Assert - отмененный контекст прерывает операцию, запись не создается
+	assert.ErrorIs(s.T(), createErr, context.Canceled)
+	assert.ErrorIs(s.T(), readErr, context.Canceled)
+	assert.ErrorIs(s.T(), listErr, context.Canceled)
+	assert.ErrorIs(s.T(), pageErr, context.Canceled)
+	_, err := s.repo.Read(s.ctx, s.userID, entry.ID.String())
+	assert.ErrorIs(s.T(), err, repos.ErrEntryNotFound)
+}
@@ -0,0 +1,208 @@
+package repotest
+
+import (
+	"context"
+	"crypto/sha256"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Создает пустое хранилище токенов для одного теста
+type NewTokenRepository func(t *testing.T) repos.TokenRepository
+
+// RunTokenRepository запускает набор для реализации, которую создает newRepo
+func RunTokenRepository(t *testing.T, newRepo NewTokenRepository) {
+	suite.Run(t, &TokenRepositorySuite{NewRepository: newRepo})
+}
+
+type TokenRepositorySuite struct {
+	suite.Suite
+	NewRepository NewTokenRepository
+
+	repo   repos.TokenRepository
+	ctx    context.Context
+	userID uuid.UUID
+}
+
+func (s *TokenRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "repotest: NewRepository is not set")
+	s.repo = s.NewRepository(s.T())
+	s.ctx = context.Background()
+	s.userID = uuid.New()
+}
+
+func (s *TokenRepositorySuite) newToken(userID uuid.UUID, name string, createdAt time.Time) *models.PersonalAccessToken {
+	sum := sha256.Sum256([]byte(uuid.NewString()))
+	return &models.PersonalAccessToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       name,
+		SecretHash: hex.EncodeToString(sum[:]),
+		Scopes:     models.JoinScopes([]models.TokenScope{models.ScopeEntriesRead}),
+		CreatedAt:  at(createdAt),
+	}
+}
+
+func (s *TokenRepositorySuite) create(name string, createdAt time.Time) *models.PersonalAccessToken {
+	token := s.newToken(s.userID, name, createdAt)
+	s.Require().NoError(s.repo.CreateToken(s.ctx, token))
+	return token
+}
+
+func (s *TokenRepositorySuite) TestCreateAndReadByHash() {
+	// Arrange
+	expiresAt := at(time.Now().Add(24 * time.Hour))
+	token := s.newToken(s.userID, "cron", time.Now())
+	token.ExpiresAt = &expiresAt
+
+	// Act
+	s.Require().NoError(s.repo.CreateToken(s.ctx, token))
+	found, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Equal(s.T(), token.ID, found.ID)
+	assert.Equal(s.T(), s.userID, found.UserID)
+	assert.Equal(s.T(), "cron", found.Name)
+	assert.Equal(s.T(), token.Scopes, found.Scopes)
+	s.Require().NotNil(found.ExpiresAt)
+	assert.True(s.T(), expiresAt.Equal(*found.ExpiresAt))
+	assert.Nil(s.T(), found.LastUsedAt)
+	assert.True(s.T(), token.CreatedAt.Equal(found.CreatedAt))
+}
+
+func (s *TokenRepositorySuite) TestReadByUnknownHash() {
+	// Arrange
+	s.create("cron", time.Now())
+
+	// Act
+	_, err := s.repo.ReadTokenByHash(s.ctx, "unknown")
+
+	// Assert
+	assert.ErrorIs(s.T(), err, repos.ErrTokenNotFound)
+	assert.ErrorIs(s.T(), err, errs.ErrNotFound)
+}
+
+func (s *TokenRepositorySuite) TestDuplicateSecret() {
+	// Arrange
+	token := s.create("cron", time.Now())
+	duplicate := s.newToken(uuid.New(), "copy", time.Now())
+	duplicate.SecretHash = token.SecretHash
+
+	// Act & Assert - секрет однозначно определяет токен
+	assert.ErrorIs(s.T(), s.repo.CreateToken(s.ctx, duplicate), errs.ErrConflict)
+}
+
+func (s *TokenRepositorySuite) TestListNewestFirst() {
+	// Arrange
+	base := time.Now().Add(-time.Hour)
+	s.create("old", base)
+	s.create("new", base.Add(time.Minute))
+	s.Require().NoError(s.repo.CreateToken(s.ctx, s.newToken(uuid.New(), "foreign", base)))
+
+	// Act
+	tokens, err := s.repo.ListTokens(s.ctx, s.userID)
+
+	// Assert - только токены пользователя
+	s.Require().NoError(err)
+	s.Require().Len(tokens, 2)
+	assert.Equal(s.T(), "new", tokens[0].Name)
+	assert.Equal(s.T(), "old", tokens[1].Name)
+}
+
+func (s *TokenRepositorySuite) TestListEmpty() {
+	// Act
+	tokens, err := s.repo.ListTokens(s.ctx, s.userID)
+
+	// Assert
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), tokens)
+}
+
+func (s *TokenRepositorySuite) TestDelete() {
+	// Arrange
+	token := s.create("cron", time.Now())
+
+	// Act
+	err := s.repo.DeleteToken(s.ctx, s.userID, token.ID.String())
+
+	// Assert - отозванный токен больше не находится
+	s.Require().NoError(err)
+	_, err = s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	assert.ErrorIs(s.T(), err, repos.ErrTokenNotFound)
+	assert.ErrorIs(s.T(), s.repo.DeleteToken(s.ctx, s.userID, token.ID.String()), repos.ErrTokenNotFound)
+}
+
+func (s *TokenRepositorySuite) TestDeleteForeignOrInvalid() {
+	// Arrange
+	token := s.create("cron", time.Now())
+
+	// Act
+	foreignErr := s.repo.DeleteToken(s.ctx, uuid.New(), token.ID.String())
+	invalidErr := s.repo.DeleteToken(s.ctx, s.userID, "not-a-uuid")
+
+	// Assert - чужой токен не отличается от несуществующего и не удаляется
+	assert.ErrorIs(s.T(), foreignErr, repos.ErrTokenNotFound)
+	assert.ErrorIs(s.T(), invalidErr, repos.ErrTokenNotFound)
+	_, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	assert.NoError(s.T(), err)
+}
+
+func (s *TokenRepositorySuite) TestTouch() {
+	// Arrange
+	token := s.create("cron", time.Now())
+	usedAt := at(time.Now())
+
+	// Act
+	s.Require().NoError(s.repo.TouchToken(s.ctx, token.ID, usedAt))
+	missingErr := s.repo.TouchToken(s.ctx, uuid.New(), usedAt)
+
+	// Assert
+	found, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	s.Require().NoError(err)
+	s.Require().NotNil(found.LastUsedAt)
+	assert.True(s.T(), usedAt.Equal(*found.LastUsedAt))
+	assert.NoError(s.T(), missingErr)
+}
+
+func (s *TokenRepositorySuite) TestReturnsCopies() {
+	// Arrange
+	token := s.create("cron", time.Now())
+
+	// Act - изменения переданного и прочитанного токена не попадают в хранилище
+	token.Name = "changed by caller"
+	found, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	s.Require().NoError(err)
+	found.Scopes = string(models.ScopeEntriesWrite)
+
+	// Assert
+	again, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	s.Require().NoError(err)
+	assert.Equal(s.T(), "cron", again.Name)
+	assert.Equal(s.T(), string(models.ScopeEntriesRead), again.Scopes)
+}
+
+func (s *TokenRepositorySuite) TestCanceledContext() {
+	// Arrange
+	token := s.newToken(s.userID, "never", time.Now())
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	// Act
+	createErr := s.repo.CreateToken(ctx, token)
+	_, listErr := s.repo.ListTokens(ctx, s.userID)
+
+	// Assert
+	assert.ErrorIs(s.T(), createErr, context.Canceled)
+	assert.ErrorIs(s.T(), listErr, context.Canceled)
+	_, err := s.repo.ReadTokenByHash(s.ctx, token.SecretHash)
+	assert.ErrorIs(s.T(), err, repos.ErrTokenNotFound)
+}
@@ -0,0 +1,145 @@
+package repotest
+
+import (
+	"context"
+	"crypto/sha256"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Хранилище, по которому считается использование: записи и токены
+// создаются через его же методы
+type UsageStore interface {
+	repos.EntryRepository
+	repos.TokenRepository
+	repos.UsageRepository
+}
+
+// Создает пустое хранилище для одного теста
+type NewUsageRepository func(t *testing.T) UsageStore
+
+// RunUsageRepository запускает набор для реализации, которую создает newRepo
+func RunUsageRepository(t *testing.T, newRepo NewUsageRepository) {
+	suite.Run(t, &UsageRepositorySuite{NewRepository: newRepo})
+}
+
+type UsageRepositorySuite struct {
+	suite.Suite
+	NewRepository NewUsageRepository
+
+	repo UsageStore
+	ctx  context.Context
+}
+
+func (s *UsageRepositorySuite) SetupTest() {
+	s.Require().NotNil(s.NewRepository, "repotest: NewRepository is not set")
+	s.repo = s.NewRepository(s.T())
+	s.ctx = context.Background()
+}
+
+func (s *UsageRepositorySuite) createEntry(userID uuid.UUID, updatedAt time.Time) *models.Entry {
+	entry := &models.Entry{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     "Entry",
+		Content:   "Content",
+		EntryDate: at(updatedAt),
+		TimeZone:  "UTC",
+		CreatedAt: at(updatedAt),
+		UpdatedAt: at(updatedAt),
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, entry))
+	return entry
+}
+
+func (s *UsageRepositorySuite) createToken(userID uuid.UUID) {
+	sum := sha256.Sum256([]byte(uuid.NewString()))
+	s.Require().NoError(s.repo.CreateToken(s.ctx, &models.PersonalAccessToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       "cron",
+		SecretHash: hex.EncodeToString(sum[:]),
+		Scopes:     string(models.ScopeEntriesRead),
+	}))
+}
+
+func (s *UsageRepositorySuite) TestEmpty() {
+	// Act
+	usage, err := s.repo.ListUsage(s.ctx)
+
+	// Assert
+	s.Require().NoError(err)
+	assert.Empty(s.T(), usage)
+}
+
+func (s *UsageRepositorySuite) TestCountsPerUser() {
+	// Arrange
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writer, scripter := uuid.New(), uuid.New()
+	s.createEntry(writer, start)
+	s.createEntry(writer, start.Add(time.Hour))
+	trashed := s.createEntry(writer, start.Add(2*time.Hour))
+	s.Require().NoError(s.repo.Delete(s.ctx, writer, trashed.ID.String()))
+	s.createToken(writer)
+	s.createToken(scripter)
+	s.createToken(scripter)
+
+	// Act
+	usage, err := s.repo.ListUsage(s.ctx)
+
+	// Assert - пользователь без записей учитывается по токенам, корзина
+	// входит в последнее изменение
+	s.Require().NoError(err)
+	byUser := make(map[uuid.UUID]*models.UserUsage)
+	for _, item := range usage {
+		byUser[item.UserID] = item
+	}
+	s.Require().Len(byUser, 2)
+
+	assert.Equal(s.T(), int64(2), byUser[writer].Entries)
+	assert.Equal(s.T(), int64(1), byUser[writer].Trashed)
+	assert.Equal(s.T(), int64(1), byUser[writer].Tokens)
+	s.Require().NotNil(byUser[writer].LastEntryAt)
+	assert.True(s.T(), at(start.Add(2*time.Hour)).Equal(*byUser[writer].LastEntryAt), byUser[writer].LastEntryAt)
+
+	assert.Equal(s.T(), int64(0), byUser[scripter].Entries)
+	assert.Equal(s.T(), int64(2), byUser[scripter].Tokens)
+	assert.Nil(s.T(), byUser[scripter].LastEntryAt)
+}
+
+func (s *UsageRepositorySuite) TestOrderedByUserID() {
+	// Arrange
+	for i := 0; i < 5; i++ {
+		s.createToken(uuid.New())
+	}
+
+	// Act
+	usage, err := s.repo.ListUsage(s.ctx)
+
+	// Assert
+	s.Require().NoError(err)
+	s.Require().Len(usage, 5)
+	for i := 1; i < len(usage); i++ {
+		assert.Less(s.T(), usage[i-1].UserID.String(), usage[i].UserID.String())
+	}
+}
+
+func (s *UsageRepositorySuite) TestCanceledContext() {
+	// Arrange
+	s.createToken(uuid.New())
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	// Act
+	_, err := s.repo.ListUsage(ctx)
+
+	// Assert
+	assert.ErrorIs(s.T(), err, context.Canceled)
+}
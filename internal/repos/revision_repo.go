@@ -0,0 +1,131 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound = errs.NotFound("revision not found")
+
+// --- Revision Repository Interface ---
+
+type RevisionRepository interface {
+	ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error)
+	ReadRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error)
+}
+
+// --- Revision Repository Implementation ---
+
+// Ревизии только читаются: записывает их EntryRepository в той же транзакции,
+// что и изменение записи
+type revisionRepository struct {
+	db *gorm.DB
+}
+
+func NewRevisionRepository(db *gorm.DB) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+// --- Revisions ---
+
+// Ревизии записи, новые первыми. История чужой или удаленной записи пуста.
+func (r *revisionRepository) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	id, ok := parseID(entryID)
+	if !ok {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	var revisions []*models.EntryRevision
+	err := db.Where("entry_id = ? AND entry_id IN (?)", id, ownedEntryIDs(db, userID)).
+		Order("number DESC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepository) ReadRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	id, ok := parseID(entryID)
+	if !ok {
+		return nil, ErrRevisionNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	db := r.db.WithContext(ctx)
+	var revision models.EntryRevision
+	err := db.Where("entry_id = ? AND number = ? AND entry_id IN (?)", id, number, ownedEntryIDs(db, userID)).
+		First(&revision).Error
+	if err != nil {
+		return nil, notFound(err, ErrRevisionNotFound)
+	}
+	return &revision, nil
+}
+
+// --- Вспомогательные функции ---
+
+// Подзапрос ID записей пользователя, не находящихся в корзине
+func ownedEntryIDs(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.Entry{}).Select("id").Where("user_id = ?", userID)
+}
+
+// Последняя ревизия записи или nil, если истории еще нет
+func latestRevision(db *gorm.DB, entryID uuid.UUID) (*models.EntryRevision, error) {
+	var revision models.EntryRevision
+	err := db.Where("entry_id = ?", entryID).Order("number DESC").First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// Добавляет ревизию, если заголовок или текст отличаются от последней.
+// У записей, созданных до появления истории, сначала сохраняется прежнее
+// состояние, чтобы к нему можно было вернуться.
+func recordRevision(tx *gorm.DB, entry *models.Entry) error {
+	latest, err := latestRevision(tx, entry.ID)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		var stored models.Entry
+		err := tx.Select("id", "title", "content").First(&stored, "id = ?", entry.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if latest, err = addRevision(tx, nil, stored.ID, stored.Title, stored.Content); err != nil {
+				return err
+			}
+		}
+	}
+	if latest != nil && latest.Title == entry.Title && latest.Content == entry.Content {
+		return nil
+	}
+	_, err = addRevision(tx, latest, entry.ID, entry.Title, entry.Content)
+	return err
+}
+
+// Добавляет ревизию со следующим номером после prev
+func addRevision(db *gorm.DB, prev *models.EntryRevision, entryID uuid.UUID, title, content string) (*models.EntryRevision, error) {
+	revision := &models.EntryRevision{
+		ID:      uuid.New(),
+		EntryID: entryID,
+		Number:  1,
+		Title:   title,
+		Content: content,
+	}
+	if prev != nil {
+		revision.Number = prev.Number + 1
+	}
+	if err := db.Create(revision).Error; err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
@@ -0,0 +1,125 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type RevisionRepositoryTestSuite struct {
+	suite.Suite
+	backend      dbtest.Backend
+	db           *gorm.DB
+	entryRepo    EntryRepository
+	revisionRepo RevisionRepository
+}
+
+func (suite *RevisionRepositoryTestSuite) SetupTest() {
+	db := openTestDB(suite.T(), suite.backend)
+
+	suite.db = db
+	suite.entryRepo = NewEntryRepository(db)
+	suite.revisionRepo = NewRevisionRepository(db)
+}
+
+func (suite *RevisionRepositoryTestSuite) createEntry() *models.Entry {
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Draft", Content: "First line"}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), entry))
+	return entry
+}
+
+func (suite *RevisionRepositoryTestSuite) TestRevisionPerChange() {
+	// Arrange
+	entry := suite.createEntry()
+
+	// Act - два изменения текста и одно изменение только настроения
+	entry.Content = "Second line"
+	suite.Require().NoError(suite.entryRepo.Update(context.Background(), entry))
+	entry.Title = "Final"
+	suite.Require().NoError(suite.entryRepo.Update(context.Background(), entry))
+	mood := 3
+	entry.Mood = &mood
+	suite.Require().NoError(suite.entryRepo.Update(context.Background(), entry))
+
+	revisions, err := suite.revisionRepo.ListRevisions(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert - новые первыми, без ревизии для неизменного текста
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), revisions, 3)
+	assert.Equal(suite.T(), 3, revisions[0].Number)
+	assert.Equal(suite.T(), "Final", revisions[0].Title)
+	assert.Equal(suite.T(), "Second line", revisions[0].Content)
+	assert.Equal(suite.T(), 1, revisions[2].Number)
+	assert.Equal(suite.T(), "First line", revisions[2].Content)
+}
+
+func (suite *RevisionRepositoryTestSuite) TestBaselineForEntryWithoutHistory() {
+	// Arrange - запись, сохраненная до появления истории
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Old", Content: "Old text"}
+	suite.Require().NoError(suite.db.Create(entry).Error)
+
+	// Act
+	entry.Content = "New text"
+	suite.Require().NoError(suite.entryRepo.Update(context.Background(), entry))
+
+	// Assert - прежнее состояние сохранено первой ревизией
+	first, err := suite.revisionRepo.ReadRevision(context.Background(), entry.UserID, entry.ID.String(), 1)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Old text", first.Content)
+	second, err := suite.revisionRepo.ReadRevision(context.Background(), entry.UserID, entry.ID.String(), 2)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "New text", second.Content)
+}
+
+func (suite *RevisionRepositoryTestSuite) TestReadRevisionNotFound() {
+	// Arrange
+	entry := suite.createEntry()
+
+	// Act
+	_, err := suite.revisionRepo.ReadRevision(context.Background(), entry.UserID, entry.ID.String(), 5)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+}
+
+func (suite *RevisionRepositoryTestSuite) TestForeignRevisionsHidden() {
+	// Arrange
+	entry := suite.createEntry()
+	stranger := uuid.New()
+
+	// Act
+	revisions, listErr := suite.revisionRepo.ListRevisions(context.Background(), stranger, entry.ID.String())
+	_, readErr := suite.revisionRepo.ReadRevision(context.Background(), stranger, entry.ID.String(), 1)
+
+	// Assert
+	assert.NoError(suite.T(), listErr)
+	assert.Empty(suite.T(), revisions)
+	assert.ErrorIs(suite.T(), readErr, ErrRevisionNotFound)
+}
+
+func (suite *RevisionRepositoryTestSuite) TestPurgeRemovesRevisions() {
+	// Arrange
+	entry := suite.createEntry()
+	suite.Require().NoError(suite.entryRepo.Delete(context.Background(), entry.UserID, entry.ID.String()))
+
+	// Act
+	err := NewTrashRepository(suite.db).Purge(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	revisions, err := suite.revisionRepo.ListRevisions(context.Background(), entry.UserID, entry.ID.String())
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), revisions)
+}
+
+func TestRevisionRepositoryTestSuite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &RevisionRepositoryTestSuite{backend: backend})
+	})
+}
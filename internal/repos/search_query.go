@@ -0,0 +1,259 @@
+package repos
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// --- Разбор поискового запроса ---
+
+// Поддерживаемый синтаксис:
+//   - слова через пробел: все должны встретиться (AND);
+//   - "фраза в кавычках": слова подряд;
+//   - слово* или "фраза"*: поиск по префиксу;
+//   - OR между частями запроса: достаточно одной из них.
+//
+// Все термы передаются в FTS5 и to_tsquery в кавычках, поэтому
+// пользовательский ввод не может сломать синтаксис запроса.
+
+type searchTerm struct {
+	text   string
+	phrase bool
+	prefix bool
+}
+
+// Дизъюнкция конъюнкций: [[a b] [c]] означает (a AND b) OR c
+type searchQuery [][]searchTerm
+
+func parseSearchQuery(input string) searchQuery {
+	var query searchQuery
+	var group []searchTerm
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+
+		case runes[i] == '"':
+			// Фраза до закрывающей кавычки (или до конца строки)
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			term := searchTerm{text: strings.TrimSpace(string(runes[i+1 : j])), phrase: true}
+			i = j + 1
+			if i < len(runes) && runes[i] == '*' {
+				term.prefix = true
+				i++
+			}
+			if term.text != "" {
+				group = append(group, term)
+			}
+
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '"' {
+				j++
+			}
+			word := string(runes[i:j])
+			i = j
+
+			if word == "OR" {
+				if len(group) > 0 {
+					query = append(query, group)
+					group = nil
+				}
+				continue
+			}
+
+			term := searchTerm{text: strings.TrimRight(word, "*")}
+			term.prefix = term.text != word
+			if term.text != "" {
+				group = append(group, term)
+			}
+		}
+	}
+
+	if len(group) > 0 {
+		query = append(query, group)
+	}
+	return query
+}
+
+// Выражение для FTS5 MATCH
+func (q searchQuery) fts() string {
+	groups := make([]string, 0, len(q))
+	for _, group := range q {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			s := `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+			if term.prefix {
+				s += "*"
+			}
+			terms = append(terms, s)
+		}
+		groups = append(groups, "("+strings.Join(terms, " ")+")")
+	}
+	return strings.Join(groups, " OR ")
+}
+
+// Выражение для to_tsquery в PostgreSQL. Терм в кавычках разбивается
+// парсером на слова, которые должны идти подряд, поэтому фразы и слова
+// записываются одинаково.
+func (q searchQuery) tsquery() string {
+	groups := make([]string, 0, len(q))
+	for _, group := range q {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			s := "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(term.text) + "'"
+			if term.prefix {
+				s += ":*"
+			}
+			terms = append(terms, s)
+		}
+		groups = append(groups, "("+strings.Join(terms, " & ")+")")
+	}
+	return strings.Join(groups, " | ")
+}
+
+// Проверяет запись так же, как поиск через LIKE: в одной из групп каждый
+// терм встречается в заголовке или тексте (без учета регистра)
+func (q searchQuery) matches(title, content string) bool {
+	title, content = strings.ToLower(title), strings.ToLower(content)
+	for _, group := range q {
+		matched := true
+		for _, term := range group {
+			text := strings.ToLower(term.text)
+			if !strings.Contains(title, text) && !strings.Contains(content, text) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Все термы запроса без учета групп (для подсветки)
+func (q searchQuery) terms() []string {
+	var terms []string
+	for _, group := range q {
+		for _, term := range group {
+			terms = append(terms, term.text)
+		}
+	}
+	return terms
+}
+
+// --- Подсветка совпадений ---
+
+// Фрагменты - готовый HTML: текст записи экранируется, разметкой остаются
+// только теги <mark>, чтобы клиент мог вставить фрагмент как есть
+const (
+	markOpen    = "<mark>"
+	markClose   = "</mark>"
+	ellipsis    = "…"
+	snippetSize = 160 // символов в фрагменте
+)
+
+// Границы совпадений во фрагментах из базы. Управляющие символы в тексте
+// записей не встречаются, поэтому фрагмент можно экранировать целиком и
+// только потом превратить маркеры в <mark>.
+const (
+	dbMarkOpen  = "\x02"
+	dbMarkClose = "\x03"
+)
+
+var dbMarks = strings.NewReplacer(dbMarkOpen, markOpen, dbMarkClose, markClose)
+
+// Экранирует фрагмент из базы и заменяет маркеры совпадений на <mark>
+func escapeSnippet(s string) string {
+	return dbMarks.Replace(html.EscapeString(s))
+}
+
+// Оборачивает все вхождения термов (без учета регистра) в <mark>;
+// остальной текст экранируется
+func highlight(text []rune, terms []string) string {
+	var b strings.Builder
+	plain := 0
+	for i := 0; i < len(text); {
+		if n := matchAnyFold(text, i, terms); n > 0 {
+			b.WriteString(html.EscapeString(string(text[plain:i])))
+			b.WriteString(markOpen)
+			b.WriteString(html.EscapeString(string(text[i : i+n])))
+			b.WriteString(markClose)
+			i += n
+			plain = i
+			continue
+		}
+		i++
+	}
+	b.WriteString(html.EscapeString(string(text[plain:])))
+	return b.String()
+}
+
+// Фрагмент текста вокруг первого совпадения с подсветкой
+func snippet(text string, terms []string) string {
+	runes := []rune(text)
+	first := -1
+	for i := range runes {
+		if matchAnyFold(runes, i, terms) > 0 {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := max(first-snippetSize/3, 0)
+	end := min(start+snippetSize, len(runes))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	b.WriteString(highlight(runes[start:end], terms))
+	if end < len(runes) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+// Длина (в символах) самого длинного терма, совпадающего с текстом в позиции i
+func matchAnyFold(text []rune, i int, terms []string) int {
+	best := 0
+	for _, term := range terms {
+		n := 0
+		for _, r := range term {
+			if i+n >= len(text) || unicode.ToLower(text[i+n]) != unicode.ToLower(r) {
+				n = -1
+				break
+			}
+			n++
+		}
+		if n > best {
+			best = n
+		}
+	}
+	return best
+}
+
+// Количество вхождений термов (для простого ранжирования без FTS5)
+func countMatches(text string, terms []string) int {
+	runes := []rune(text)
+	count := 0
+	for i := 0; i < len(runes); {
+		if n := matchAnyFold(runes, i, terms); n > 0 {
+			count++
+			i += n
+			continue
+		}
+		i++
+	}
+	return count
+}
@@ -0,0 +1,293 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidSearchQuery = errs.Field("q", "invalid search query")
+
+// --- Search Repository Interface ---
+
+type SearchRepository interface {
+	Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error)
+}
+
+// --- Search Repository Implementation ---
+
+// В PostgreSQL использует колонку entries.search (tsvector). В SQLite -
+// FTS5-таблицу entries_fts, если она создана SetupSearchIndex, иначе
+// выполняет поиск через LIKE с ранжированием и подсветкой в Go.
+type searchRepository struct {
+	db *gorm.DB
+
+	once sync.Once
+	mode searchMode
+}
+
+type searchMode int
+
+const (
+	searchLike searchMode = iota
+	searchFTS5
+	searchTSVector
+)
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// --- Индекс FTS5 ---
+
+const ftsTable = "entries_fts"
+
+// Таблица индекса хранит копию title/content и id записи. Внешний контент
+// (content='entries') не используется: rowid таблицы entries без INTEGER
+// PRIMARY KEY может измениться после VACUUM.
+var ftsSchema = []string{
+	`CREATE VIRTUAL TABLE entries_fts USING fts5(
+		entry_id UNINDEXED,
+		title,
+		content,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`,
+	`CREATE TRIGGER IF NOT EXISTS entries_fts_ai AFTER INSERT ON entries BEGIN
+		INSERT INTO entries_fts(entry_id, title, content) VALUES (new.id, new.title, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS entries_fts_ad AFTER DELETE ON entries BEGIN
+		DELETE FROM entries_fts WHERE entry_id = old.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS entries_fts_au AFTER UPDATE OF title, content ON entries BEGIN
+		DELETE FROM entries_fts WHERE entry_id = old.id;
+		INSERT INTO entries_fts(entry_id, title, content) VALUES (new.id, new.title, new.content);
+	END`,
+	`INSERT INTO entries_fts(entry_id, title, content) SELECT id, title, content FROM entries`,
+}
+
+// Триггеры из ftsSchema. Они удаляются вместе с таблицей entries (например,
+// при migrate down до нуля), а entries_fts остается.
+var ftsTriggers = []string{"entries_fts_ai", "entries_fts_ad", "entries_fts_au"}
+
+// SetupSearchIndex создает FTS5-индекс и триггеры синхронизации с таблицей entries.
+// Индекс без какого-либо из триггеров уже отстал от entries и строится заново.
+// Возвращает false без ошибки, если SQLite собран без FTS5: тогда поиск
+// работает в упрощенном режиме. В PostgreSQL индекс создают миграции.
+func SetupSearchIndex(db *gorm.DB) (bool, error) {
+	if database.Driver(db) == database.DriverPostgres {
+		return true, nil
+	}
+	ready, err := searchIndexReady(db)
+	if err != nil || ready {
+		return ready, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DROP TABLE IF EXISTS " + ftsTable).Error; err != nil {
+			return err
+		}
+		for _, stmt := range ftsSchema {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Есть ли таблица индекса и все триггеры синхронизации
+func searchIndexReady(db *gorm.DB) (bool, error) {
+	if !db.Migrator().HasTable(ftsTable) {
+		return false, nil
+	}
+	var triggers int64
+	err := db.Raw(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND tbl_name = 'entries' AND name IN ?`, ftsTriggers).
+		Scan(&triggers).Error
+	if err != nil {
+		return false, err
+	}
+	return triggers == int64(len(ftsTriggers)), nil
+}
+
+func (r *searchRepository) searchMode() searchMode {
+	r.once.Do(func() {
+		switch {
+		case database.Driver(r.db) == database.DriverPostgres:
+			r.mode = searchTSVector
+		case r.db.Migrator().HasTable(ftsTable):
+			r.mode = searchFTS5
+		}
+	})
+	return r.mode
+}
+
+// --- Search ---
+
+func (r *searchRepository) Search(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	parsed := parseSearchQuery(query)
+	if len(parsed) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+	switch r.searchMode() {
+	case searchTSVector:
+		return r.searchTSVector(ctx, userID, parsed, limit)
+	case searchFTS5:
+		return r.searchFTS(ctx, userID, parsed, limit)
+	}
+	return r.searchLike(ctx, userID, parsed, limit)
+}
+
+type searchRow struct {
+	models.Entry   `gorm:"embedded"`
+	Score          float64
+	TitleSnippet   string
+	ContentSnippet string
+}
+
+func (r *searchRepository) searchFTS(ctx context.Context, userID uuid.UUID, query searchQuery, limit int) ([]*models.SearchResult, error) {
+	// bm25 возвращает отрицательные значения: чем меньше, тем релевантнее.
+	// Совпадения в заголовке весят больше, чем в тексте.
+	var rows []searchRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT entries.*,
+			-bm25(entries_fts, 0.0, 10.0, 1.0) AS score,
+			highlight(entries_fts, 1, ?, ?) AS title_snippet,
+			snippet(entries_fts, 2, ?, ?, ?, 24) AS content_snippet
+		FROM entries_fts
+		JOIN entries ON entries.id = entries_fts.entry_id
+		WHERE entries_fts MATCH ? AND entries.user_id = ? AND entries.deleted_at IS NULL AND entries.algorithm = ''
+		ORDER BY score DESC
+		LIMIT ?`,
+		dbMarkOpen, dbMarkClose, dbMarkOpen, dbMarkClose, ellipsis,
+		query.fts(), userID, limit,
+	).Scan(&rows).Error
+	if err != nil {
+		if strings.Contains(err.Error(), "fts5: syntax error") {
+			return nil, ErrInvalidSearchQuery
+		}
+		return nil, err
+	}
+	return r.searchResults(ctx, rows)
+}
+
+func (r *searchRepository) searchTSVector(ctx context.Context, userID uuid.UUID, query searchQuery, limit int) ([]*models.SearchResult, error) {
+	// Веса {D, C, B, A}: совпадения в заголовке (A) весят в 10 раз больше,
+	// чем в тексте (B), как и в bm25 для FTS5
+	var rows []searchRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT entries.*,
+			ts_rank('{0.1, 0.1, 0.1, 1.0}', entries.search, q) AS score,
+			ts_headline('simple', entries.title, q, ?) AS title_snippet,
+			ts_headline('simple', entries.content, q, ?) AS content_snippet
+		FROM entries, to_tsquery('simple', ?) AS q
+		WHERE entries.search @@ q AND entries.user_id = ? AND entries.deleted_at IS NULL AND entries.algorithm = ''
+		ORDER BY score DESC, entries.entry_date DESC
+		LIMIT ?`,
+		"StartSel="+dbMarkOpen+", StopSel="+dbMarkClose+", HighlightAll=true",
+		"StartSel="+dbMarkOpen+", StopSel="+dbMarkClose+", MinWords=12, MaxWords=24",
+		query.tsquery(), userID, limit,
+	).Scan(&rows).Error
+	if err != nil {
+		if strings.Contains(err.Error(), "syntax error in tsquery") {
+			return nil, ErrInvalidSearchQuery
+		}
+		return nil, err
+	}
+	return r.searchResults(ctx, rows)
+}
+
+// Результаты поиска в порядке строк с загруженными тегами
+func (r *searchRepository) searchResults(ctx context.Context, rows []searchRow) ([]*models.SearchResult, error) {
+	entries := make([]*models.Entry, 0, len(rows))
+	results := make([]*models.SearchResult, 0, len(rows))
+	for i := range rows {
+		entry := rows[i].Entry
+		entries = append(entries, &entry)
+		results = append(results, &models.SearchResult{
+			Entry:          &entry,
+			Rank:           rows[i].Score,
+			TitleSnippet:   escapeSnippet(rows[i].TitleSnippet),
+			ContentSnippet: escapeSnippet(rows[i].ContentSnippet),
+		})
+	}
+	if err := loadTags(r.db.WithContext(ctx), entries); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Максимум кандидатов, которые ранжируются в памяти в режиме без FTS5
+const likeCandidateLimit = 500
+
+func (r *searchRepository) searchLike(ctx context.Context, userID uuid.UUID, query searchQuery, limit int) ([]*models.SearchResult, error) {
+	// (t1 AND t2) OR (t3): каждый терм ищется в заголовке или тексте
+	var groups []string
+	var args []interface{}
+	for _, group := range query {
+		conds := make([]string, 0, len(group))
+		for _, term := range group {
+			pattern := "%" + escapeLike(strings.ToLower(term.text)) + "%"
+			conds = append(conds, `(LOWER(title) LIKE ? ESCAPE '\' OR LOWER(content) LIKE ? ESCAPE '\')`)
+			args = append(args, pattern, pattern)
+		}
+		groups = append(groups, "("+strings.Join(conds, " AND ")+")")
+	}
+
+	var entries []*models.Entry
+	err := r.db.WithContext(ctx).Where("user_id = ? AND algorithm = ''", userID).
+		Where(strings.Join(groups, " OR "), args...).
+		Order("entry_date DESC").
+		Limit(likeCandidateLimit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := rankResults(entries, query, limit)
+	ranked := make([]*models.Entry, 0, len(results))
+	for _, result := range results {
+		ranked = append(ranked, result.Entry)
+	}
+	if err := loadTags(r.db.WithContext(ctx), ranked); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Ранжирует найденные записи в Go: совпадения в заголовке весят в 10 раз
+// больше, чем в тексте. Записи передаются в порядке даты, новые первыми;
+// стабильная сортировка сохраняет этот порядок при равном ранге.
+func rankResults(entries []*models.Entry, query searchQuery, limit int) []*models.SearchResult {
+	terms := query.terms()
+	results := make([]*models.SearchResult, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, &models.SearchResult{
+			Entry:          entry,
+			Rank:           float64(10*countMatches(entry.Title, terms) + countMatches(entry.Content, terms)),
+			TitleSnippet:   highlight([]rune(entry.Title), terms),
+			ContentSnippet: snippet(entry.Content, terms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,271 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// На каждом бэкенде dbtest запускается дважды: в полнотекстовом режиме
+// (FTS5, если SQLite собран с тегом sqlite_fts5; tsvector в PostgreSQL)
+// и в упрощенном режиме через LIKE
+type SearchRepositoryTestSuite struct {
+	suite.Suite
+	backend       dbtest.Backend
+	db            *gorm.DB
+	repo          SearchRepository
+	forceFallback bool
+	userID        uuid.UUID
+}
+
+func (suite *SearchRepositoryTestSuite) SetupTest() {
+	db := openTestDB(suite.T(), suite.backend)
+
+	fts, err := SetupSearchIndex(db)
+	suite.Require().NoError(err)
+	suite.T().Logf("full-text index available: %v, forced fallback: %v", fts, suite.forceFallback)
+
+	repo := &searchRepository{db: db}
+	if suite.forceFallback {
+		repo.once.Do(func() {})
+	}
+
+	suite.db = db
+	suite.repo = repo
+	suite.userID = uuid.New()
+
+	entries := []*models.Entry{
+		{ID: uuid.New(), UserID: suite.userID, Title: "Trip to the mountains", Content: "We hiked all day and saw a beautiful sunset."},
+		{ID: uuid.New(), UserID: suite.userID, Title: "Work notes", Content: "Long meeting about the mountain project budget."},
+		{ID: uuid.New(), UserID: suite.userID, Title: "Groceries", Content: "Bread, milk, apples."},
+		{ID: uuid.New(), UserID: uuid.New(), Title: "Someone else's mountains", Content: "Private sunset thoughts."},
+	}
+	for _, entry := range entries {
+		suite.Require().NoError(suite.db.Create(entry).Error)
+	}
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchOnlyOwnEntries() {
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, "sunset", 10)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), results, 1)
+	assert.Equal(suite.T(), "Trip to the mountains", results[0].Entry.Title)
+	assert.Contains(suite.T(), results[0].ContentSnippet, "<mark>sunset</mark>")
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchTitleRankedFirst() {
+	// Act - "mountain*" встречается в заголовке одной записи и в тексте другой
+	results, err := suite.repo.Search(context.Background(), suite.userID, "mountain*", 10)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), results, 2)
+	assert.Equal(suite.T(), "Trip to the mountains", results[0].Entry.Title)
+	assert.Contains(suite.T(), results[0].TitleSnippet, "<mark>")
+	assert.GreaterOrEqual(suite.T(), results[0].Rank, results[1].Rank)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchPhrase() {
+	// Act
+	matched, err := suite.repo.Search(context.Background(), suite.userID, `"meeting about"`, 10)
+	suite.Require().NoError(err)
+	notMatched, err := suite.repo.Search(context.Background(), suite.userID, `"about meeting"`, 10)
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Len(suite.T(), matched, 1)
+	assert.Equal(suite.T(), "Work notes", matched[0].Entry.Title)
+	assert.Empty(suite.T(), notMatched)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchOr() {
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, "bread OR budget", 10)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), results, 2)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchReflectsUpdatesAndDeletes() {
+	// Arrange
+	var entry models.Entry
+	suite.Require().NoError(suite.db.First(&entry, "title = ?", "Groceries").Error)
+
+	// Act - изменение текста
+	entry.Content = "Cheese and tomatoes."
+	suite.Require().NoError(suite.db.Save(&entry).Error)
+	oldTerm, err := suite.repo.Search(context.Background(), suite.userID, "milk", 10)
+	suite.Require().NoError(err)
+	newTerm, err := suite.repo.Search(context.Background(), suite.userID, "tomatoes", 10)
+	suite.Require().NoError(err)
+
+	// Act - удаление
+	suite.Require().NoError(suite.db.Delete(&entry).Error)
+	deleted, err := suite.repo.Search(context.Background(), suite.userID, "tomatoes", 10)
+	suite.Require().NoError(err)
+
+	// Assert
+	assert.Empty(suite.T(), oldTerm)
+	assert.Len(suite.T(), newTerm, 1)
+	assert.Empty(suite.T(), deleted)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchSkipsEncrypted() {
+	// Arrange - у зашифрованной записи текста на сервере нет; если он
+	// все же оказался в колонках, поиск его не показывает
+	encrypted := &models.Entry{
+		ID: uuid.New(), UserID: suite.userID, Title: "Sunset", Content: "sunset",
+		Algorithm: models.EncryptionA256GCM, KeyID: "main", Nonce: make([]byte, 12), Ciphertext: []byte{1, 2, 3},
+	}
+	suite.Require().NoError(suite.db.Create(encrypted).Error)
+
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, "sunset", 10)
+
+	// Assert
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "Trip to the mountains", results[0].Entry.Title)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchSnippetsEscapeHTML() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: suite.userID, Title: "<img src=x onerror=alert(1)> sunrise", Content: "<script>sunrise</script>"}
+	suite.Require().NoError(suite.db.Create(entry).Error)
+
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, "sunrise", 10)
+
+	// Assert - в каждом режиме поиска разметка записи экранирована
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 1)
+	assert.Equal(suite.T(), "&lt;img src=x onerror=alert(1)&gt; <mark>sunrise</mark>", results[0].TitleSnippet)
+	assert.Contains(suite.T(), results[0].ContentSnippet, "&lt;script&gt;<mark>sunrise</mark>&lt;/script&gt;")
+	assert.NotContains(suite.T(), results[0].ContentSnippet, "<script>")
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchUnsafeInput() {
+	// Act - операторы FTS5 в пользовательском вводе не ломают запрос
+	results, err := suite.repo.Search(context.Background(), suite.userID, `sunset AND (NOT "`, 10)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), results)
+}
+
+func (suite *SearchRepositoryTestSuite) TestSearchEmptyQuery() {
+	// Act
+	results, err := suite.repo.Search(context.Background(), suite.userID, `  "" * `, 10)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrInvalidSearchQuery)
+	assert.Nil(suite.T(), results)
+}
+
+func TestSearchRepositoryTestSuite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &SearchRepositoryTestSuite{backend: backend})
+	})
+}
+
+func TestSearchRepositoryFallbackTestSuite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &SearchRepositoryTestSuite{backend: backend, forceFallback: true})
+	})
+}
+
+func TestSetupSearchIndexRestoresTriggers(t *testing.T) {
+	// Arrange - entries_fts осталась, а триггера вставки нет: без
+	// проверки триггеров индекс молча отставал бы от entries
+	db := openTestDB(t, dbtest.Backend{Name: database.DriverSQLite})
+	fts, err := SetupSearchIndex(db)
+	require.NoError(t, err)
+	if !fts {
+		t.Skip("SQLite built without FTS5")
+	}
+	userID := uuid.New()
+	require.NoError(t, db.Exec("DROP TRIGGER entries_fts_ai").Error)
+	require.NoError(t, db.Create(&models.Entry{ID: uuid.New(), UserID: userID, Title: "Missed", Content: "early sunrise"}).Error)
+
+	// Act
+	fts, err = SetupSearchIndex(db)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Entry{ID: uuid.New(), UserID: userID, Title: "After", Content: "late sunrise"}).Error)
+	results, err := NewSearchRepository(db).Search(context.Background(), userID, "sunrise", 10)
+
+	// Assert - индекс перестроен: в нем и пропущенная запись, и новая
+	require.NoError(t, err)
+	assert.True(t, fts)
+	assert.Len(t, results, 2)
+}
+
+// --- Разбор запроса ---
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		fts   string
+	}{
+		{"hello world", `("hello" "world")`},
+		{`"exact phrase" word*`, `("exact phrase" "word"*)`},
+		{`"open phrase`, `("open phrase")`},
+		{"a b OR c", `("a" "b") OR ("c")`},
+		{`say "hi""there"`, `("say" "hi" "there")`},
+		{`quote"inside`, `("quote" "inside")`},
+		{"OR leading", `("leading")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.fts, parseSearchQuery(tt.input).fts())
+		})
+	}
+}
+
+func TestSearchQueryTSQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		tsquery string
+	}{
+		{"hello world", `('hello' & 'world')`},
+		{`"exact phrase" word*`, `('exact phrase' & 'word':*)`},
+		{"a b OR c", `('a' & 'b') | ('c')`},
+		{`it's back\slash`, `('it''s' & 'back\\slash')`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.tsquery, parseSearchQuery(tt.input).tsquery())
+		})
+	}
+}
+
+func TestSnippetHighlight(t *testing.T) {
+	// Act
+	result := snippet("Привет, Мир! Мир прекрасен.", []string{"мир"})
+
+	// Assert - без учета регистра, с сохранением исходного написания
+	assert.Equal(t, "Привет, <mark>Мир</mark>! <mark>Мир</mark> прекрасен.", result)
+}
+
+func TestSnippetEscapesHTML(t *testing.T) {
+	// Act
+	result := snippet(`<script>alert("мир")</script> & мир`, []string{"мир"})
+	escaped := escapeSnippet("<b>" + dbMarkOpen + "мир" + dbMarkClose + "</b>")
+
+	// Assert - разметкой остаются только теги mark
+	assert.Equal(t, `&lt;script&gt;alert(&#34;<mark>мир</mark>&#34;)&lt;/script&gt; &amp; <mark>мир</mark>`, result)
+	assert.Equal(t, "&lt;b&gt;<mark>мир</mark>&lt;/b&gt;", escaped)
+}
@@ -0,0 +1,45 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Stats Repository Interface ---
+
+type StatsRepository interface {
+	ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error)
+}
+
+// --- Stats Repository Implementation ---
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+// --- Stats ---
+
+// Отметки настроения и энергии за период [from, to) по дате записи в хронологическом порядке.
+// Записи без обеих отметок не возвращаются.
+func (r *statsRepository) ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error) {
+	var points []*models.MoodPoint
+	err := r.db.WithContext(ctx).Model(&models.Entry{}).
+		Select("entry_date, mood, energy").
+		Where("user_id = ?", userID).
+		Where("entry_date >= ? AND entry_date < ?", from.UTC(), to.UTC()).
+		Where("mood IS NOT NULL OR energy IS NOT NULL").
+		Order("entry_date ASC").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -0,0 +1,48 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMoodPoints(t *testing.T) {
+	dbtest.Run(t, testListMoodPoints)
+}
+
+func testListMoodPoints(t *testing.T, backend dbtest.Backend) {
+	// Arrange
+	db := openTestDB(t, backend)
+	repo := NewStatsRepository(db)
+
+	userID := uuid.New()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mood := func(v int) *int { return &v }
+	entries := []*models.Entry{
+		{ID: uuid.New(), UserID: userID, Title: "Second", Content: "-", EntryDate: day.Add(20 * time.Hour), Mood: mood(4)},
+		{ID: uuid.New(), UserID: userID, Title: "First", Content: "-", EntryDate: day.Add(8 * time.Hour), Energy: mood(2)},
+		{ID: uuid.New(), UserID: userID, Title: "No marks", Content: "-", EntryDate: day.Add(9 * time.Hour)},
+		{ID: uuid.New(), UserID: userID, Title: "Out of range", Content: "-", EntryDate: day.AddDate(0, 0, 2), Mood: mood(1)},
+		{ID: uuid.New(), UserID: uuid.New(), Title: "Other user", Content: "-", EntryDate: day.Add(10 * time.Hour), Mood: mood(5)},
+	}
+	for _, entry := range entries {
+		require.NoError(t, db.Create(entry).Error)
+	}
+
+	// Act
+	points, err := repo.ListMoodPoints(context.Background(), userID, day, day.AddDate(0, 0, 1))
+
+	// Assert - только отметки пользователя за период, по возрастанию времени
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Nil(t, points[0].Mood)
+	assert.Equal(t, 2, *points[0].Energy)
+	assert.Equal(t, 4, *points[1].Mood)
+	assert.Nil(t, points[1].Energy)
+}
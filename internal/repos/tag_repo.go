@@ -0,0 +1,215 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTagNotFound = errs.NotFound("tag not found")
+
+// --- Tag Repository Interface ---
+
+type TagRepository interface {
+	ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error)
+	RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error
+	MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error
+}
+
+// --- Tag Repository Implementation ---
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// --- Tags ---
+
+// Теги пользователя, которые используются хотя бы в одной записи вне корзины
+func (r *tagRepository) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	var counts []*models.TagCount
+	err := r.db.WithContext(ctx).Table("tags").
+		Select("tags.name AS name, COUNT(entry_tags.entry_id) AS count").
+		Joins("JOIN entry_tags ON entry_tags.tag_id = tags.id").
+		Joins("JOIN entries ON entries.id = entry_tags.entry_id AND entries.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id, tags.name").
+		Order("count DESC, name ASC").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Переименовывает тег. Если тег с новым именем уже существует, теги сливаются.
+func (r *tagRepository) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		source, err := findTag(tx, userID, oldName)
+		if err != nil {
+			return err
+		}
+		if oldName == newName {
+			return nil
+		}
+
+		if err := bumpTaggedEntries(tx, source.ID); err != nil {
+			return err
+		}
+		target, err := findTag(tx, userID, newName)
+		if errors.Is(err, ErrTagNotFound) {
+			return tx.Model(source).Update("name", newName).Error
+		}
+		if err != nil {
+			return err
+		}
+		return mergeTag(tx, source, target)
+	})
+}
+
+// Переносит записи исходных тегов на целевой тег и удаляет исходные.
+// Целевой тег создается, если его еще нет.
+func (r *tagRepository) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		targets, err := resolveTags(tx, userID, []models.Tag{{Name: target}})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range sources {
+			if name == target {
+				continue
+			}
+			source, err := findTag(tx, userID, name)
+			if err != nil {
+				return err
+			}
+			if err := mergeTag(tx, source, &targets[0]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// --- Вспомогательные функции ---
+
+func findTag(db *gorm.DB, userID uuid.UUID, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func mergeTag(db *gorm.DB, source, target *models.Tag) error {
+	if err := bumpTaggedEntries(db, source.ID); err != nil {
+		return err
+	}
+	// Связи, которых у целевого тега еще нет. ID целевого тега берется из
+	// таблицы, а не из параметра: PostgreSQL не приводит текстовый параметр к uuid.
+	err := db.Exec(`
+		INSERT INTO entry_tags (entry_id, tag_id)
+		SELECT entry_tags.entry_id, target.id FROM entry_tags, tags AS target
+		WHERE target.id = ? AND entry_tags.tag_id = ?
+			AND entry_tags.entry_id NOT IN (SELECT entry_id FROM entry_tags WHERE tag_id = ?)`,
+		target.ID, source.ID, target.ID,
+	).Error
+	if err != nil {
+		return err
+	}
+	if err := db.Exec("DELETE FROM entry_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+		return err
+	}
+	return db.Delete(source).Error
+}
+
+// Увеличивает версию записей с тегом: их представление меняется вместе с именем тега
+func bumpTaggedEntries(db *gorm.DB, tagID uuid.UUID) error {
+	return db.Exec(
+		"UPDATE entries SET version = version + 1 WHERE id IN (SELECT entry_id FROM entry_tags WHERE tag_id = ?)",
+		tagID,
+	).Error
+}
+
+// Подставляет ID существующих тегов пользователя по имени и создает недостающие
+func resolveTags(db *gorm.DB, userID uuid.UUID, tags []models.Tag) ([]models.Tag, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+
+	var existing []models.Tag
+	if err := db.Where("user_id = ? AND name IN ?", userID, names).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.Tag, len(existing))
+	for _, tag := range existing {
+		byName[tag.Name] = tag
+	}
+
+	resolved := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag, ok := byName[name]
+		if !ok {
+			tag = models.Tag{ID: uuid.New(), UserID: userID, Name: name}
+			if err := db.Create(&tag).Error; err != nil {
+				return nil, err
+			}
+			byName[name] = tag
+		}
+		resolved = append(resolved, tag)
+	}
+	return resolved, nil
+}
+
+// Загружает теги для уже выбранных записей одним запросом
+func loadTags(db *gorm.DB, entries []*models.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(entries))
+	byID := make(map[uuid.UUID]*models.Entry, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+		byID[entry.ID] = entry
+		entry.Tags = nil
+	}
+
+	var rows []struct {
+		EntryID    uuid.UUID
+		models.Tag `gorm:"embedded"`
+	}
+	err := db.Table("tags").
+		Select("entry_tags.entry_id AS entry_id, tags.*").
+		Joins("JOIN entry_tags ON entry_tags.tag_id = tags.id").
+		Where("entry_tags.entry_id IN ?", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if entry, ok := byID[row.EntryID]; ok {
+			entry.Tags = append(entry.Tags, row.Tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TagRepositoryTestSuite struct {
+	suite.Suite
+	backend   dbtest.Backend
+	db        *gorm.DB
+	entryRepo EntryRepository
+	tagRepo   TagRepository
+	userID    uuid.UUID
+}
+
+func (suite *TagRepositoryTestSuite) SetupTest() {
+	// Отдельная база на каждый тест: теги и связи не пересекаются
+	db := openTestDB(suite.T(), suite.backend)
+
+	suite.db = db
+	suite.entryRepo = NewEntryRepository(db)
+	suite.tagRepo = NewTagRepository(db)
+	suite.userID = uuid.New()
+}
+
+func (suite *TagRepositoryTestSuite) createEntry(title string, tags ...string) *models.Entry {
+	entry := &models.Entry{
+		ID:      uuid.New(),
+		UserID:  suite.userID,
+		Title:   title,
+		Content: "Content",
+	}
+	for _, name := range tags {
+		entry.Tags = append(entry.Tags, models.Tag{Name: name})
+	}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), entry))
+	return entry
+}
+
+func (suite *TagRepositoryTestSuite) tagNames(entryID uuid.UUID) []string {
+	entry, err := suite.entryRepo.Read(context.Background(), suite.userID, entryID.String())
+	suite.Require().NoError(err)
+	var names []string
+	for _, tag := range entry.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+func (suite *TagRepositoryTestSuite) TestCreateReusesTags() {
+	// Arrange
+	first := suite.createEntry("First", "work", "travel")
+	second := suite.createEntry("Second", "work")
+
+	// Assert - тег "work" создан один раз
+	var count int64
+	suite.db.Model(&models.Tag{}).Where("user_id = ? AND name = ?", suite.userID, "work").Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+	assert.Equal(suite.T(), []string{"travel", "work"}, suite.tagNames(first.ID))
+	assert.Equal(suite.T(), []string{"work"}, suite.tagNames(second.ID))
+}
+
+func (suite *TagRepositoryTestSuite) TestUpdateReplacesTags() {
+	// Arrange
+	entry := suite.createEntry("Entry", "work", "travel")
+
+	// Act
+	entry.Tags = []models.Tag{{Name: "travel"}, {Name: "family"}}
+	err := suite.entryRepo.Update(context.Background(), entry)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"family", "travel"}, suite.tagNames(entry.ID))
+}
+
+func (suite *TagRepositoryTestSuite) TestDeleteKeepsLinksUntilPurge() {
+	// Arrange
+	entry := suite.createEntry("Entry", "work")
+	trashRepo := NewTrashRepository(suite.db)
+	links := func() int64 {
+		var count int64
+		suite.db.Table("entry_tags").Where("entry_id = ?", entry.ID).Count(&count)
+		return count
+	}
+
+	// Act - запись в корзине
+	suite.Require().NoError(suite.entryRepo.Delete(context.Background(), entry.UserID, entry.ID.String()))
+	counts, err := suite.tagRepo.ListTags(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+
+	// Assert - связи сохранены для восстановления, но тег не учитывается
+	assert.Equal(suite.T(), int64(1), links())
+	assert.Empty(suite.T(), counts)
+
+	// Act - окончательное удаление
+	suite.Require().NoError(trashRepo.Purge(context.Background(), entry.UserID, entry.ID.String()))
+
+	// Assert
+	assert.Zero(suite.T(), links())
+}
+
+func (suite *TagRepositoryTestSuite) TestListTagsWithCounts() {
+	// Arrange
+	suite.createEntry("One", "work", "travel")
+	suite.createEntry("Two", "work")
+	// Теги другого пользователя не учитываются
+	other := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Other", Content: "Content",
+		Tags: []models.Tag{{Name: "work"}}}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), other))
+
+	// Act
+	counts, err := suite.tagRepo.ListTags(context.Background(), suite.userID)
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []*models.TagCount{
+		{Name: "work", Count: 2},
+		{Name: "travel", Count: 1},
+	}, counts)
+}
+
+func (suite *TagRepositoryTestSuite) TestFilterByTags() {
+	// Arrange
+	suite.createEntry("Both", "work", "travel")
+	suite.createEntry("Work only", "work")
+	suite.createEntry("None")
+
+	// Act
+	anyOf, _, err := suite.entryRepo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+		Tags: []string{"work", "travel"}, TagMatch: models.TagMatchAny})
+	suite.Require().NoError(err)
+	allOf, _, err := suite.entryRepo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+		Tags: []string{"work", "travel"}, TagMatch: models.TagMatchAll})
+	suite.Require().NoError(err)
+	repeated, _, err := suite.entryRepo.ListByUser(context.Background(), suite.userID, models.EntryFilter{
+		Tags: []string{"work", "work"}, TagMatch: models.TagMatchAll})
+	suite.Require().NoError(err)
+
+	// Assert - повторы тега в фильтре не меняют результат
+	assert.Len(suite.T(), anyOf, 2)
+	assert.Len(suite.T(), allOf, 1)
+	assert.Equal(suite.T(), "Both", allOf[0].Title)
+	assert.Len(suite.T(), allOf[0].Tags, 2)
+	assert.Len(suite.T(), repeated, 2)
+}
+
+func (suite *TagRepositoryTestSuite) TestRenameTag() {
+	// Arrange
+	entry := suite.createEntry("Entry", "wrok")
+
+	// Act
+	err := suite.tagRepo.RenameTag(context.Background(), suite.userID, "wrok", "work")
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"work"}, suite.tagNames(entry.ID))
+}
+
+func (suite *TagRepositoryTestSuite) TestRenameTagBumpsEntryVersion() {
+	// Arrange
+	tagged := suite.createEntry("Tagged", "wrok")
+	untagged := suite.createEntry("Untagged")
+
+	// Act
+	err := suite.tagRepo.RenameTag(context.Background(), suite.userID, "wrok", "work")
+
+	// Assert - меняется только версия записей с переименованным тегом
+	assert.NoError(suite.T(), err)
+	found, err := suite.entryRepo.Read(context.Background(), tagged.UserID, tagged.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), tagged.Version+1, found.Version)
+	found, err = suite.entryRepo.Read(context.Background(), untagged.UserID, untagged.ID.String())
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), untagged.Version, found.Version)
+}
+
+func (suite *TagRepositoryTestSuite) TestRenameTagIntoExistingMerges() {
+	// Arrange
+	both := suite.createEntry("Both", "job", "work")
+	jobOnly := suite.createEntry("Job", "job")
+
+	// Act
+	err := suite.tagRepo.RenameTag(context.Background(), suite.userID, "job", "work")
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"work"}, suite.tagNames(both.ID))
+	assert.Equal(suite.T(), []string{"work"}, suite.tagNames(jobOnly.ID))
+
+	counts, err := suite.tagRepo.ListTags(context.Background(), suite.userID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), []*models.TagCount{{Name: "work", Count: 2}}, counts)
+}
+
+func (suite *TagRepositoryTestSuite) TestRenameTagNotFound() {
+	// Act
+	err := suite.tagRepo.RenameTag(context.Background(), suite.userID, "missing", "other")
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, ErrTagNotFound)
+}
+
+func (suite *TagRepositoryTestSuite) TestMergeTagsIntoNewTarget() {
+	// Arrange
+	first := suite.createEntry("First", "trip")
+	second := suite.createEntry("Second", "vacation", "trip")
+
+	// Act
+	err := suite.tagRepo.MergeTags(context.Background(), suite.userID, []string{"trip", "vacation"}, "travel")
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"travel"}, suite.tagNames(first.ID))
+	assert.Equal(suite.T(), []string{"travel"}, suite.tagNames(second.ID))
+}
+
+func TestTagRepositoryTestSuite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &TagRepositoryTestSuite{backend: backend})
+	})
+}
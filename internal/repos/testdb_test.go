@@ -0,0 +1,22 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/migrations"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Пустая база бэкенда со схемой из миграций: тесты проверяют запросы
+// на той же схеме, что и в рабочей базе
+func openTestDB(t testing.TB, backend dbtest.Backend) *gorm.DB {
+	db := backend.Open(t)
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,138 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTokenNotFound = errs.NotFound("token not found")
+
+// --- Token Repository Interface ---
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *models.PersonalAccessToken) error
+	ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error)
+	// Поиск для аутентификации: владелец токена еще неизвестен
+	ReadTokenByHash(ctx context.Context, secretHash string) (*models.PersonalAccessToken, error)
+	DeleteToken(ctx context.Context, userID uuid.UUID, id string) error
+	// Отмечает использование токена; отозванный токен пропускается без ошибки
+	TouchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// --- Token Repository Implementation ---
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+// --- Tokens ---
+
+func (r *tokenRepository) CreateToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return conflict(r.db.WithContext(ctx).Create(token).Error, errTokenExists)
+}
+
+// Токены пользователя, новые первыми
+func (r *tokenRepository) ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	var tokens []*models.PersonalAccessToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *tokenRepository) ReadTokenByHash(ctx context.Context, secretHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("secret_hash = ?", secretHash).First(&token).Error
+	if err != nil {
+		return nil, notFound(err, ErrTokenNotFound)
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) DeleteToken(ctx context.Context, userID uuid.UUID, id string) error {
+	tokenID, ok := parseID(id)
+	if !ok {
+		return ErrTokenNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", tokenID, userID).
+		Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r *tokenRepository) TouchToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
+
+// --- Вспомогательные функции ---
+
+// Токен с тем же ID или секретом уже есть; в базе это нарушение ключа,
+// которое conflict переводит в эту же ошибку
+var errTokenExists = errs.Conflict("token already exists")
+
+// Копия токена; хранилища без базы не отдают наружу свои значения
+func cloneToken(token *models.PersonalAccessToken) *models.PersonalAccessToken {
+	clone := *token
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.UTC()
+		clone.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.UTC()
+		clone.LastUsedAt = &lastUsedAt
+	}
+	clone.CreatedAt = token.CreatedAt.UTC()
+	return &clone
+}
+
+// Порядок ListTokens для хранилищ без базы: новые первыми
+func sortTokens(tokens []*models.PersonalAccessToken) {
+	sort.Slice(tokens, func(i, j int) bool {
+		a, b := tokens[i], tokens[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+}
+
+// Заполняет время создания, как autoCreateTime в базе
+func prepareToken(token *models.PersonalAccessToken, now time.Time) *models.PersonalAccessToken {
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = now
+	}
+	return cloneToken(token)
+}
+
+// Есть ли у токенов такой же ID или секрет
+func tokenConflicts(tokens map[uuid.UUID]*models.PersonalAccessToken, token *models.PersonalAccessToken) bool {
+	for id, stored := range tokens {
+		if id == token.ID || stored.SecretHash == token.SecretHash {
+			return true
+		}
+	}
+	return false
+}
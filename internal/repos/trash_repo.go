@@ -0,0 +1,129 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTrashedEntryNotFound = errs.NotFound("entry not found in trash")
+
+// --- Trash Repository Interface ---
+
+type TrashRepository interface {
+	ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error)
+	ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error)
+	Restore(ctx context.Context, userID uuid.UUID, id string) error
+	Purge(ctx context.Context, userID uuid.UUID, id string) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// --- Trash Repository Implementation ---
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// --- Trash ---
+
+// Записи пользователя в корзине, недавно удаленные первыми
+func (r *trashRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	var entries []*models.Entry
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Tags", orderTagsByName).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *trashRepository) ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	entryID, ok := parseID(id)
+	if !ok {
+		return nil, ErrTrashedEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	var entry models.Entry
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Tags", orderTagsByName).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		First(&entry, "id = ?", entryID).Error
+	if err != nil {
+		return nil, notFound(err, ErrTrashedEntryNotFound)
+	}
+	return &entry, nil
+}
+
+// Возвращает запись из корзины
+func (r *trashRepository) Restore(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return ErrTrashedEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Entry{}).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", entryID, userID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTrashedEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// Окончательно удаляет запись из корзины вместе со связями и историей
+func (r *trashRepository) Purge(ctx context.Context, userID uuid.UUID, id string) error {
+	entryID, ok := parseID(id)
+	if !ok {
+		return ErrTrashedEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", entryID, userID).
+			Delete(&models.Entry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTrashedEntryNotFound.Wrap(gorm.ErrRecordNotFound)
+		}
+		if err := tx.Exec("DELETE FROM entry_tags WHERE entry_id = ?", entryID).Error; err != nil {
+			return err
+		}
+		return tx.Where("entry_id = ?", entryID).Delete(&models.EntryRevision{}).Error
+	})
+}
+
+// Окончательно удаляет все записи, попавшие в корзину раньше cutoff.
+// Системная операция: затрагивает записи всех пользователей.
+func (r *trashRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := tx.Unscoped().Model(&models.Entry{}).
+			Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff.UTC())
+		if err := tx.Exec("DELETE FROM entry_tags WHERE entry_id IN (?)", expired).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("entry_id IN (?)", expired).Delete(&models.EntryRevision{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff.UTC()).
+			Delete(&models.Entry{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
@@ -0,0 +1,189 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/database/dbtest"
+	"diary/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TrashRepositoryTestSuite struct {
+	suite.Suite
+	backend   dbtest.Backend
+	db        *gorm.DB
+	entryRepo EntryRepository
+	trashRepo TrashRepository
+	userID    uuid.UUID
+}
+
+func (suite *TrashRepositoryTestSuite) SetupTest() {
+	db := openTestDB(suite.T(), suite.backend)
+
+	suite.db = db
+	suite.entryRepo = NewEntryRepository(db)
+	suite.trashRepo = NewTrashRepository(db)
+	suite.userID = uuid.New()
+}
+
+func (suite *TrashRepositoryTestSuite) createTrashed(title string, deletedAt time.Time) *models.Entry {
+	entry := &models.Entry{
+		ID:      uuid.New(),
+		UserID:  suite.userID,
+		Title:   title,
+		Content: "Content",
+		Tags:    []models.Tag{{Name: "work"}},
+	}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), entry))
+	suite.Require().NoError(suite.entryRepo.Delete(context.Background(), entry.UserID, entry.ID.String()))
+	suite.Require().NoError(suite.db.Unscoped().Model(entry).Update("deleted_at", deletedAt.UTC()).Error)
+	return entry
+}
+
+func (suite *TrashRepositoryTestSuite) TestDeletedEntryHidden() {
+	// Arrange
+	entry := suite.createTrashed("Trashed", time.Now())
+
+	// Act
+	_, readErr := suite.entryRepo.Read(context.Background(), entry.UserID, entry.ID.String())
+	listed, _, err := suite.entryRepo.ListByUser(context.Background(), suite.userID, models.EntryFilter{})
+
+	// Assert
+	assert.ErrorIs(suite.T(), readErr, gorm.ErrRecordNotFound)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), listed)
+}
+
+func (suite *TrashRepositoryTestSuite) TestListTrash() {
+	// Arrange
+	now := time.Now()
+	older := suite.createTrashed("Older", now.Add(-2*time.Hour))
+	newer := suite.createTrashed("Newer", now.Add(-time.Hour))
+	// Активные записи и корзина другого пользователя не попадают в список
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), &models.Entry{
+		ID: uuid.New(), UserID: suite.userID, Title: "Active", Content: "Content"}))
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), &models.Entry{
+		ID: uuid.New(), UserID: uuid.New(), Title: "Other", Content: "Content"}))
+
+	// Act
+	entries, err := suite.trashRepo.ListTrash(context.Background(), suite.userID)
+
+	// Assert - недавно удаленные первыми, с тегами
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+	assert.Equal(suite.T(), newer.ID, entries[0].ID)
+	assert.Equal(suite.T(), older.ID, entries[1].ID)
+	assert.Len(suite.T(), entries[0].Tags, 1)
+	assert.True(suite.T(), entries[0].DeletedAt.Valid)
+}
+
+func (suite *TrashRepositoryTestSuite) TestRestore() {
+	// Arrange
+	entry := suite.createTrashed("Trashed", time.Now())
+
+	// Act
+	err := suite.trashRepo.Restore(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert - запись снова доступна вместе с тегами
+	assert.NoError(suite.T(), err)
+	restored, err := suite.entryRepo.Read(context.Background(), entry.UserID, entry.ID.String())
+	suite.Require().NoError(err)
+	assert.False(suite.T(), restored.DeletedAt.Valid)
+	assert.Len(suite.T(), restored.Tags, 1)
+
+	_, err = suite.trashRepo.ReadTrashed(context.Background(), entry.UserID, entry.ID.String())
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+}
+
+func (suite *TrashRepositoryTestSuite) TestRestoreNotInTrash() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: suite.userID, Title: "Active", Content: "Content"}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), entry))
+
+	// Act
+	err := suite.trashRepo.Restore(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+}
+
+func (suite *TrashRepositoryTestSuite) TestForeignTrashHidden() {
+	// Arrange
+	entry := suite.createTrashed("Trashed", time.Now())
+	stranger := uuid.New()
+
+	// Act
+	_, readErr := suite.trashRepo.ReadTrashed(context.Background(), stranger, entry.ID.String())
+	restoreErr := suite.trashRepo.Restore(context.Background(), stranger, entry.ID.String())
+	purgeErr := suite.trashRepo.Purge(context.Background(), stranger, entry.ID.String())
+
+	// Assert
+	assert.ErrorIs(suite.T(), readErr, ErrTrashedEntryNotFound)
+	assert.ErrorIs(suite.T(), restoreErr, ErrTrashedEntryNotFound)
+	assert.ErrorIs(suite.T(), purgeErr, ErrTrashedEntryNotFound)
+	_, err := suite.trashRepo.ReadTrashed(context.Background(), entry.UserID, entry.ID.String())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *TrashRepositoryTestSuite) TestPurge() {
+	// Arrange
+	entry := suite.createTrashed("Trashed", time.Now())
+
+	// Act
+	err := suite.trashRepo.Purge(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	var count int64
+	suite.db.Unscoped().Model(&models.Entry{}).Where("id = ?", entry.ID).Count(&count)
+	assert.Zero(suite.T(), count)
+	assert.ErrorIs(suite.T(), suite.trashRepo.Purge(context.Background(), entry.UserID, entry.ID.String()), gorm.ErrRecordNotFound)
+}
+
+func (suite *TrashRepositoryTestSuite) TestPurgeActiveEntryNotAllowed() {
+	// Arrange
+	entry := &models.Entry{ID: uuid.New(), UserID: suite.userID, Title: "Active", Content: "Content"}
+	suite.Require().NoError(suite.entryRepo.Create(context.Background(), entry))
+
+	// Act
+	err := suite.trashRepo.Purge(context.Background(), entry.UserID, entry.ID.String())
+
+	// Assert - запись вне корзины не удаляется
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+	_, err = suite.entryRepo.Read(context.Background(), entry.UserID, entry.ID.String())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *TrashRepositoryTestSuite) TestPurgeDeletedBefore() {
+	// Arrange
+	now := time.Now()
+	expired := suite.createTrashed("Expired", now.AddDate(0, 0, -40))
+	recent := suite.createTrashed("Recent", now.AddDate(0, 0, -1))
+
+	// Act
+	purged, err := suite.trashRepo.PurgeDeletedBefore(context.Background(), now.AddDate(0, 0, -30))
+
+	// Assert
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), purged)
+
+	_, err = suite.trashRepo.ReadTrashed(context.Background(), expired.UserID, expired.ID.String())
+	assert.ErrorIs(suite.T(), err, gorm.ErrRecordNotFound)
+	_, err = suite.trashRepo.ReadTrashed(context.Background(), recent.UserID, recent.ID.String())
+	assert.NoError(suite.T(), err)
+
+	var links int64
+	suite.db.Table("entry_tags").Where("entry_id = ?", expired.ID).Count(&links)
+	assert.Zero(suite.T(), links)
+}
+
+func TestTrashRepositoryTestSuite(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T, backend dbtest.Backend) {
+		suite.Run(t, &TrashRepositoryTestSuite{backend: backend})
+	})
+}
@@ -0,0 +1,134 @@
+package repos
+
+import (
+	"context"
+	"diary/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- Usage Repository Interface ---
+
+// Системные выборки по всем пользователям для администраторов
+type UsageRepository interface {
+	// Использование по пользователям, у которых есть записи или токены,
+	// в порядке ID
+	ListUsage(ctx context.Context) ([]*models.UserUsage, error)
+}
+
+// --- Usage Repository Implementation ---
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// --- Usage ---
+
+func (r *usageRepository) ListUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	db := r.db.WithContext(ctx)
+
+	var entries []struct {
+		UserID  uuid.UUID
+		Entries int64
+		Trashed int64
+	}
+	err := db.Unscoped().Model(&models.Entry{}).
+		Select("user_id, " +
+			"SUM(CASE WHEN deleted_at IS NULL THEN 1 ELSE 0 END) AS entries, " +
+			"SUM(CASE WHEN deleted_at IS NOT NULL THEN 1 ELSE 0 END) AS trashed").
+		Group("user_id").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// MAX(updated_at) в SQLite возвращается строкой без типа колонки,
+	// поэтому выбираются сами строки с последним изменением
+	var latest []struct {
+		UserID    uuid.UUID
+		UpdatedAt time.Time
+	}
+	err = db.Unscoped().Model(&models.Entry{}).
+		Select("user_id, updated_at").
+		Where("updated_at = (SELECT MAX(e.updated_at) FROM entries e WHERE e.user_id = entries.user_id)").
+		Scan(&latest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []struct {
+		UserID uuid.UUID
+		Tokens int64
+	}
+	err = db.Model(&models.PersonalAccessToken{}).
+		Select("user_id, COUNT(*) AS tokens").
+		Group("user_id").
+		Scan(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(usageByUser)
+	for _, row := range entries {
+		item := usage.user(row.UserID)
+		item.Entries, item.Trashed = row.Entries, row.Trashed
+	}
+	for _, row := range latest {
+		usage.touch(row.UserID, row.UpdatedAt)
+	}
+	for _, row := range tokens {
+		usage.user(row.UserID).Tokens = row.Tokens
+	}
+	return usage.list(), nil
+}
+
+// --- Вспомогательные функции ---
+
+// Накопитель использования для всех хранилищ
+type usageByUser map[uuid.UUID]*models.UserUsage
+
+func (u usageByUser) user(userID uuid.UUID) *models.UserUsage {
+	item, ok := u[userID]
+	if !ok {
+		item = &models.UserUsage{UserID: userID}
+		u[userID] = item
+	}
+	return item
+}
+
+// Учитывает изменение записи пользователя в LastEntryAt
+func (u usageByUser) touch(userID uuid.UUID, updatedAt time.Time) {
+	item := u.user(userID)
+	updatedAt = updatedAt.UTC()
+	if item.LastEntryAt == nil || updatedAt.After(*item.LastEntryAt) {
+		item.LastEntryAt = &updatedAt
+	}
+}
+
+// Учитывает запись в хранилищах без базы
+func countEntry(usage usageByUser, userID uuid.UUID, item *entrySummary) {
+	if isTrashed(item) {
+		usage.user(userID).Trashed++
+	} else {
+		usage.user(userID).Entries++
+	}
+	usage.touch(userID, item.UpdatedAt)
+}
+
+func (u usageByUser) list() []*models.UserUsage {
+	list := make([]*models.UserUsage, 0, len(u))
+	for _, item := range u {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UserID.String() < list[j].UserID.String()
+	})
+	return list
+}
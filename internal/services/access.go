@@ -0,0 +1,24 @@
+package services
+
+import (
+	"diary/internal/errs"
+
+	"github.com/google/uuid"
+)
+
+// --- Проверка доступа ---
+
+// Каждый метод сервиса, работающий с данными пользователя, принимает
+// ID действующего пользователя и передает его в репозиторий, где он
+// входит в условие каждого запроса. Поэтому чужие записи для сервиса
+// неотличимы от несуществующих, а вызывающему коду не нужно сверять
+// владельца самостоятельно.
+
+var ErrNoActingUser = errs.Forbidden("acting user is required")
+
+func requireUser(userID uuid.UUID) error {
+	if userID == uuid.Nil {
+		return ErrNoActingUser
+	}
+	return nil
+}
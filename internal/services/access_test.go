@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestActingUserRequired(t *testing.T) {
+	// Arrange
+	repo := new(MockEntryRepository)
+	service := NewEntryService(repo, repos.NewMemoryRepository(), DefaultEntryLimits())
+
+	// Act
+	_, getErr := service.GetEntryByID(context.Background(), uuid.Nil, uuid.NewString())
+	createErr := service.CreateEntry(context.Background(), uuid.Nil, &models.Entry{Title: "Title"})
+
+	// Assert - без пользователя репозиторий не вызывается
+	assert.ErrorIs(t, getErr, ErrNoActingUser)
+	assert.ErrorIs(t, createErr, ErrNoActingUser)
+	repo.AssertNotCalled(t, "Read", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateEntryOwnedByActingUser(t *testing.T) {
+	// Arrange
+	repo := new(MockEntryRepository)
+	service := NewEntryService(repo, repos.NewMemoryRepository(), DefaultEntryLimits())
+	userID := uuid.New()
+	repo.On("Create", mock.Anything).Return(nil)
+
+	// Act - владелец из тела запроса игнорируется
+	entry := &models.Entry{UserID: uuid.New(), Title: "Title"}
+	err := service.CreateEntry(context.Background(), userID, entry)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, userID, entry.UserID)
+}
+
+func TestUpdateForeignEntry(t *testing.T) {
+	// Arrange
+	repo := new(MockEntryRepository)
+	service := NewEntryService(repo, repos.NewMemoryRepository(), DefaultEntryLimits())
+	entry := &models.Entry{ID: uuid.New(), UserID: uuid.New(), Title: "Title"}
+
+	// Act
+	err := service.UpdateEntry(context.Background(), uuid.New(), entry)
+
+	// Assert
+	assert.ErrorIs(t, err, repos.ErrEntryNotFound)
+	repo.AssertNotCalled(t, "Update", mock.Anything)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+)
+
+// --- Admin Service Interface ---
+
+// Системные операции над данными всех пользователей. Действующего
+// пользователя у них нет: доступ проверяет политика маршрута (admin:read).
+type AdminService interface {
+	ListUserUsage(ctx context.Context) ([]*models.UserUsage, error)
+	UsageTotals(ctx context.Context) (*models.UsageTotals, error)
+}
+
+// --- Admin Service Implementation ---
+
+type adminService struct {
+	repo repos.UsageRepository
+}
+
+func NewAdminService(repo repos.UsageRepository) AdminService {
+	return &adminService{repo: repo}
+}
+
+// --- Business Logic Admin ---
+
+func (s *adminService) ListUserUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	return s.repo.ListUsage(ctx)
+}
+
+func (s *adminService) UsageTotals(ctx context.Context) (*models.UsageTotals, error) {
+	usage, err := s.repo.ListUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	totals := &models.UsageTotals{Users: int64(len(usage))}
+	for _, item := range usage {
+		totals.Entries += item.Entries
+		totals.Trashed += item.Trashed
+		totals.Tokens += item.Tokens
+	}
+	return totals, nil
+}
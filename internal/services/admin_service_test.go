@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTotals(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := repos.NewMemoryRepository()
+	entries := NewEntryService(repo, repo, DefaultEntryLimits())
+	tokens := NewTokenService(repo)
+	service := NewAdminService(repo)
+	writer, scripter := uuid.New(), uuid.New()
+	for _, title := range []string{"One", "Two"} {
+		require.NoError(t, entries.CreateEntry(ctx, writer, &models.Entry{Title: title, Content: "Text", EntryDate: time.Now()}))
+	}
+	_, _, err := tokens.CreateToken(ctx, scripter, "cron", []models.TokenScope{models.ScopeEntriesRead}, nil)
+	require.NoError(t, err)
+
+	// Act
+	usage, listErr := service.ListUserUsage(ctx)
+	totals, totalsErr := service.UsageTotals(ctx)
+
+	// Assert
+	require.NoError(t, listErr)
+	require.NoError(t, totalsErr)
+	assert.Len(t, usage, 2)
+	assert.Equal(t, &models.UsageTotals{Users: 2, Entries: 2, Tokens: 1}, totals)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// --- Entry Key Service Interface ---
+
+// Ключи для шифрования записей на клиенте (E2EE). Клиент создает ключ
+// записей, оборачивает его ключом из пароля пользователя и хранит обертку
+// на сервере, чтобы получить ее на другом устройстве. Сервер проверяет
+// только форму обертки: ни пароля, ни ключа записей у него нет.
+type EntryKeyService interface {
+	// Сохраняет обертку ключа; повторный вызов с тем же keyID заменяет ее
+	// (например, после смены пароля), сам ключ записей должен остаться прежним
+	PutEntryKey(ctx context.Context, userID uuid.UUID, key *models.EntryKey) error
+	ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error)
+	GetEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error)
+}
+
+const (
+	// Длина идентификатора ключа; совпадает с размером колонки
+	MaxKeyIDLength = 64
+	// Обернутый ключ и соль - десятки байт; ограничение защищает хранилище
+	maxWrappedKeySize = 1024
+	maxSaltSize       = 1024
+	minSaltSize       = 16
+	// Меньшее число итераций PBKDF2 делает перебор пароля слишком дешевым
+	MinKDFIterations = 100_000
+)
+
+const errInvalidKeyID = "must be 1-64 characters: letters, digits, '.', '_' or '-'"
+
+// --- Entry Key Service Implementation ---
+
+type entryKeyService struct {
+	repo repos.EntryKeyRepository
+}
+
+func NewEntryKeyService(repo repos.EntryKeyRepository) EntryKeyService {
+	return &entryKeyService{repo: repo}
+}
+
+// --- Business Logic Entry Key ---
+
+// Ключ всегда сохраняется для userID, независимо от key.UserID
+func (s *entryKeyService) PutEntryKey(ctx context.Context, userID uuid.UUID, key *models.EntryKey) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	key.UserID = userID
+	if err := validateEntryKey(key); err != nil {
+		return err
+	}
+	return s.repo.PutEntryKey(ctx, key)
+}
+
+func (s *entryKeyService) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListEntryKeys(ctx, userID)
+}
+
+func (s *entryKeyService) GetEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ReadEntryKey(ctx, userID, keyID)
+}
+
+// --- Проверка обертки ---
+
+func validateEntryKey(key *models.EntryKey) error {
+	var v fieldErrors
+
+	if !validKeyID(key.KeyID) {
+		v.add("key_id", errInvalidKeyID)
+	}
+	if nonceSize, ok := models.EncryptionNonceSize(key.Algorithm); !ok {
+		v.add("algorithm", "unsupported algorithm, expected "+models.EncryptionA256GCM)
+	} else if len(key.Nonce) != nonceSize {
+		v.add("nonce", fmt.Sprintf("must be %d bytes", nonceSize))
+	}
+	switch {
+	case len(key.WrappedKey) == 0:
+		v.add("wrapped_key", "must not be empty")
+	case len(key.WrappedKey) > maxWrappedKeySize:
+		v.add("wrapped_key", fmt.Sprintf("must be at most %d bytes", maxWrappedKeySize))
+	}
+	if key.KDF != models.KDFPBKDF2SHA256 {
+		v.add("kdf", "unsupported key derivation, expected "+models.KDFPBKDF2SHA256)
+	}
+	if len(key.Salt) < minSaltSize || len(key.Salt) > maxSaltSize {
+		v.add("salt", fmt.Sprintf("must be %d to %d bytes", minSaltSize, maxSaltSize))
+	}
+	if key.Iterations < MinKDFIterations {
+		v.add("iterations", fmt.Sprintf("must be at least %d", MinKDFIterations))
+	}
+	return v.err()
+}
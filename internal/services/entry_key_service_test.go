@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/repos"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutAndGetEntryKey(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := NewEntryKeyService(repos.NewMemoryRepository())
+	userID, otherID := uuid.New(), uuid.New()
+	key := newWrappedKey("main")
+	key.UserID = otherID
+
+	// Act - владелец из тела запроса игнорируется
+	err := service.PutEntryKey(ctx, userID, key)
+
+	// Assert
+	require.NoError(t, err)
+	found, err := service.GetEntryKey(ctx, userID, "main")
+	require.NoError(t, err)
+	assert.Equal(t, userID, found.UserID)
+	_, err = service.GetEntryKey(ctx, otherID, "main")
+	assert.ErrorIs(t, err, repos.ErrEntryKeyNotFound)
+	keys, err := service.ListEntryKeys(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestPutEntryKeyValidation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := NewEntryKeyService(repos.NewMemoryRepository())
+	key := newWrappedKey("../main")
+	key.Algorithm = "A128CBC"
+	key.WrappedKey = nil
+	key.KDF = "scrypt"
+	key.Salt = []byte("short")
+	key.Iterations = 1000
+
+	// Act
+	err := service.PutEntryKey(ctx, uuid.New(), key)
+	_, noUserErr := service.ListEntryKeys(ctx, uuid.Nil)
+
+	// Assert - все ошибки полей сразу
+	require.ErrorIs(t, err, errs.ErrValidation)
+	_, fields := errs.Details(err)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	assert.Equal(t, []string{"key_id", "algorithm", "wrapped_key", "kdf", "salt", "iterations"}, names)
+	assert.ErrorIs(t, noUserErr, ErrNoActingUser)
+}
+
+func TestValidKeyID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"main", true},
+		{"2024-05.device_1", true},
+		{"", false},
+		{"with space", false},
+		{"slash/inside", false},
+		{"ключ", false},
+		{string(make([]byte, MaxKeyIDLength+1)), false},
+	}
+
+	for _, tt := range tests {
+		// Act & Assert
+		assert.Equal(t, tt.want, validKeyID(tt.id), "key id %q", tt.id)
+	}
+}
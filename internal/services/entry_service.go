@@ -1,8 +1,14 @@
 package services
 
 import (
+	"context"
+	"diary/internal/errs"
 	"diary/internal/models"
 	"diary/internal/repos"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -10,44 +16,171 @@ import (
 // --- Entry Service Interface ---
 
 type EntryService interface {
-	CreateEntry(entry *models.Entry) error
-	GetEntryByID(id string) (*models.Entry, error)
-	UpdateEntry(entry *models.Entry) error
-	DeleteEntry(id string) error
-	ListEntries() ([]*models.Entry, error)
+	CreateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error
+	GetEntryByID(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error)
+	UpdateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error
+	DeleteEntry(ctx context.Context, userID uuid.UUID, id string) error
+	ListEntries(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error)
+	ListEntriesByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error)
 }
 
+var (
+	ErrInvalidMood     = errs.Field("mood", "invalid mood")
+	ErrInvalidEnergy   = errs.Field("energy", "invalid energy")
+	ErrInvalidTimeZone = errs.Field("timezone", "invalid time zone")
+	ErrInvalidDate     = errs.Field("entry_date", "invalid entry date")
+	ErrUnknownKey      = errs.Field("encrypted.key_id", "unknown encryption key")
+)
+
 // --- Entry Service Implementation ---
 
 type entryService struct {
-	repo repos.EntryRepository
+	repo   repos.EntryRepository
+	keys   repos.EntryKeyRepository
+	limits EntryLimits
 }
 
-func NewEntryService(repo repos.EntryRepository) EntryService {
-	return &entryService{repo: repo}
+func NewEntryService(repo repos.EntryRepository, keys repos.EntryKeyRepository, limits EntryLimits) EntryService {
+	return &entryService{repo: repo, keys: keys, limits: limits}
 }
 
 // --- Business Logic Entry ---
 
-func (s *entryService) CreateEntry(entry *models.Entry) error {
+// Запись всегда создается от имени userID, независимо от entry.UserID
+func (s *entryService) CreateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	entry.UserID = userID
 	if entry.ID == uuid.Nil {
 		entry.ID = uuid.New()
 	}
-	return s.repo.Create(entry)
+	if entry.EntryDate.IsZero() {
+		entry.EntryDate = time.Now()
+	}
+	if err := s.validate(ctx, entry); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, entry)
+}
+
+func (s *entryService) GetEntryByID(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.Read(ctx, userID, id)
+}
+
+func (s *entryService) UpdateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	if entry.UserID != userID {
+		return repos.ErrEntryNotFound
+	}
+	if err := s.validate(ctx, entry); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, entry)
+}
+
+func (s *entryService) DeleteEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *entryService) ListEntries(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID)
 }
 
-func (s *entryService) GetEntryByID(id string) (*models.Entry, error) {
-	return s.repo.Read(id)
+func (s *entryService) ListEntriesByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, "", err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultPageSize
+	}
+	if filter.Limit > models.MaxPageSize {
+		filter.Limit = models.MaxPageSize
+	}
+	// Повторы убираются: tags=work,Work - это один тег
+	tags := make([]string, 0, len(filter.Tags))
+	seen := make(map[string]bool, len(filter.Tags))
+	for _, name := range filter.Tags {
+		if name = models.NormalizeTagName(name); name != "" && !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	filter.Tags = tags
+	return s.repo.ListByUser(ctx, userID, filter)
 }
 
-func (s *entryService) UpdateEntry(entry *models.Entry) error {
-	return s.repo.Update(entry)
+// Проверяет поля записи; зашифрованная запись должна ссылаться на
+// сохраненный ключ пользователя, иначе ее никто не расшифрует
+func (s *entryService) validate(ctx context.Context, entry *models.Entry) error {
+	if err := validateEntry(entry, s.limits); err != nil {
+		return err
+	}
+	if !entry.Encrypted() {
+		return nil
+	}
+	_, err := s.keys.ReadEntryKey(ctx, entry.UserID, entry.KeyID)
+	if errors.Is(err, repos.ErrEntryKeyNotFound) {
+		return ErrUnknownKey
+	}
+	return err
 }
 
-func (s *entryService) DeleteEntry(id string) error {
-	return s.repo.Delete(id)
+// --- Проверка настроения и энергии ---
+
+const (
+	maxMoodEmojiLength = 8
+	maxMoodLabelLength = 64
+)
+
+func validateMood(entry *models.Entry) error {
+	entry.MoodEmoji = strings.TrimSpace(entry.MoodEmoji)
+	entry.MoodLabel = strings.TrimSpace(entry.MoodLabel)
+
+	if entry.Mood == nil {
+		// Эмодзи и подпись описывают оценку и без нее не имеют смысла
+		if entry.MoodEmoji != "" || entry.MoodLabel != "" {
+			return ErrInvalidMood
+		}
+	} else if *entry.Mood < models.MoodMin || *entry.Mood > models.MoodMax {
+		return ErrInvalidMood
+	}
+	if utf8.RuneCountInString(entry.MoodEmoji) > maxMoodEmojiLength ||
+		utf8.RuneCountInString(entry.MoodLabel) > maxMoodLabelLength {
+		return ErrInvalidMood
+	}
+
+	if entry.Energy != nil && (*entry.Energy < models.EnergyMin || *entry.Energy > models.EnergyMax) {
+		return ErrInvalidEnergy
+	}
+	return nil
 }
 
-func (s *entryService) ListEntries() ([]*models.Entry, error) {
-	return s.repo.List()
+// --- Дата записи ---
+
+// Проверяет часовой пояс и приводит дату записи к UTC
+func normalizeEntryDate(entry *models.Entry) error {
+	if entry.TimeZone == "" {
+		entry.TimeZone = "UTC"
+	}
+	// "Local" зависит от сервера и не описывает пояс пользователя
+	if _, err := time.LoadLocation(entry.TimeZone); err != nil || entry.TimeZone == "Local" {
+		return ErrInvalidTimeZone
+	}
+	if entry.EntryDate.IsZero() {
+		return ErrInvalidDate
+	}
+	entry.EntryDate = entry.EntryDate.UTC()
+	return nil
 }
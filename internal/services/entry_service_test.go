@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сервисы поверх хранилища в памяти: проверяется поведение целиком,
+// без моков и без базы данных
+
+func newMemoryService() Service {
+	return NewService(repos.NewMemoryRepository(), DefaultEntryLimits())
+}
+
+func TestCreateAndReadEntry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	entry := &models.Entry{Title: "Title", Content: "Content", Tags: []models.Tag{{Name: "  Work "}, {Name: "work"}}}
+
+	// Act
+	err := service.CreateEntry(ctx, userID, entry)
+
+	// Assert - ID и дата записи назначаются, теги нормализуются
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, entry.ID)
+	found, err := service.GetEntryByID(ctx, userID, entry.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Title", found.Title)
+	assert.False(t, found.EntryDate.IsZero())
+	require.Len(t, found.Tags, 1)
+	assert.Equal(t, "work", found.Tags[0].Name)
+
+	_, err = service.GetEntryByID(ctx, uuid.New(), entry.ID.String())
+	assert.ErrorIs(t, err, repos.ErrEntryNotFound)
+}
+
+func TestUpdateEntryLifecycle(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	entry := &models.Entry{Title: "Draft", Content: "Content"}
+	require.NoError(t, service.CreateEntry(ctx, userID, entry))
+	stale := *entry
+
+	// Act
+	entry.Title = "Final"
+	updateErr := service.UpdateEntry(ctx, userID, entry)
+	stale.Title = "Lost"
+	conflictErr := service.UpdateEntry(ctx, userID, &stale)
+
+	// Assert
+	assert.NoError(t, updateErr)
+	assert.ErrorIs(t, conflictErr, repos.ErrVersionConflict)
+	revisions, err := service.ListRevisions(ctx, userID, entry.ID.String())
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+
+	// Удаленная запись попадает в корзину и восстанавливается
+	require.NoError(t, service.DeleteEntry(ctx, userID, entry.ID.String()))
+	trash, err := service.ListTrash(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, trash, 1)
+	require.NoError(t, service.RestoreEntry(ctx, userID, entry.ID.String()))
+	found, err := service.GetEntryByID(ctx, userID, entry.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Final", found.Title)
+}
+
+func TestListEntriesByUserPageSize(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	for i := 0; i < models.MaxPageSize+5; i++ {
+		require.NoError(t, service.CreateEntry(ctx, userID, &models.Entry{Title: "Entry", Content: "Content"}))
+	}
+
+	// Act
+	defaultPage, _, err := service.ListEntriesByUser(ctx, userID, models.EntryFilter{})
+	require.NoError(t, err)
+	maxPage, next, err := service.ListEntriesByUser(ctx, userID, models.EntryFilter{Limit: models.MaxPageSize * 2})
+	require.NoError(t, err)
+
+	// Assert - размер страницы ограничивается сервисом
+	assert.Len(t, defaultPage, models.DefaultPageSize)
+	assert.Len(t, maxPage, models.MaxPageSize)
+	assert.NotEmpty(t, next)
+}
+
+func TestListEntriesByUserRepeatedTags(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	require.NoError(t, service.CreateEntry(ctx, userID, &models.Entry{Title: "Tagged", Tags: []models.Tag{{Name: "work"}}}))
+
+	// Act - имена совпадают после нормализации
+	entries, _, err := service.ListEntriesByUser(ctx, userID, models.EntryFilter{Tags: []string{"work", " Work "}, TagMatch: models.TagMatchAll})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// Обертка ключа в том виде, в каком ее присылает клиент
+func newWrappedKey(keyID string) *models.EntryKey {
+	return &models.EntryKey{
+		KeyID:      keyID,
+		Algorithm:  models.EncryptionA256GCM,
+		Nonce:      make([]byte, 12),
+		WrappedKey: make([]byte, 48),
+		KDF:        models.KDFPBKDF2SHA256,
+		Salt:       make([]byte, 16),
+		Iterations: MinKDFIterations,
+	}
+}
+
+func newEncryptedEntry(keyID string) *models.Entry {
+	return &models.Entry{
+		Algorithm:  models.EncryptionA256GCM,
+		KeyID:      keyID,
+		Nonce:      make([]byte, 12),
+		Ciphertext: []byte("opaque ciphertext"),
+		Tags:       []models.Tag{{Name: " Private "}},
+	}
+}
+
+func TestCreateEncryptedEntry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	require.NoError(t, service.PutEntryKey(ctx, userID, newWrappedKey("main")))
+	entry := newEncryptedEntry("main")
+
+	// Act
+	err := service.CreateEntry(ctx, userID, entry)
+
+	// Assert - пустой заголовок допустим, метаданные по-прежнему проверяются
+	require.NoError(t, err)
+	found, err := service.GetEntryByID(ctx, userID, entry.ID.String())
+	require.NoError(t, err)
+	assert.True(t, found.Encrypted())
+	assert.Equal(t, []byte("opaque ciphertext"), found.Ciphertext)
+	assert.Equal(t, "private", found.Tags[0].Name)
+
+	// Поиск по зашифрованным записям ничего не находит
+	results, err := service.SearchEntries(ctx, userID, "opaque", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestCreateEncryptedEntryValidation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	require.NoError(t, service.PutEntryKey(ctx, userID, newWrappedKey("main")))
+	malformed := newEncryptedEntry("main")
+	malformed.Title = "Plain title"
+	malformed.Nonce = []byte{1}
+	malformed.Ciphertext = nil
+
+	// Act
+	malformedErr := service.CreateEntry(ctx, userID, malformed)
+	unknownKeyErr := service.CreateEntry(ctx, userID, newEncryptedEntry("other"))
+	foreignKeyErr := service.CreateEntry(ctx, uuid.New(), newEncryptedEntry("main"))
+
+	// Assert
+	require.ErrorIs(t, malformedErr, errs.ErrValidation)
+	_, fields := errs.Details(malformedErr)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	assert.Equal(t, []string{"title", "encrypted.nonce", "encrypted.ciphertext"}, names)
+	assert.ErrorIs(t, unknownKeyErr, ErrUnknownKey)
+	assert.ErrorIs(t, foreignKeyErr, ErrUnknownKey)
+}
+
+func TestRestoreRevisionOfEncryptedEntry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service := newMemoryService()
+	userID := uuid.New()
+	require.NoError(t, service.PutEntryKey(ctx, userID, newWrappedKey("main")))
+	entry := newEncryptedEntry("main")
+	require.NoError(t, service.CreateEntry(ctx, userID, entry))
+
+	// Act
+	_, err := service.RestoreRevision(ctx, userID, entry.ID.String(), 1)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrEncryptedRevision)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+}
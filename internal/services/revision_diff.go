@@ -0,0 +1,120 @@
+package services
+
+import (
+	"diary/internal/models"
+	"strings"
+	"unicode"
+)
+
+// --- Построчное и пословное сравнение текста ---
+
+// Предел размера таблицы LCS; для больших изменений текст считается
+// замененным целиком, чтобы не расходовать память
+const maxDiffCells = 4 << 20
+
+func diffText(old, new string, mode models.DiffMode) []models.DiffOp {
+	var a, b []string
+	if mode == models.DiffByWord {
+		a, b = splitWords(old), splitWords(new)
+	} else {
+		a, b = splitLines(old), splitLines(new)
+	}
+
+	// Общие начало и конец не участвуют в LCS
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []models.DiffOp
+	ops = appendOps(ops, models.DiffEqual, a[:prefix])
+	ops = append(ops, diffTokens(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	ops = appendOps(ops, models.DiffEqual, a[len(a)-suffix:])
+	return mergeOps(ops)
+}
+
+// Различия по наибольшей общей подпоследовательности токенов
+func diffTokens(a, b []string) []models.DiffOp {
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxDiffCells {
+		return appendOps(appendOps(nil, models.DiffDelete, a), models.DiffInsert, b)
+	}
+
+	// lcs[i][j] - длина LCS для a[i:] и b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	var ops []models.DiffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, models.DiffOp{Type: models.DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			ops = append(ops, models.DiffOp{Type: models.DiffDelete, Text: a[i]})
+			i++
+		default:
+			ops = append(ops, models.DiffOp{Type: models.DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	ops = appendOps(ops, models.DiffDelete, a[i:])
+	return appendOps(ops, models.DiffInsert, b[j:])
+}
+
+func appendOps(ops []models.DiffOp, opType models.DiffOpType, tokens []string) []models.DiffOp {
+	for _, token := range tokens {
+		ops = append(ops, models.DiffOp{Type: opType, Text: token})
+	}
+	return ops
+}
+
+// Склеивает соседние фрагменты одного типа
+func mergeOps(ops []models.DiffOp) []models.DiffOp {
+	merged := make([]models.DiffOp, 0, len(ops))
+	for _, op := range ops {
+		if n := len(merged); n > 0 && merged[n-1].Type == op.Type {
+			merged[n-1].Text += op.Text
+			continue
+		}
+		merged = append(merged, op)
+	}
+	return merged
+}
+
+// Строки вместе с переводом строки
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Слова и пробельные промежутки между ними как отдельные токены
+func splitWords(s string) []string {
+	var tokens []string
+	start := 0
+	runes := []rune(s)
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || unicode.IsSpace(runes[i]) != unicode.IsSpace(runes[i-1]) {
+			tokens = append(tokens, string(runes[start:i]))
+			start = i
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDiffMode   = errs.Field("mode", "invalid diff mode, expected line or word")
+	ErrEncryptedRevision = errs.Conflict("encrypted entries cannot be restored from revisions")
+)
+
+// --- Revision Service Interface ---
+
+type RevisionService interface {
+	ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error)
+	GetRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error)
+	DiffRevisions(ctx context.Context, userID uuid.UUID, entryID string, from, to int, mode models.DiffMode) (*models.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.Entry, error)
+}
+
+// --- Revision Service Implementation ---
+
+type revisionService struct {
+	entries   repos.EntryRepository
+	revisions repos.RevisionRepository
+	limits    EntryLimits
+}
+
+func NewRevisionService(entries repos.EntryRepository, revisions repos.RevisionRepository, limits EntryLimits) RevisionService {
+	return &revisionService{entries: entries, revisions: revisions, limits: limits}
+}
+
+// --- Business Logic Revision ---
+
+// История записи; запись должна существовать и принадлежать userID
+func (s *revisionService) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.entries.Read(ctx, userID, entryID); err != nil {
+		return nil, err
+	}
+	return s.revisions.ListRevisions(ctx, userID, entryID)
+}
+
+func (s *revisionService) GetRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.revisions.ReadRevision(ctx, userID, entryID, number)
+}
+
+// Различия заголовка и текста между двумя ревизиями записи (по строкам или словам)
+func (s *revisionService) DiffRevisions(ctx context.Context, userID uuid.UUID, entryID string, from, to int, mode models.DiffMode) (*models.RevisionDiff, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	if mode == "" {
+		mode = models.DiffByLine
+	}
+	if !mode.Valid() {
+		return nil, ErrInvalidDiffMode
+	}
+
+	older, err := s.revisions.ReadRevision(ctx, userID, entryID, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.revisions.ReadRevision(ctx, userID, entryID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevisionDiff{
+		From: from,
+		To:   to,
+		Mode: mode,
+		// Заголовок однострочный, поэтому всегда сравнивается по словам
+		Title:   diffText(older.Title, newer.Title, models.DiffByWord),
+		Content: diffText(older.Content, newer.Content, mode),
+	}, nil
+}
+
+// Возвращает заголовок и текст из старой ревизии. История не переписывается:
+// восстановленное состояние сохраняется новой ревизией.
+func (s *revisionService) RestoreRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.Entry, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	revision, err := s.revisions.ReadRevision(ctx, userID, entryID, number)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.entries.Read(ctx, userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	// Ревизии хранят только заголовок и текст, которых у зашифрованной
+	// записи на сервере нет
+	if entry.Encrypted() {
+		return nil, ErrEncryptedRevision
+	}
+
+	// Старая ревизия проверяется как обычное обновление: с тех пор могли
+	// измениться ограничения, например уменьшиться max_title_length
+	entry.Title = revision.Title
+	entry.Content = revision.Content
+	if err := validateEntry(entry, s.limits); err != nil {
+		return nil, err
+	}
+	if err := s.entries.Update(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+
+	"github.com/google/uuid"
+)
+
+// --- Search Service Interface ---
+
+type SearchService interface {
+	SearchEntries(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error)
+}
+
+// --- Search Service Implementation ---
+
+type searchService struct {
+	repo repos.SearchRepository
+}
+
+func NewSearchService(repo repos.SearchRepository) SearchService {
+	return &searchService{repo: repo}
+}
+
+// --- Business Logic Search ---
+
+func (s *searchService) SearchEntries(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = models.DefaultPageSize
+	}
+	if limit > models.MaxPageSize {
+		limit = models.MaxPageSize
+	}
+	return s.repo.Search(ctx, userID, query, limit)
+}
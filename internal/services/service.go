@@ -1,47 +1,187 @@
 package services
 
 import (
+	"context"
 	"diary/internal/models"
 	"diary/internal/repos"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Главный интерфейс объединяет все подсервисы
 type Service interface {
 	EntryService
+	SearchService
+	TagService
+	StatsService
+	TrashService
+	RevisionService
+	TokenService
+	AdminService
+	EntryKeyService
 }
 
 // --- Комбинирующий сервис ---
 
 type service struct {
-	entryService EntryService
+	entryService    EntryService
+	searchService   SearchService
+	tagService      TagService
+	statsService    StatsService
+	trashService    TrashService
+	revisionService RevisionService
+	tokenService    TokenService
+	adminService    AdminService
+	keyService      EntryKeyService
 }
 
 // Прокси-методы EntryService
 
-func (s *service) CreateEntry(entry *models.Entry) error {
-	return s.entryService.CreateEntry(entry)
+func (s *service) CreateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error {
+	return s.entryService.CreateEntry(ctx, userID, entry)
+}
+
+func (s *service) GetEntryByID(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	return s.entryService.GetEntryByID(ctx, userID, id)
+}
+
+func (s *service) UpdateEntry(ctx context.Context, userID uuid.UUID, entry *models.Entry) error {
+	return s.entryService.UpdateEntry(ctx, userID, entry)
+}
+
+func (s *service) DeleteEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	return s.entryService.DeleteEntry(ctx, userID, id)
+}
+
+func (s *service) ListEntries(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	return s.entryService.ListEntries(ctx, userID)
+}
+
+func (s *service) ListEntriesByUser(ctx context.Context, userID uuid.UUID, filter models.EntryFilter) ([]*models.Entry, string, error) {
+	return s.entryService.ListEntriesByUser(ctx, userID, filter)
+}
+
+// Прокси-методы SearchService
+
+func (s *service) SearchEntries(ctx context.Context, userID uuid.UUID, query string, limit int) ([]*models.SearchResult, error) {
+	return s.searchService.SearchEntries(ctx, userID, query, limit)
+}
+
+// Прокси-методы TagService
+
+func (s *service) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	return s.tagService.ListTags(ctx, userID)
+}
+
+func (s *service) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	return s.tagService.RenameTag(ctx, userID, oldName, newName)
+}
+
+func (s *service) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	return s.tagService.MergeTags(ctx, userID, sources, target)
+}
+
+// Прокси-методы StatsService
+
+func (s *service) MoodTrend(ctx context.Context, userID uuid.UUID, from, to time.Time, period models.StatsPeriod, loc *time.Location) ([]*models.MoodBucket, error) {
+	return s.statsService.MoodTrend(ctx, userID, from, to, period, loc)
+}
+
+// Прокси-методы TrashService
+
+func (s *service) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	return s.trashService.ListTrash(ctx, userID)
+}
+
+func (s *service) GetTrashedEntry(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	return s.trashService.GetTrashedEntry(ctx, userID, id)
 }
 
-func (s *service) GetEntryByID(id string) (*models.Entry, error) {
-	return s.entryService.GetEntryByID(id)
+func (s *service) RestoreEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	return s.trashService.RestoreEntry(ctx, userID, id)
 }
 
-func (s *service) UpdateEntry(entry *models.Entry) error {
-	return s.entryService.UpdateEntry(entry)
+func (s *service) PurgeEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	return s.trashService.PurgeEntry(ctx, userID, id)
+}
+
+func (s *service) PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.trashService.PurgeExpiredTrash(ctx, retention)
+}
+
+// Прокси-методы RevisionService
+
+func (s *service) ListRevisions(ctx context.Context, userID uuid.UUID, entryID string) ([]*models.EntryRevision, error) {
+	return s.revisionService.ListRevisions(ctx, userID, entryID)
+}
+
+func (s *service) GetRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.EntryRevision, error) {
+	return s.revisionService.GetRevision(ctx, userID, entryID, number)
+}
+
+func (s *service) DiffRevisions(ctx context.Context, userID uuid.UUID, entryID string, from, to int, mode models.DiffMode) (*models.RevisionDiff, error) {
+	return s.revisionService.DiffRevisions(ctx, userID, entryID, from, to, mode)
+}
+
+func (s *service) RestoreRevision(ctx context.Context, userID uuid.UUID, entryID string, number int) (*models.Entry, error) {
+	return s.revisionService.RestoreRevision(ctx, userID, entryID, number)
+}
+
+// Прокси-методы TokenService
+
+func (s *service) CreateToken(ctx context.Context, userID uuid.UUID, name string, scopes []models.TokenScope, expiresAt *time.Time) (*models.PersonalAccessToken, string, error) {
+	return s.tokenService.CreateToken(ctx, userID, name, scopes, expiresAt)
+}
+
+func (s *service) ListTokens(ctx context.Context, userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	return s.tokenService.ListTokens(ctx, userID)
+}
+
+func (s *service) RevokeToken(ctx context.Context, userID uuid.UUID, id string) error {
+	return s.tokenService.RevokeToken(ctx, userID, id)
+}
+
+func (s *service) AuthenticateToken(ctx context.Context, secret string) (*models.PersonalAccessToken, error) {
+	return s.tokenService.AuthenticateToken(ctx, secret)
+}
+
+// Прокси-методы AdminService
+
+func (s *service) ListUserUsage(ctx context.Context) ([]*models.UserUsage, error) {
+	return s.adminService.ListUserUsage(ctx)
+}
+
+func (s *service) UsageTotals(ctx context.Context) (*models.UsageTotals, error) {
+	return s.adminService.UsageTotals(ctx)
+}
+
+// Прокси-методы EntryKeyService
+
+func (s *service) PutEntryKey(ctx context.Context, userID uuid.UUID, key *models.EntryKey) error {
+	return s.keyService.PutEntryKey(ctx, userID, key)
 }
 
-func (s *service) DeleteEntry(id string) error {
-	return s.entryService.DeleteEntry(id)
+func (s *service) ListEntryKeys(ctx context.Context, userID uuid.UUID) ([]*models.EntryKey, error) {
+	return s.keyService.ListEntryKeys(ctx, userID)
 }
 
-func (s *service) ListEntries() ([]*models.Entry, error) {
-	return s.entryService.ListEntries()
+func (s *service) GetEntryKey(ctx context.Context, userID uuid.UUID, keyID string) (*models.EntryKey, error) {
+	return s.keyService.GetEntryKey(ctx, userID, keyID)
 }
 
 // --- Конструктор комбинирующего сервиса ---
 
-func NewService(repo repos.Repository) Service {
+func NewService(repo repos.Repository, limits EntryLimits) Service {
 	return &service{
-		entryService: NewEntryService(repo),
+		entryService:    NewEntryService(repo, repo, limits),
+		searchService:   NewSearchService(repo),
+		tagService:      NewTagService(repo),
+		statsService:    NewStatsService(repo),
+		trashService:    NewTrashService(repo),
+		revisionService: NewRevisionService(repo, repo, limits),
+		tokenService:    NewTokenService(repo),
+		adminService:    NewAdminService(repo),
+		keyService:      NewEntryKeyService(repo),
 	}
 }
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidStatsRange = errs.Validation("invalid stats range")
+
+// Максимальная длина периода для статистики
+const MaxStatsRange = 5 * 366 * 24 * time.Hour
+
+// --- Stats Service Interface ---
+
+type StatsService interface {
+	MoodTrend(ctx context.Context, userID uuid.UUID, from, to time.Time, period models.StatsPeriod, loc *time.Location) ([]*models.MoodBucket, error)
+}
+
+// --- Stats Service Implementation ---
+
+type statsService struct {
+	repo repos.StatsRepository
+}
+
+func NewStatsService(repo repos.StatsRepository) StatsService {
+	return &statsService{repo: repo}
+}
+
+// --- Business Logic Stats ---
+
+// Агрегирует настроение и энергию по дням, неделям или месяцам.
+// Границы периодов считаются в часовом поясе loc; пустые периоды не возвращаются.
+func (s *statsService) MoodTrend(ctx context.Context, userID uuid.UUID, from, to time.Time, period models.StatsPeriod, loc *time.Location) ([]*models.MoodBucket, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	if !period.Valid() || !from.Before(to) || to.Sub(from) > MaxStatsRange {
+		return nil, ErrInvalidStatsRange
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	points, err := s.repo.ListMoodPoints(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []*models.MoodBucket
+	var mood, energy scaleAccumulator
+	var current *models.MoodBucket
+
+	flush := func() {
+		if current != nil {
+			current.Mood = mood.result()
+			current.Energy = energy.result()
+			buckets = append(buckets, current)
+		}
+		mood, energy = scaleAccumulator{}, scaleAccumulator{}
+	}
+
+	// Точки отсортированы по времени, поэтому периоды идут подряд
+	for _, point := range points {
+		start := periodStart(point.EntryDate.In(loc), period)
+		if current == nil || !current.Start.Equal(start) {
+			flush()
+			current = &models.MoodBucket{Start: start}
+		}
+		current.Entries++
+		mood.add(point.Mood)
+		energy.add(point.Energy)
+	}
+	flush()
+
+	return buckets, nil
+}
+
+// Начало дня, недели (понедельник) или месяца, содержащего t
+func periodStart(t time.Time, period models.StatsPeriod) time.Time {
+	y, m, d := t.Date()
+	switch period {
+	case models.PeriodWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case models.PeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+type scaleAccumulator struct {
+	count    int
+	sum      int
+	min, max int
+}
+
+func (a *scaleAccumulator) add(v *int) {
+	if v == nil {
+		return
+	}
+	if a.count == 0 || *v < a.min {
+		a.min = *v
+	}
+	if a.count == 0 || *v > a.max {
+		a.max = *v
+	}
+	a.count++
+	a.sum += *v
+}
+
+func (a *scaleAccumulator) result() *models.ScaleAggregate {
+	if a.count == 0 {
+		return nil
+	}
+	return &models.ScaleAggregate{
+		Count: a.count,
+		Avg:   float64(a.sum) / float64(a.count),
+		Min:   a.min,
+		Max:   a.max,
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock Stats Repository ---
+
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) ListMoodPoints(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.MoodPoint, error) {
+	args := m.Called(userID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MoodPoint), args.Error(1)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestMoodTrendWeekly(t *testing.T) {
+	// Arrange - понедельник 6 мая 2024 и воскресенье 12 мая в одной неделе
+	repo := new(MockStatsRepository)
+	service := NewStatsService(repo)
+	userID := uuid.New()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	repo.On("ListMoodPoints", userID, from, to).Return([]*models.MoodPoint{
+		{EntryDate: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), Mood: intPtr(2)},
+		{EntryDate: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC), Mood: intPtr(3), Energy: intPtr(4)},
+		{EntryDate: time.Date(2024, 5, 12, 21, 0, 0, 0, time.UTC), Mood: intPtr(5)},
+	}, nil)
+
+	// Act
+	buckets, err := service.MoodTrend(context.Background(), userID, from, to, models.PeriodWeek, time.UTC)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+
+	assert.Equal(t, time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), buckets[0].Start)
+	assert.Equal(t, 1, buckets[0].Entries)
+	assert.Nil(t, buckets[0].Energy)
+
+	assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), buckets[1].Start)
+	assert.Equal(t, 2, buckets[1].Entries)
+	assert.Equal(t, &models.ScaleAggregate{Count: 2, Avg: 4, Min: 3, Max: 5}, buckets[1].Mood)
+	assert.Equal(t, &models.ScaleAggregate{Count: 1, Avg: 4, Min: 4, Max: 4}, buckets[1].Energy)
+	repo.AssertExpectations(t)
+}
+
+func TestMoodTrendUsesTimezone(t *testing.T) {
+	// Arrange - 23:30 UTC это уже следующий день в Москве
+	repo := new(MockStatsRepository)
+	service := NewStatsService(repo)
+	loc, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 7)
+
+	repo.On("ListMoodPoints", mock.Anything, from, to).Return([]*models.MoodPoint{
+		{EntryDate: time.Date(2024, 5, 2, 23, 30, 0, 0, time.UTC), Mood: intPtr(3)},
+	}, nil)
+
+	// Act
+	buckets, err := service.MoodTrend(context.Background(), uuid.New(), from, to, models.PeriodDay, loc)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	assert.Equal(t, "2024-05-03", buckets[0].Start.Format(time.DateOnly))
+}
+
+func TestMoodTrendInvalidRange(t *testing.T) {
+	// Arrange
+	service := NewStatsService(new(MockStatsRepository))
+	now := time.Now()
+
+	// Act
+	_, reversed := service.MoodTrend(context.Background(), uuid.New(), now, now.Add(-time.Hour), models.PeriodDay, nil)
+	_, badPeriod := service.MoodTrend(context.Background(), uuid.New(), now.Add(-time.Hour), now, models.StatsPeriod("year"), nil)
+
+	// Assert
+	assert.ErrorIs(t, reversed, ErrInvalidStatsRange)
+	assert.ErrorIs(t, badPeriod, ErrInvalidStatsRange)
+}
+
+func TestValidateMood(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry models.Entry
+		err   error
+	}{
+		{"no marks", models.Entry{}, nil},
+		{"valid", models.Entry{Mood: intPtr(3), MoodEmoji: "🙂", MoodLabel: "ok", Energy: intPtr(5)}, nil},
+		{"mood too low", models.Entry{Mood: intPtr(0)}, ErrInvalidMood},
+		{"mood too high", models.Entry{Mood: intPtr(6)}, ErrInvalidMood},
+		{"label without mood", models.Entry{MoodLabel: "happy"}, ErrInvalidMood},
+		{"energy out of range", models.Entry{Energy: intPtr(9)}, ErrInvalidEnergy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			err := validateMood(&entry)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
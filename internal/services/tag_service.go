@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidTag = errs.Field("tags", "invalid tag name")
+
+// --- Tag Service Interface ---
+
+type TagService interface {
+	ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error)
+	RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error
+	MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error
+}
+
+// --- Tag Service Implementation ---
+
+type tagService struct {
+	repo repos.TagRepository
+}
+
+func NewTagService(repo repos.TagRepository) TagService {
+	return &tagService{repo: repo}
+}
+
+// --- Business Logic Tag ---
+
+func (s *tagService) ListTags(ctx context.Context, userID uuid.UUID) ([]*models.TagCount, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTags(ctx, userID)
+}
+
+func (s *tagService) RenameTag(ctx context.Context, userID uuid.UUID, oldName, newName string) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	oldName, err := normalizeTagName(oldName)
+	if err != nil {
+		return err
+	}
+	newName, err = normalizeTagName(newName)
+	if err != nil {
+		return err
+	}
+	return s.repo.RenameTag(ctx, userID, oldName, newName)
+}
+
+func (s *tagService) MergeTags(ctx context.Context, userID uuid.UUID, sources []string, target string) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	target, err := normalizeTagName(target)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return ErrInvalidTag
+	}
+	normalized := make([]string, 0, len(sources))
+	for _, name := range sources {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return err
+		}
+		normalized = append(normalized, name)
+	}
+	return s.repo.MergeTags(ctx, userID, normalized, target)
+}
+
+// --- Нормализация тегов ---
+
+func normalizeTagName(name string) (string, error) {
+	name, problem := normalizeText(name, false)
+	if problem != "" {
+		return "", ErrInvalidTag
+	}
+	name = models.NormalizeTagName(name)
+	if name == "" || utf8.RuneCountInString(name) > models.MaxTagNameLength {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// Нормализует имена тегов записи и убирает повторы
+func normalizeTags(tags []models.Tag, maxTags int) ([]models.Tag, error) {
+	if len(tags) > maxTags {
+		return nil, ErrInvalidTag
+	}
+	seen := make(map[string]bool, len(tags))
+	result := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		name, err := normalizeTagName(tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, models.Tag{Name: name})
+	}
+	return result, nil
+}
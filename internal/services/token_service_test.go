@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"diary/internal/errs"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сервис токенов с управляемыми часами
+func newTokenTestService(now *time.Time) (TokenService, repos.TokenRepository) {
+	repo := repos.NewMemoryRepository()
+	service := NewTokenService(repo).(*tokenService)
+	service.now = func() time.Time { return *now }
+	return service, repo
+}
+
+func TestCreateAndAuthenticateToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service, repo := newTokenTestService(&now)
+	userID := uuid.New()
+	expiresAt := now.Add(24 * time.Hour)
+
+	// Act
+	token, secret, err := service.CreateToken(ctx, userID, "  cron  ", []models.TokenScope{models.ScopeEntriesRead, models.ScopeEntriesRead}, &expiresAt)
+	require.NoError(t, err)
+	authenticated, authErr := service.AuthenticateToken(ctx, secret)
+
+	// Assert - хранится только хеш, имя и права нормализованы
+	require.NoError(t, authErr)
+	assert.True(t, strings.HasPrefix(secret, TokenPrefix))
+	assert.NotContains(t, token.SecretHash, strings.TrimPrefix(secret, TokenPrefix))
+	assert.Equal(t, "cron", token.Name)
+	assert.Equal(t, "entries:read", token.Scopes)
+	assert.Equal(t, token.ID, authenticated.ID)
+	assert.Equal(t, userID, authenticated.UserID)
+
+	stored, err := repo.ListTokens(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.NotNil(t, stored[0].LastUsedAt)
+	assert.True(t, now.Equal(*stored[0].LastUsedAt))
+}
+
+func TestAuthenticateTokenTouchInterval(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	service, repo := newTokenTestService(&now)
+	userID := uuid.New()
+	_, secret, err := service.CreateToken(ctx, userID, "cron", []models.TokenScope{models.ScopeEntriesWrite}, nil)
+	require.NoError(t, err)
+	lastUsed := func() time.Time {
+		tokens, err := repo.ListTokens(ctx, userID)
+		require.NoError(t, err)
+		return *tokens[0].LastUsedAt
+	}
+
+	// Act & Assert - частые запросы не пишут в хранилище на каждый вызов
+	_, err = service.AuthenticateToken(ctx, secret)
+	require.NoError(t, err)
+	now = start.Add(30 * time.Second)
+	_, err = service.AuthenticateToken(ctx, secret)
+	require.NoError(t, err)
+	assert.True(t, start.Equal(lastUsed()))
+
+	now = start.Add(2 * time.Minute)
+	_, err = service.AuthenticateToken(ctx, secret)
+	require.NoError(t, err)
+	assert.True(t, now.Equal(lastUsed()))
+}
+
+func TestAuthenticateTokenRejects(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service, _ := newTokenTestService(&now)
+	userID := uuid.New()
+	expiresAt := now.Add(time.Hour)
+	expiring, expiringSecret, err := service.CreateToken(ctx, userID, "short", []models.TokenScope{models.ScopeEntriesRead}, &expiresAt)
+	require.NoError(t, err)
+	revoked, revokedSecret, err := service.CreateToken(ctx, userID, "revoked", []models.TokenScope{models.ScopeEntriesRead}, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.RevokeToken(ctx, userID, revoked.ID.String()))
+
+	// Act
+	now = *expiring.ExpiresAt
+	_, expiredErr := service.AuthenticateToken(ctx, expiringSecret)
+	_, revokedErr := service.AuthenticateToken(ctx, revokedSecret)
+	_, unknownErr := service.AuthenticateToken(ctx, TokenPrefix+"unknown")
+	_, foreignErr := service.AuthenticateToken(ctx, "eyJhbGciOiJIUzI1NiJ9.e30.sig")
+
+	// Assert
+	assert.ErrorIs(t, expiredErr, ErrInvalidToken)
+	assert.ErrorIs(t, revokedErr, ErrInvalidToken)
+	assert.ErrorIs(t, unknownErr, ErrInvalidToken)
+	assert.ErrorIs(t, foreignErr, ErrInvalidToken)
+}
+
+func TestCreateTokenValidation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service, _ := newTokenTestService(&now)
+	past := now.Add(-time.Minute)
+
+	// Act
+	_, _, err := service.CreateToken(ctx, uuid.New(), " ", []models.TokenScope{"entries:delete"}, &past)
+	_, _, noScopes := service.CreateToken(ctx, uuid.New(), "cron", nil, nil)
+	_, _, noUser := service.CreateToken(ctx, uuid.Nil, "cron", []models.TokenScope{models.ScopeEntriesRead}, nil)
+
+	// Assert - все ошибки полей сразу
+	require.ErrorIs(t, err, errs.ErrValidation)
+	_, fields := errs.Details(err)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	assert.Equal(t, []string{"name", "scopes", "expires_at"}, names)
+	assert.ErrorIs(t, noScopes, ErrInvalidScopes)
+	assert.ErrorIs(t, noUser, ErrNoActingUser)
+}
+
+func TestRevokeForeignToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Now()
+	service, _ := newTokenTestService(&now)
+	token, secret, err := service.CreateToken(ctx, uuid.New(), "cron", []models.TokenScope{models.ScopeEntriesRead}, nil)
+	require.NoError(t, err)
+
+	// Act
+	err = service.RevokeToken(ctx, uuid.New(), token.ID.String())
+
+	// Assert - чужой токен не отзывается
+	assert.ErrorIs(t, err, repos.ErrTokenNotFound)
+	_, err = service.AuthenticateToken(ctx, secret)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"diary/internal/repos"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Trash Service Interface ---
+
+type TrashService interface {
+	ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error)
+	GetTrashedEntry(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error)
+	RestoreEntry(ctx context.Context, userID uuid.UUID, id string) error
+	PurgeEntry(ctx context.Context, userID uuid.UUID, id string) error
+	PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// --- Trash Service Implementation ---
+
+type trashService struct {
+	repo repos.TrashRepository
+	now  func() time.Time
+}
+
+func NewTrashService(repo repos.TrashRepository) TrashService {
+	return &trashService{repo: repo, now: time.Now}
+}
+
+// --- Business Logic Trash ---
+
+func (s *trashService) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTrash(ctx, userID)
+}
+
+func (s *trashService) GetTrashedEntry(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	if err := requireUser(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ReadTrashed(ctx, userID, id)
+}
+
+func (s *trashService) RestoreEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	return s.repo.Restore(ctx, userID, id)
+}
+
+func (s *trashService) PurgeEntry(ctx context.Context, userID uuid.UUID, id string) error {
+	if err := requireUser(userID); err != nil {
+		return err
+	}
+	return s.repo.Purge(ctx, userID, id)
+}
+
+// Окончательно удаляет записи, которые лежат в корзине дольше retention.
+// Неположительный срок хранения отключает очистку. Системная задача,
+// выполняется без действующего пользователя.
+func (s *trashService) PurgeExpiredTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.PurgeDeletedBefore(ctx, s.now().Add(-retention))
+}
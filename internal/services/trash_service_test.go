@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"diary/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Mock Trash Repository ---
+
+type MockTrashRepository struct {
+	mock.Mock
+}
+
+func (m *MockTrashRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*models.Entry, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Entry), args.Error(1)
+}
+
+func (m *MockTrashRepository) ReadTrashed(ctx context.Context, userID uuid.UUID, id string) (*models.Entry, error) {
+	args := m.Called(userID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Entry), args.Error(1)
+}
+
+func (m *MockTrashRepository) Restore(ctx context.Context, userID uuid.UUID, id string) error {
+	return m.Called(userID, id).Error(0)
+}
+
+func (m *MockTrashRepository) Purge(ctx context.Context, userID uuid.UUID, id string) error {
+	return m.Called(userID, id).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	// Arrange
+	repo := new(MockTrashRepository)
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	service := &trashService{repo: repo, now: func() time.Time { return now }}
+	repo.On("PurgeDeletedBefore", now.Add(-30*24*time.Hour)).Return(int64(3), nil)
+
+	// Act
+	purged, err := service.PurgeExpiredTrash(context.Background(), 30*24*time.Hour)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	repo.AssertExpectations(t)
+}
+
+func TestPurgeExpiredTrashDisabled(t *testing.T) {
+	// Arrange
+	repo := new(MockTrashRepository)
+	service := NewTrashService(repo)
+
+	// Act
+	purged, err := service.PurgeExpiredTrash(context.Background(), 0)
+
+	// Assert - при нулевом сроке хранения репозиторий не вызывается
+	assert.NoError(t, err)
+	assert.Zero(t, purged)
+	repo.AssertNotCalled(t, "PurgeDeletedBefore", mock.Anything)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// --- Ограничения полей записи ---
+
+type EntryLimits struct {
+	// Длина заголовка в символах; не больше размера колонки title
+	MaxTitleLength int
+	// Длина текста в символах
+	MaxContentLength int
+	// Количество тегов у одной записи
+	MaxTags int
+}
+
+// Запас на служебные данные шифротекста: тег аутентификации, поля
+// формата клиента
+const ciphertextOverhead = 1024
+
+// Наибольший размер шифротекста в байтах. Сервер не видит открытый текст,
+// поэтому ограничивает его оценкой сверху: заголовок и текст в JSON, до
+// 6 байт на символ (экранирование \uXXXX).
+func (l EntryLimits) MaxCiphertextSize() int {
+	return 6*(l.MaxTitleLength+l.MaxContentLength) + ciphertextOverhead
+}
+
+func DefaultEntryLimits() EntryLimits {
+	return EntryLimits{
+		MaxTitleLength:   models.MaxTitleColumnLength,
+		MaxContentLength: models.DefaultMaxContentLength,
+		MaxTags:          models.DefaultMaxTags,
+	}
+}
+
+// --- Проверка записи ---
+
+// Нормализует поля записи и проверяет их все сразу, чтобы клиент получил
+// полный список ошибок по полям, а не только первую.
+func validateEntry(entry *models.Entry, limits EntryLimits) error {
+	var v fieldErrors
+
+	if entry.Encrypted() {
+		validateEncrypted(&v, entry, limits)
+	} else {
+		entry.Title = v.text("title", entry.Title, false, limits.MaxTitleLength)
+		if entry.Title == "" && !v.has("title") {
+			v.add("title", "must not be empty")
+		}
+		entry.Content = v.text("content", entry.Content, true, limits.MaxContentLength)
+	}
+	entry.MoodEmoji = v.text("mood_emoji", entry.MoodEmoji, false, 0)
+	entry.MoodLabel = v.text("mood_label", entry.MoodLabel, false, 0)
+
+	v.check(normalizeEntryDate(entry))
+	v.check(validateMood(entry))
+
+	tags, err := normalizeTags(entry.Tags, limits.MaxTags)
+	v.check(err)
+	if err == nil {
+		entry.Tags = tags
+	}
+	return v.err()
+}
+
+// Заголовок и текст зашифрованной записи есть только в шифротексте, их
+// сервер проверить не может; проверяется только форма шифротекста
+func validateEncrypted(v *fieldErrors, entry *models.Entry, limits EntryLimits) {
+	if entry.Title != "" {
+		v.add("title", "must be empty for encrypted entries")
+	}
+	if entry.Content != "" {
+		v.add("content", "must be empty for encrypted entries")
+	}
+	if nonceSize, ok := models.EncryptionNonceSize(entry.Algorithm); !ok {
+		v.add("encrypted.algorithm", "unsupported algorithm, expected "+models.EncryptionA256GCM)
+	} else if len(entry.Nonce) != nonceSize {
+		v.add("encrypted.nonce", fmt.Sprintf("must be %d bytes", nonceSize))
+	}
+	if !validKeyID(entry.KeyID) {
+		v.add("encrypted.key_id", errInvalidKeyID)
+	}
+	switch {
+	case len(entry.Ciphertext) == 0:
+		v.add("encrypted.ciphertext", "must not be empty")
+	case len(entry.Ciphertext) > limits.MaxCiphertextSize():
+		v.add("encrypted.ciphertext", fmt.Sprintf("must be at most %d bytes", limits.MaxCiphertextSize()))
+	}
+}
+
+// Идентификатор ключа выбирает клиент; он попадает в пути API и имена полей
+func validKeyID(id string) bool {
+	if id == "" || len(id) > MaxKeyIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// --- Нормализация текста ---
+
+// Проверяет, что строка - корректный UTF-8 без управляющих символов,
+// приводит ее к NFC и обрезает пробелы по краям. В многострочном тексте
+// допускаются переводы строк и табуляция; \r\n заменяется на \n.
+// Возвращает нормализованную строку или описание проблемы.
+func normalizeText(s string, multiline bool) (string, string) {
+	if !utf8.ValidString(s) {
+		return "", "must be valid UTF-8"
+	}
+	if multiline {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && !(multiline && (r == '\n' || r == '\t')) {
+			return "", "must not contain control characters"
+		}
+	}
+	return strings.TrimSpace(norm.NFC.String(s)), ""
+}
+
+// --- Сбор ошибок по полям ---
+
+type fieldErrors struct {
+	fields []errs.FieldError
+	causes []error
+}
+
+func (v *fieldErrors) add(field, message string) {
+	v.fields = append(v.fields, errs.FieldError{Field: field, Message: message})
+}
+
+func (v *fieldErrors) has(field string) bool {
+	for _, f := range v.fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Нормализует текстовое поле; maxLength <= 0 означает отсутствие ограничения
+func (v *fieldErrors) text(field, value string, multiline bool, maxLength int) string {
+	normalized, problem := normalizeText(value, multiline)
+	if problem != "" {
+		v.add(field, problem)
+		return value
+	}
+	if maxLength > 0 && utf8.RuneCountInString(normalized) > maxLength {
+		v.add(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	}
+	return normalized
+}
+
+// Добавляет ошибки полей из ошибки валидации (например, ErrInvalidMood).
+// Исходная ошибка остается доступной через errors.Is.
+func (v *fieldErrors) check(err error) {
+	if err == nil {
+		return
+	}
+	message, fields := errs.Details(err)
+	if len(fields) == 0 {
+		fields = []errs.FieldError{{Message: message}}
+	}
+	v.fields = append(v.fields, fields...)
+	v.causes = append(v.causes, err)
+}
+
+func (v *fieldErrors) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	e := errs.Validation("entry is invalid", v.fields...)
+	e.Err = errors.Join(v.causes...)
+	return e
+}
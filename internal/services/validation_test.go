@@ -0,0 +1,89 @@
+package services
+
+import (
+	"diary/internal/errs"
+	"diary/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		multiline bool
+		want      string
+		problem   bool
+	}{
+		{"trims unicode spaces", "  Заголовок　", false, "Заголовок", false},
+		{"composes to NFC", "e\u0301te\u0301", false, "\u00e9t\u00e9", false},
+		{"keeps newlines in content", "line one\r\nline\ttwo\n", true, "line one\nline\ttwo", false},
+		{"rejects newline in title", "two\nlines", false, "", true},
+		{"rejects control characters", "bell\x07", true, "", true},
+		{"rejects invalid UTF-8", "bad \xff byte", true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, problem := normalizeText(tt.input, tt.multiline)
+
+			// Assert
+			assert.Equal(t, tt.problem, problem != "")
+			if !tt.problem {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateEntryCollectsFieldErrors(t *testing.T) {
+	// Arrange
+	limits := EntryLimits{MaxTitleLength: 10, MaxContentLength: 20, MaxTags: 2}
+	entry := &models.Entry{
+		Title:     "   ",
+		Content:   strings.Repeat("a", 21),
+		Mood:      intPtr(9),
+		EntryDate: time.Now(),
+		Tags:      []models.Tag{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+	}
+
+	// Act
+	err := validateEntry(entry, limits)
+
+	// Assert - все ошибки сразу, с исходными ошибками в цепочке
+	require.ErrorIs(t, err, errs.ErrValidation)
+	assert.ErrorIs(t, err, ErrInvalidMood)
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	_, fields := errs.Details(err)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+	assert.Equal(t, []string{"title", "content", "mood", "tags"}, names)
+}
+
+func TestValidateEntryNormalizes(t *testing.T) {
+	// Arrange
+	entry := &models.Entry{
+		Title:     "  Morning  ",
+		Content:   "Coffee\r\nWalk\n",
+		EntryDate: time.Now(),
+		Tags:      []models.Tag{{Name: " Work "}, {Name: "work"}},
+	}
+
+	// Act
+	err := validateEntry(entry, DefaultEntryLimits())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Morning", entry.Title)
+	assert.Equal(t, "Coffee\nWalk", entry.Content)
+	assert.Equal(t, []models.Tag{{Name: "work"}}, entry.Tags)
+	assert.Equal(t, "UTC", entry.TimeZone)
+}